@@ -0,0 +1,16 @@
+package main
+
+import (
+	"log"
+	"os"
+
+	benchCli "go-auth-service/src/interface/cli/bench"
+)
+
+// main menjalankan benchmark hashing password terhadap service yang sedang berjalan.
+// Entry point ini terpisah dari main.go sehingga tidak ikut di binary production.
+func main() {
+	if err := benchCli.Run(os.Args[1:]); err != nil {
+		log.Fatalf("%s: %v", benchCli.Command, err)
+	}
+}
@@ -2,7 +2,8 @@
 CREATE TABLE user_auth (
                            id BIGSERIAL PRIMARY KEY,
                            email VARCHAR(100) NOT NULL UNIQUE,
-                           password VARCHAR(100) NOT NULL,
+                           password VARCHAR(255) NOT NULL,
+                           password_reset_required BOOLEAN NOT NULL DEFAULT FALSE,
                            created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
                            updated_at TIMESTAMP,
                            deleted_at TIMESTAMP
@@ -33,34 +34,122 @@ CREATE TABLE user_detail (
                              CONSTRAINT fk_user_detail_user_type FOREIGN KEY(user_type_id) REFERENCES user_type(id) ON DELETE SET NULL
 );
 
+-- Table: user_session
+CREATE TABLE user_session (
+                              id VARCHAR(64) PRIMARY KEY,
+                              user_id BIGINT NOT NULL,
+                              device_label VARCHAR(100) NOT NULL,
+                              ip_address VARCHAR(45) NOT NULL,
+                              user_agent TEXT NOT NULL,
+                              client_id VARCHAR(100) NOT NULL DEFAULT '',
+                              scope VARCHAR(255) NOT NULL DEFAULT '',
+                              created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                              last_seen_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                              expires_at TIMESTAMP NOT NULL,
+                              revoked_at TIMESTAMP,
+                              revoke_reason VARCHAR(50),
+                              CONSTRAINT fk_session_user FOREIGN KEY(user_id) REFERENCES user_auth(id) ON DELETE CASCADE
+);
+
 -- Table: user_login_history
 CREATE TABLE user_login_history (
                                     id BIGSERIAL PRIMARY KEY,
                                     user_id BIGINT NOT NULL,
+                                    session_id VARCHAR(64),
                                     login_time TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
                                     ip_address VARCHAR(45) NOT NULL,
                                     user_agent TEXT NOT NULL,
                                     logout_time TIMESTAMP,
                                     logout_reason VARCHAR(50),
-                                    CONSTRAINT fk_login_history_user FOREIGN KEY(user_id) REFERENCES user_auth(id) ON DELETE CASCADE
+                                    auth_method VARCHAR(20) NOT NULL DEFAULT 'password',
+                                    CONSTRAINT fk_login_history_user FOREIGN KEY(user_id) REFERENCES user_auth(id) ON DELETE CASCADE,
+                                    CONSTRAINT fk_login_history_session FOREIGN KEY(session_id) REFERENCES user_session(id) ON DELETE SET NULL
 );
 
 -- Table: user_refresh_token
 CREATE TABLE user_refresh_token (
                                     id BIGSERIAL PRIMARY KEY,
                                     user_id BIGINT NOT NULL,
+                                    session_id VARCHAR(64) NOT NULL,
                                     refresh_token_hash VARCHAR(255) NOT NULL,
                                     expires_at TIMESTAMP NOT NULL,
-                                    user_agent TEXT NOT NULL,
                                     is_active BOOLEAN NOT NULL DEFAULT TRUE,
-                                    CONSTRAINT fk_refresh_token_user FOREIGN KEY(user_id) REFERENCES user_auth(id) ON DELETE CASCADE
+                                    used_at TIMESTAMP,
+                                    CONSTRAINT fk_refresh_token_user FOREIGN KEY(user_id) REFERENCES user_auth(id) ON DELETE CASCADE,
+                                    CONSTRAINT fk_refresh_token_session FOREIGN KEY(session_id) REFERENCES user_session(id) ON DELETE CASCADE
+);
+
+-- Table: user_totp
+CREATE TABLE user_totp (
+                           id BIGSERIAL PRIMARY KEY,
+                           user_id BIGINT NOT NULL UNIQUE,
+                           secret TEXT NOT NULL,
+                           confirmed_at TIMESTAMP,
+                           created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                           updated_at TIMESTAMP,
+                           CONSTRAINT fk_user_totp_user FOREIGN KEY(user_id) REFERENCES user_auth(id) ON DELETE CASCADE
+);
+
+-- Table: user_recovery_code
+CREATE TABLE user_recovery_code (
+                                    id BIGSERIAL PRIMARY KEY,
+                                    user_id BIGINT NOT NULL,
+                                    code_hash VARCHAR(64) NOT NULL,
+                                    used_at TIMESTAMP,
+                                    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                                    CONSTRAINT fk_recovery_code_user FOREIGN KEY(user_id) REFERENCES user_auth(id) ON DELETE CASCADE
+);
+
+-- Table: user_password_history
+CREATE TABLE user_password_history (
+                                       id BIGSERIAL PRIMARY KEY,
+                                       user_id BIGINT NOT NULL,
+                                       password_hash VARCHAR(255) NOT NULL,
+                                       created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                                       CONSTRAINT fk_password_history_user FOREIGN KEY(user_id) REFERENCES user_auth(id) ON DELETE CASCADE
+);
+
+-- Table: user_webauthn_credential
+CREATE TABLE user_webauthn_credential (
+                                          id BIGSERIAL PRIMARY KEY,
+                                          user_id BIGINT NOT NULL,
+                                          credential_id VARCHAR(1400) NOT NULL UNIQUE,
+                                          public_key BYTEA NOT NULL,
+                                          sign_count BIGINT NOT NULL DEFAULT 0,
+                                          transports VARCHAR(255) NOT NULL DEFAULT '',
+                                          nickname VARCHAR(100) NOT NULL,
+                                          created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                                          last_used_at TIMESTAMP,
+                                          CONSTRAINT fk_webauthn_credential_user FOREIGN KEY(user_id) REFERENCES user_auth(id) ON DELETE CASCADE
+);
+
+-- Table: oauth_client
+CREATE TABLE oauth_client (
+                              id BIGSERIAL PRIMARY KEY,
+                              client_id VARCHAR(100) NOT NULL UNIQUE,
+                              client_secret_hash VARCHAR(255),
+                              name VARCHAR(100) NOT NULL,
+                              redirect_uris TEXT NOT NULL DEFAULT '',
+                              grant_types VARCHAR(255) NOT NULL,
+                              scopes VARCHAR(255) NOT NULL,
+                              is_active BOOLEAN NOT NULL DEFAULT TRUE,
+                              created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                              updated_at TIMESTAMP,
+                              deleted_at TIMESTAMP
 );
 
 -- Indexes
 CREATE INDEX idx_user_auth_id ON user_auth(id);
 CREATE INDEX idx_user_detail_id ON user_detail(id);
+CREATE INDEX idx_user_session_user_id ON user_session(user_id);
 CREATE INDEX idx_user_login_history_user_id ON user_login_history(user_id);
+CREATE INDEX idx_user_login_history_session_id ON user_login_history(session_id);
 CREATE INDEX idx_user_refresh_token_user_id ON user_refresh_token(user_id);
+CREATE INDEX idx_user_refresh_token_hash ON user_refresh_token(refresh_token_hash);
+CREATE INDEX idx_user_refresh_token_session_id ON user_refresh_token(session_id);
+CREATE INDEX idx_user_recovery_code_user_id ON user_recovery_code(user_id);
+CREATE INDEX idx_user_password_history_user_id ON user_password_history(user_id);
+CREATE INDEX idx_user_webauthn_credential_user_id ON user_webauthn_credential(user_id);
 
 -- Seed data for user_type
 INSERT INTO public.user_type (type) VALUES ('super admin');
@@ -2,6 +2,9 @@ package main
 
 import (
 	"context"
+	"log"
+	"os"
+
 	"github.com/sirupsen/logrus"
 	"go-auth-service/src/infra/broker/nats"
 	natsPub "go-auth-service/src/infra/broker/nats/publisher"
@@ -11,17 +14,35 @@ import (
 
 	usecase "go-auth-service/src/app/usecases"
 	mailUC "go-auth-service/src/app/usecases/mail"
+	oauthUC "go-auth-service/src/app/usecases/oauth"
 	userUC "go-auth-service/src/app/usecases/user"
 	"go-auth-service/src/infra/config"
+	"go-auth-service/src/infra/helper"
 	ms_log "go-auth-service/src/infra/log"
+	"go-auth-service/src/infra/password"
 	postgresDb "go-auth-service/src/infra/persistence/postgres"
 	historyRepo "go-auth-service/src/infra/persistence/postgres/history"
+	oauthClientRepo "go-auth-service/src/infra/persistence/postgres/oauth_client"
+	passwordHistoryRepo "go-auth-service/src/infra/persistence/postgres/password_history"
+	recoveryCodeRepo "go-auth-service/src/infra/persistence/postgres/recovery_code"
 	refreshTokenRepo "go-auth-service/src/infra/persistence/postgres/refresh_token"
+	sessionRepo "go-auth-service/src/infra/persistence/postgres/session"
+	totpRepo "go-auth-service/src/infra/persistence/postgres/totp"
 	userRepo "go-auth-service/src/infra/persistence/postgres/user"
+	webauthnRepo "go-auth-service/src/infra/persistence/postgres/webauthn"
+	"go-auth-service/src/infra/sms"
+	breachCli "go-auth-service/src/interface/cli/breach"
 	"go-auth-service/src/interface/rest"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == breachCli.Command {
+		if err := breachCli.Run(os.Args[2:]); err != nil {
+			log.Fatalf("%s: %v", breachCli.Command, err)
+		}
+		return
+	}
+
 	ctx := context.Background()
 
 	conf := config.Make()
@@ -45,6 +66,42 @@ func main() {
 		ms_log.IsProduction(isProd),
 		ms_log.LogAdditionalFields(m))
 
+	if isProd && len(conf.Jwt.Keys) == 0 {
+		logger.Fatalf("JWT_SIGNING_KEYS must be configured in production")
+	}
+
+	if err := helper.LoadSigningKeys(conf.Jwt); err != nil {
+		logger.Fatalf("Failed to load JWT signing keys: %v", err)
+	}
+
+	if isProd && conf.Secrets.AllowEphemeral {
+		logger.Fatalf("ALLOW_EPHEMERAL_SECRETS must not be enabled in production")
+	}
+
+	if err := helper.LoadSecrets(conf.Secrets); err != nil {
+		logger.Fatalf("Failed to load secrets: %v", err)
+	}
+
+	if err := helper.LoadWebAuthn(conf.WebAuthn); err != nil {
+		logger.Fatalf("Failed to load WebAuthn relying party: %v", err)
+	}
+
+	if err := helper.LoadPasswordHasher(conf.PasswordHash); err != nil {
+		logger.Fatalf("Failed to load password hasher: %v", err)
+	}
+
+	if err := helper.LoadBreachedPasswords(conf.BreachedPassword); err != nil {
+		logger.Fatalf("Failed to load breached passwords: %v", err)
+	}
+
+	smsSender, err := sms.NewSender(conf.Sms)
+	if err != nil {
+		logger.Fatalf("Failed to initialize SMS sender: %v", err)
+	}
+	if isProd && conf.Sms.Provider == "log" {
+		logger.Warnf("SMS_PROVIDER=log only writes OTP codes to a log and must not be used in production")
+	}
+
 	postgresConnection, err := postgresDb.NewConnection(conf.SqlDb.Master, conf.SqlDb.Slave, logger)
 	if err != nil {
 		logger.Fatalf("Failed to connect to PostgreSQL: %v", err)
@@ -67,15 +124,24 @@ func main() {
 
 	redisClient, err := redis.NewRedisClient(conf.Redis, logger)
 	redisService := redisServe.NewServRedis(redisClient)
+	helper.InitTokenDenylist(redisService)
 
 	userRepository := userRepo.NewUserRepository(postgresConnection)
 	historyRepository := historyRepo.NewHistoryRepository(postgresConnection)
 	refreshTokenRepository := refreshTokenRepo.NewRefreshTokenRepository(postgresConnection)
+	sessionRepository := sessionRepo.NewSessionRepository(postgresConnection)
+	totpRepository := totpRepo.NewTotpRepository(postgresConnection)
+	webauthnRepository := webauthnRepo.NewWebauthnRepository(postgresConnection)
+	recoveryCodeRepository := recoveryCodeRepo.NewRecoveryCodeRepository(postgresConnection)
+	passwordHistoryRepository := passwordHistoryRepo.NewPasswordHistoryRepository(postgresConnection)
+	oauthClientRepository := oauthClientRepo.NewOAuthClientRepository(postgresConnection)
 
 	// Inisialisasi use cases
+	userUseCase := userUC.NewUserUseCase(natsPublisher, redisService, userRepository, historyRepository, refreshTokenRepository, sessionRepository, totpRepository, webauthnRepository, recoveryCodeRepository, passwordHistoryRepository, smsSender, password.NewPolicy(conf.PasswordPolicy), conf.EmailVerification.Policy, conf.MagicLink.Enabled, conf.Sms.Login, conf.Sms.Mfa, conf.Lockout)
 	useCaseList := usecase.AllUseCases{
-		UserUC: userUC.NewUserUseCase(natsPublisher, redisService, userRepository, historyRepository, refreshTokenRepository),
-		MailUC: mailUC.NewMailUseCase(redisService, userRepository, historyRepository),
+		UserUC:  userUseCase,
+		MailUC:  mailUC.NewMailUseCase(redisService, userRepository, historyRepository, recoveryCodeRepository),
+		OAuthUC: oauthUC.NewOAuthUseCase(redisService, oauthClientRepository, refreshTokenRepository, userUseCase),
 	}
 
 	// * worker initialization *
@@ -83,6 +149,10 @@ func main() {
 
 	httpServer, err := rest.New(
 		conf.Http,
+		conf.Page,
+		conf.EmailVerification,
+		conf.RateLimit,
+		redisService,
 		isProd,
 		logger,
 		useCaseList,
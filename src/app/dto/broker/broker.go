@@ -2,7 +2,12 @@ package broker
 
 type AuthBrokerDto struct {
 	UserId    int64  `json:"user_id"`
+	SessionId string `json:"session_id,omitempty"`
 	IpAddress string `json:"ip_adress"`
 	Device    string `json:"device"`
 	Event     string `json:"event"`
+
+	AuthMethod    string `json:"auth_method,omitempty"`
+	DeviceBinding string `json:"device_binding,omitempty"`
+	LockedUntil   int64  `json:"locked_until,omitempty"`
 }
@@ -0,0 +1,55 @@
+package oauth
+
+import (
+	validation "github.com/go-ozzo/ozzo-validation"
+	"regexp"
+
+	"go-auth-service/src/infra/constants/common"
+)
+
+type AuthorizeReqInterface interface {
+	Validate() error
+}
+
+// AuthorizeReq berisi parameter authorization request (RFC 6749 4.1.1, RFC 7636 4.3)
+type AuthorizeReq struct {
+	ResponseType        string `json:"response_type"`
+	ClientId            string `json:"client_id"`
+	RedirectUri         string `json:"redirect_uri"`
+	Scope               string `json:"scope"`
+	State               string `json:"state"`
+	Nonce               string `json:"nonce"`
+	CodeChallenge       string `json:"code_challenge"`
+	CodeChallengeMethod string `json:"code_challenge_method"`
+}
+
+var codeChallengeRegex = regexp.MustCompile(`^[A-Za-z0-9\-._~]{43,128}$`)
+
+func (dto *AuthorizeReq) Validate() error {
+	return validation.ValidateStruct(
+		dto,
+		validation.Field(&dto.ResponseType, validation.Required, validation.In(common.ResponseTypeCode).Error("response_type must be 'code'")),
+		validation.Field(&dto.Scope, validation.Required),
+		validation.Field(
+			&dto.CodeChallenge,
+			validation.Required,
+			validation.Match(codeChallengeRegex).Error("code_challenge must be 43-128 characters of [A-Za-z0-9-._~]"),
+		),
+		validation.Field(&dto.CodeChallengeMethod, validation.Required, validation.In(common.CodeChallengeS256).Error("code_challenge_method must be 'S256'")),
+	)
+}
+
+// AuthorizationCode adalah data yang disimpan di Redis untuk satu authorization code. Sesi dan
+// token baru dibuat saat code ditukar, sehingga yang disimpan hanya hasil autentikasi user.
+type AuthorizationCode struct {
+	ClientId      string `json:"client_id"`
+	RedirectUri   string `json:"redirect_uri"`
+	Scope         string `json:"scope"`
+	Nonce         string `json:"nonce"`
+	CodeChallenge string `json:"code_challenge"`
+	UserId        int64  `json:"user_id"`
+	AuthMethod    string `json:"auth_method"`
+	AuthTime      int64  `json:"auth_time"`
+	IpAddress     string `json:"ip_address"`
+	UserAgent     string `json:"user_agent"`
+}
@@ -0,0 +1,46 @@
+package oauth
+
+import (
+	validation "github.com/go-ozzo/ozzo-validation"
+
+	"go-auth-service/src/infra/constants/common"
+)
+
+type IntrospectReqInterface interface {
+	Validate() error
+}
+
+// IntrospectReq berisi parameter introspection request (RFC 7662 2.1)
+type IntrospectReq struct {
+	Token         string `json:"token"`
+	TokenTypeHint string `json:"token_type_hint"`
+	ClientId      string `json:"client_id"`
+	ClientSecret  string `json:"client_secret"`
+}
+
+func (dto *IntrospectReq) Validate() error {
+	return validation.ValidateStruct(
+		dto,
+		validation.Field(&dto.Token, validation.Required),
+		validation.Field(&dto.TokenTypeHint, validation.In(common.TokenTypeHintAccess, common.TokenTypeHintRefresh)),
+		validation.Field(&dto.ClientId, validation.Required),
+	)
+}
+
+// IntrospectResp adalah response introspection (RFC 7662 2.2). Token yang tidak aktif
+// hanya mengembalikan active=false.
+type IntrospectResp struct {
+	Active      bool   `json:"active"`
+	Scope       string `json:"scope,omitempty"`
+	ClientId    string `json:"client_id,omitempty"`
+	Username    string `json:"username,omitempty"`
+	TokenType   string `json:"token_type,omitempty"`
+	Exp         int64  `json:"exp,omitempty"`
+	Iat         int64  `json:"iat,omitempty"`
+	Sub         string `json:"sub,omitempty"`
+	Aud         string `json:"aud,omitempty"`
+	Iss         string `json:"iss,omitempty"`
+	SubjectType string `json:"sub_type,omitempty"`
+	Email       string `json:"email,omitempty"`
+	Sid         string `json:"sid,omitempty"`
+}
@@ -0,0 +1,82 @@
+package oauth
+
+import (
+	validation "github.com/go-ozzo/ozzo-validation"
+
+	"go-auth-service/src/infra/constants/common"
+)
+
+type TokenReqInterface interface {
+	Validate() error
+}
+
+// TokenReq berisi parameter token request (RFC 6749 4.1.3 dan 6)
+type TokenReq struct {
+	GrantType    string `json:"grant_type"`
+	Code         string `json:"code"`
+	RedirectUri  string `json:"redirect_uri"`
+	CodeVerifier string `json:"code_verifier"`
+	RefreshToken string `json:"refresh_token"`
+	Scope        string `json:"scope"`
+	ClientId     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+}
+
+func (dto *TokenReq) Validate() error {
+	fields := []*validation.FieldRules{
+		validation.Field(&dto.GrantType, validation.Required),
+		validation.Field(&dto.ClientId, validation.Required),
+	}
+
+	switch dto.GrantType {
+	case common.GrantTypeAuthorizationCode:
+		fields = append(fields,
+			validation.Field(&dto.Code, validation.Required),
+			validation.Field(&dto.RedirectUri, validation.Required),
+			validation.Field(&dto.CodeVerifier, validation.Required, validation.Match(codeChallengeRegex).Error("code_verifier must be 43-128 characters of [A-Za-z0-9-._~]")),
+		)
+	case common.GrantTypeRefreshToken:
+		fields = append(fields, validation.Field(&dto.RefreshToken, validation.Required))
+	}
+
+	return validation.ValidateStruct(dto, fields...)
+}
+
+type TokenResp struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	IdToken      string `json:"id_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+}
+
+type UserInfoResp struct {
+	Sub           string `json:"sub"`
+	Email         string `json:"email,omitempty"`
+	EmailVerified *bool  `json:"email_verified,omitempty"`
+	Name          string `json:"name,omitempty"`
+	GivenName     string `json:"given_name,omitempty"`
+	FamilyName    string `json:"family_name,omitempty"`
+	Picture       string `json:"picture,omitempty"`
+	Gender        string `json:"gender,omitempty"`
+	Birthdate     string `json:"birthdate,omitempty"`
+}
+
+// OpenIDConfiguration adalah discovery document OpenID Connect
+type OpenIDConfiguration struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	UserinfoEndpoint                  string   `json:"userinfo_endpoint"`
+	IntrospectionEndpoint             string   `json:"introspection_endpoint"`
+	JwksUri                           string   `json:"jwks_uri"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
+	IdTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+	ScopesSupported                   []string `json:"scopes_supported"`
+	ClaimsSupported                   []string `json:"claims_supported"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+}
@@ -9,13 +9,19 @@ type LoginReqInterface interface {
 	Validate() error
 }
 
+// LoginResp berisi token, atau MfaToken jika akun memakai TOTP/SMS OTP dan login
+// harus dilanjutkan ke POST /login/mfa. MfaMethod menentukan code yang diminta.
 type LoginResp struct {
-	AccessToken  string `json:"access_token"`
-	RefreshToken string `json:"refresh_token"`
+	AccessToken  string `json:"access_token,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	MfaRequired  bool   `json:"mfa_required,omitempty"`
+	MfaToken     string `json:"mfa_token,omitempty"`
+	MfaMethod    string `json:"mfa_method,omitempty"`
 }
 
 type RefreshTokenResp struct {
-	AccessToken string `json:"access_token"`
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
 }
 
 type LoginReq struct {
@@ -0,0 +1,40 @@
+package user
+
+import validation "github.com/go-ozzo/ozzo-validation"
+
+// MagicLink adalah isi token pada link login. Nonce dipakai untuk memastikan link hanya
+// bisa dipakai sekali, DeviceHash dan UserAgentHash mengikat link ke perangkat peminta.
+type MagicLink struct {
+	UserId        int64  `json:"uid"`
+	Nonce         string `json:"nonce"`
+	DeviceHash    string `json:"dev,omitempty"`
+	UserAgentHash string `json:"ua"`
+}
+
+type MagicLinkReq struct {
+	Email string `json:"email"`
+}
+
+func (dto *MagicLinkReq) Validate() error {
+	return validation.ValidateStruct(
+		dto,
+		validation.Field(&dto.Email, validation.Required, validation.Match(emailRegex).Error("invalid email format")),
+	)
+}
+
+// MagicLinkResp berisi device token yang harus disimpan client dan dikirim kembali saat link dibuka
+type MagicLinkResp struct {
+	DeviceToken string `json:"device_token"`
+}
+
+type MagicLinkLoginReq struct {
+	Token       string `json:"token"`
+	DeviceToken string `json:"device_token"`
+}
+
+func (dto *MagicLinkLoginReq) Validate() error {
+	return validation.ValidateStruct(
+		dto,
+		validation.Field(&dto.Token, validation.Required),
+	)
+}
@@ -0,0 +1,68 @@
+package user
+
+import (
+	"errors"
+	validation "github.com/go-ozzo/ozzo-validation"
+	"regexp"
+)
+
+var totpCodeRegex = regexp.MustCompile(`^[0-9]{6}$`)
+
+type TotpEnrollResp struct {
+	Secret     string `json:"secret"`
+	OtpauthUri string `json:"otpauth_uri"`
+	QrCode     string `json:"qr_code"` // data URI image/png
+}
+
+type TotpConfirmReqInterface interface {
+	Validate() error
+}
+
+type TotpConfirmReq struct {
+	Code string `json:"code"`
+}
+
+func (dto *TotpConfirmReq) Validate() error {
+	return validation.ValidateStruct(
+		dto,
+		validation.Field(&dto.Code, validation.Required, validation.Match(totpCodeRegex).Error("code must be 6 digits")),
+	)
+}
+
+// MfaChallenge adalah data challenge langkah kedua login yang disimpan di Redis
+type MfaChallenge struct {
+	UserId int64  `json:"uid"`
+	Method string `json:"method"`
+}
+
+type LoginMfaReqInterface interface {
+	Validate() error
+}
+
+// LoginMfaReq berisi code TOTP atau recovery code, tidak keduanya
+type LoginMfaReq struct {
+	MfaToken     string `json:"mfa_token"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+func (dto *LoginMfaReq) Validate() error {
+	if err := validation.ValidateStruct(
+		dto,
+		validation.Field(&dto.MfaToken, validation.Required),
+		validation.Field(&dto.Code, validation.Match(totpCodeRegex).Error("code must be 6 digits")),
+		validation.Field(&dto.RecoveryCode, validation.Length(10, 20)),
+	); err != nil {
+		return err
+	}
+
+	if (dto.Code == "") == (dto.RecoveryCode == "") {
+		return errors.New("either code or recovery_code is required")
+	}
+
+	return nil
+}
+
+type RecoveryCodesResp struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
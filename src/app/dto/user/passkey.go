@@ -0,0 +1,144 @@
+package user
+
+import (
+	validation "github.com/go-ozzo/ozzo-validation"
+)
+
+// Options dan response passkey mengikuti format JSON WebAuthn Level 3
+// (PublicKeyCredential.parseCreationOptionsFromJSON / toJSON); data biner dalam base64url.
+
+type PasskeyRelyingParty struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type PasskeyUser struct {
+	Id          string `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+type PasskeyCredentialParam struct {
+	Type string `json:"type"`
+	Alg  int64  `json:"alg"`
+}
+
+type PasskeyDescriptor struct {
+	Type       string   `json:"type"`
+	Id         string   `json:"id"`
+	Transports []string `json:"transports,omitempty"`
+}
+
+type PasskeyAuthenticatorSelection struct {
+	ResidentKey      string `json:"residentKey"`
+	UserVerification string `json:"userVerification"`
+}
+
+type PasskeyRegisterOptions struct {
+	Challenge              string                        `json:"challenge"`
+	Rp                     PasskeyRelyingParty           `json:"rp"`
+	User                   PasskeyUser                   `json:"user"`
+	PubKeyCredParams       []PasskeyCredentialParam      `json:"pubKeyCredParams"`
+	Timeout                int64                         `json:"timeout"`
+	Attestation            string                        `json:"attestation"`
+	AuthenticatorSelection PasskeyAuthenticatorSelection `json:"authenticatorSelection"`
+	ExcludeCredentials     []PasskeyDescriptor           `json:"excludeCredentials"`
+}
+
+type PasskeyLoginOptions struct {
+	Challenge        string              `json:"challenge"`
+	RpId             string              `json:"rpId"`
+	Timeout          int64               `json:"timeout"`
+	UserVerification string              `json:"userVerification"`
+	AllowCredentials []PasskeyDescriptor `json:"allowCredentials"`
+}
+
+// PasskeyChallenge disimpan di Redis selama ceremony berjalan. UserId 0 pada login
+// berarti passkey discoverable milik user mana pun boleh dipakai.
+type PasskeyChallenge struct {
+	UserId   int64  `json:"uid"`
+	Ceremony string `json:"ceremony"`
+}
+
+type PasskeyRegisterReqInterface interface {
+	Validate() error
+}
+
+type PasskeyAttestationResponse struct {
+	ClientDataJSON    string   `json:"clientDataJSON"`
+	AttestationObject string   `json:"attestationObject"`
+	Transports        []string `json:"transports"`
+}
+
+type PasskeyRegisterReq struct {
+	Id       string                     `json:"id"`
+	Nickname string                     `json:"nickname"`
+	Response PasskeyAttestationResponse `json:"response"`
+}
+
+func (dto *PasskeyRegisterReq) Validate() error {
+	if err := validation.ValidateStruct(
+		dto,
+		validation.Field(&dto.Id, validation.Required),
+		validation.Field(&dto.Nickname, validation.Length(0, 100)),
+	); err != nil {
+		return err
+	}
+
+	return validation.ValidateStruct(
+		&dto.Response,
+		validation.Field(&dto.Response.ClientDataJSON, validation.Required),
+		validation.Field(&dto.Response.AttestationObject, validation.Required),
+	)
+}
+
+type PasskeyLoginBeginReq struct {
+	Email string `json:"email"`
+}
+
+func (dto *PasskeyLoginBeginReq) Validate() error {
+	return validation.ValidateStruct(
+		dto,
+		validation.Field(&dto.Email, validation.Match(emailRegex).Error("invalid email format")),
+	)
+}
+
+type PasskeyLoginReqInterface interface {
+	Validate() error
+}
+
+type PasskeyAssertionResponse struct {
+	ClientDataJSON    string `json:"clientDataJSON"`
+	AuthenticatorData string `json:"authenticatorData"`
+	Signature         string `json:"signature"`
+	UserHandle        string `json:"userHandle"`
+}
+
+type PasskeyLoginReq struct {
+	Id       string                   `json:"id"`
+	Response PasskeyAssertionResponse `json:"response"`
+}
+
+func (dto *PasskeyLoginReq) Validate() error {
+	if err := validation.ValidateStruct(
+		dto,
+		validation.Field(&dto.Id, validation.Required),
+	); err != nil {
+		return err
+	}
+
+	return validation.ValidateStruct(
+		&dto.Response,
+		validation.Field(&dto.Response.ClientDataJSON, validation.Required),
+		validation.Field(&dto.Response.AuthenticatorData, validation.Required),
+		validation.Field(&dto.Response.Signature, validation.Required),
+	)
+}
+
+type PasskeyResp struct {
+	Id         int64    `json:"id"`
+	Nickname   string   `json:"nickname"`
+	Transports []string `json:"transports"`
+	CreatedAt  string   `json:"created_at"`
+	LastUsedAt string   `json:"last_used_at"`
+}
@@ -0,0 +1,44 @@
+package user
+
+import (
+	validation "github.com/go-ozzo/ozzo-validation"
+)
+
+type ForgotPasswordReqInterface interface {
+	Validate() error
+}
+
+type ForgotPasswordReq struct {
+	Email string `json:"email"`
+}
+
+func (dto *ForgotPasswordReq) Validate() error {
+	return validation.ValidateStruct(
+		dto,
+		validation.Field(&dto.Email, validation.Required, validation.Match(emailRegex).Error("invalid email format")),
+	)
+}
+
+type ResetPasswordReqInterface interface {
+	Validate() error
+}
+
+type ResetPasswordReq struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
+}
+
+func (dto *ResetPasswordReq) Validate() error {
+	if err := validation.ValidateStruct(
+		dto,
+		validation.Field(&dto.Token, validation.Required),
+		validation.Field(
+			&dto.NewPassword,
+			validation.Required,
+		),
+	); err != nil {
+		return err
+	}
+
+	return nil
+}
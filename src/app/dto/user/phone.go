@@ -0,0 +1,54 @@
+package user
+
+import (
+	validation "github.com/go-ozzo/ozzo-validation"
+	"regexp"
+)
+
+// phoneRegex adalah format E.164: tanda +, kode negara lalu maksimal 15 digit
+var phoneRegex = regexp.MustCompile(`^\+[1-9][0-9]{7,14}$`)
+
+// SmsOtp adalah OTP yang disimpan di Redis. Code hanya disimpan dalam bentuk hash.
+type SmsOtp struct {
+	Phone    string `json:"phone"`
+	CodeHash string `json:"code_hash"`
+}
+
+type PhoneReqInterface interface {
+	Validate() error
+}
+
+type PhoneReq struct {
+	Phone string `json:"phone"`
+}
+
+func (dto *PhoneReq) Validate() error {
+	return validation.ValidateStruct(
+		dto,
+		validation.Field(&dto.Phone, validation.Required, validation.Match(phoneRegex).Error("phone must be in E.164 format, e.g. +6281234567890")),
+	)
+}
+
+type PhoneVerifyReq struct {
+	Code string `json:"code"`
+}
+
+func (dto *PhoneVerifyReq) Validate() error {
+	return validation.ValidateStruct(
+		dto,
+		validation.Field(&dto.Code, validation.Required, validation.Match(totpCodeRegex).Error("code must be 6 digits")),
+	)
+}
+
+type SmsLoginReq struct {
+	Phone string `json:"phone"`
+	Code  string `json:"code"`
+}
+
+func (dto *SmsLoginReq) Validate() error {
+	return validation.ValidateStruct(
+		dto,
+		validation.Field(&dto.Phone, validation.Required, validation.Match(phoneRegex).Error("phone must be in E.164 format, e.g. +6281234567890")),
+		validation.Field(&dto.Code, validation.Required, validation.Match(totpCodeRegex).Error("code must be 6 digits")),
+	)
+}
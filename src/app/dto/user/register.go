@@ -16,6 +16,7 @@ type RegisterReq struct {
 	Password  string `json:"password"`
 }
 
+// Validate hanya memeriksa format request, aturan password diperiksa oleh password.PasswordPolicy
 func (dto *RegisterReq) Validate() error {
 	if err := validation.ValidateStruct(
 		dto,
@@ -37,11 +38,6 @@ func (dto *RegisterReq) Validate() error {
 		validation.Field(
 			&dto.Password,
 			validation.Required,
-			validation.Length(8, 20).Error("password must be between 8 and 20 characters"),
-			validation.Match(regexp.MustCompile(`[A-Z]`)).Error("password must contain at least one uppercase letter"),
-			validation.Match(regexp.MustCompile(`[a-z]`)).Error("password must contain at least one lowercase letter"),
-			validation.Match(regexp.MustCompile(`[0-9]`)).Error("password must contain at least one number"),
-			validation.Match(regexp.MustCompile(`[\W_]+`)).Error("password must contain at least one special character (e.g., @, #, $, %, etc.)"),
 		),
 	); err != nil {
 		return err
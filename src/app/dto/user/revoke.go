@@ -0,0 +1,9 @@
+package user
+
+// RevokeLink adalah isi link "this wasn't me" pada email login. Nonce dipakai
+// sebagai key Redis sehingga link hanya bisa dipakai sekali.
+type RevokeLink struct {
+	UserId    int64  `json:"uid"`
+	SessionId string `json:"sid"`
+	Nonce     string `json:"nonce"`
+}
@@ -0,0 +1,12 @@
+package user
+
+type SessionResp struct {
+	Id           string `json:"id"`
+	Device       string `json:"device"`
+	IpAddress    string `json:"ip_address"`
+	UserAgent    string `json:"user_agent"`
+	LoginTime    string `json:"login_time"`
+	LastActivity string `json:"last_activity"`
+	ExpiresAt    string `json:"expires_at"`
+	Current      bool   `json:"current"`
+}
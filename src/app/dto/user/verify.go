@@ -0,0 +1,25 @@
+package user
+
+import validation "github.com/go-ozzo/ozzo-validation"
+
+// VerifyEmailLink adalah isi token pada link verifikasi email. Email ikut disimpan
+// sehingga link tidak berlaku lagi jika email akun sudah berubah.
+type VerifyEmailLink struct {
+	UserId int64  `json:"uid"`
+	Email  string `json:"email"`
+}
+
+type ResendVerificationReqInterface interface {
+	Validate() error
+}
+
+type ResendVerificationReq struct {
+	Email string `json:"email"`
+}
+
+func (dto *ResendVerificationReq) Validate() error {
+	return validation.ValidateStruct(
+		dto,
+		validation.Field(&dto.Email, validation.Required, validation.Match(emailRegex).Error("invalid email format")),
+	)
+}
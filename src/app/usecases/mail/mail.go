@@ -2,37 +2,53 @@ package mail
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
 	"html/template"
+	"net/url"
 	"os"
+	"strconv"
 	"time"
 
+	"go-auth-service/src/app/dto/user"
+	"go-auth-service/src/infra/constants/common"
 	"go-auth-service/src/infra/helper"
 	repoHitory "go-auth-service/src/infra/persistence/postgres/history"
+	repoRecoveryCode "go-auth-service/src/infra/persistence/postgres/recovery_code"
 	repoUser "go-auth-service/src/infra/persistence/postgres/user"
 	redis "go-auth-service/src/infra/persistence/redis/service"
 )
 
 type MailUCInterface interface {
-	SendMailLogin(userId int64, ipAddress, userAgent string) error
+	SendMailLogin(userId int64, sessionId, ipAddress, userAgent, authMethod string) error
 	SendMailRegister(userId int64) error
 	SendMailUpdatePassword(userId int64) error
+	SendMailRefreshTokenReuse(userId int64, userAgent string) error
+	SendMailResetPassword(userId int64) error
+	SendMailVerification(userId int64) error
+	SendMailRecoveryCodeUsed(userId int64, ipAddress, userAgent string) error
+	SendMailMagicLink(userId int64, deviceBinding, ipAddress, userAgent string) error
+	SendMailAccountLocked(userId int64, ipAddress string, lockedUntil int64) error
 }
 
 type MailUseCase struct {
-	Redis       redis.ServRedisInterface
-	RepoUser    repoUser.UserRepository
-	RepoHistory repoHitory.HistoryRepository
+	Redis            redis.ServRedisInterface
+	RepoUser         repoUser.UserRepository
+	RepoHistory      repoHitory.HistoryRepository
+	RepoRecoveryCode repoRecoveryCode.RecoveryCodeRepository
 }
 
-func NewMailUseCase(redisService redis.ServRedisInterface, repoUser repoUser.UserRepository, repoHistory repoHitory.HistoryRepository) *MailUseCase {
+func NewMailUseCase(redisService redis.ServRedisInterface, repoUser repoUser.UserRepository, repoHistory repoHitory.HistoryRepository, repoRecoveryCode repoRecoveryCode.RecoveryCodeRepository) *MailUseCase {
 	return &MailUseCase{
-		Redis:       redisService,
-		RepoUser:    repoUser,
-		RepoHistory: repoHistory,
+		Redis:            redisService,
+		RepoUser:         repoUser,
+		RepoHistory:      repoHistory,
+		RepoRecoveryCode: repoRecoveryCode,
 	}
 }
 
-func (uc *MailUseCase) SendMailLogin(userId int64, ipAddress, userAgent string) error {
+func (uc *MailUseCase) SendMailLogin(userId int64, sessionId, ipAddress, userAgent, authMethod string) error {
 	users, err := uc.RepoUser.GetUserDetailById(userId)
 	if err != nil {
 		return err
@@ -43,12 +59,19 @@ func (uc *MailUseCase) SendMailLogin(userId int64, ipAddress, userAgent string)
 		name = users.FirstName + " " + users.LastName
 	}
 
+	revokeLink, err := uc.revokeLink(userId, sessionId)
+	if err != nil {
+		return err
+	}
+
 	dataEmail := map[string]interface{}{
 		"name":                name,
 		"ip_address":          ipAddress,
 		"user_agent":          userAgent,
 		"login_time":          time.Now().Format("02 Jan 2006 15:04:05"),
 		"reset_password_link": os.Getenv("URL_RESET_PASSWORD"),
+		"revoke_link":         revokeLink,
+		"revoke_all_link":     revokeLink + "?all=true",
 	}
 
 	file := os.Getenv("PATH_EMAIL_TEMPLATE") + "login.html"
@@ -71,11 +94,45 @@ func (uc *MailUseCase) SendMailLogin(userId int64, ipAddress, userAgent string)
 		return err
 	}
 
-	_ = uc.RepoHistory.Create(userId, ipAddress, userAgent)
+	if authMethod == "" {
+		authMethod = common.AuthMethodPassword
+	}
+
+	_ = uc.RepoHistory.Create(userId, sessionId, ipAddress, userAgent, authMethod)
 
 	return nil
 }
 
+// revokeLink membuat link "this wasn't me" sekali pakai untuk sesi login tertentu
+func (uc *MailUseCase) revokeLink(userId int64, sessionId string) (string, error) {
+	nonce, err := helper.RandomToken(16)
+	if err != nil {
+		return "", err
+	}
+
+	payload, err := json.Marshal(user.RevokeLink{
+		UserId:    userId,
+		SessionId: sessionId,
+		Nonce:     nonce,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	token, err := helper.Encrypt(common.PurposeRevokeLink, string(payload), common.RevokeTokenExp)
+	if err != nil {
+		return "", err
+	}
+
+	revokeTokenKey := fmt.Sprintf("%s:%s", common.RevokeTokenKey, nonce)
+	err = uc.Redis.SetData(context.Background(), revokeTokenKey, sessionId, common.RevokeTokenExp)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s/api/auth/revoke-token/%s", os.Getenv("URL_API"), token), nil
+}
+
 func (uc *MailUseCase) SendMailRegister(userId int64) error {
 	users, err := uc.RepoUser.GetUserDetailById(userId)
 	if err != nil {
@@ -100,10 +157,17 @@ func (uc *MailUseCase) SendMailRegister(userId int64) error {
 	}
 	formattedDate := parsedTime.Format("02 Jan 2006 15:04:05")
 
+	verifyLink, err := uc.verifyLink(userId, users.Email)
+	if err != nil {
+		return err
+	}
+
 	dataEmail := map[string]interface{}{
 		"name":              name,
 		"email":             users.Email,
 		"registration_date": formattedDate,
+		"verify_link":       verifyLink,
+		"expires_in":        int(common.VerifyEmailExp.Hours()),
 	}
 
 	var buffer bytes.Buffer
@@ -159,3 +223,365 @@ func (uc *MailUseCase) SendMailUpdatePassword(userId int64) error {
 
 	return nil
 }
+
+func (uc *MailUseCase) SendMailRefreshTokenReuse(userId int64, userAgent string) error {
+	users, err := uc.RepoUser.GetUserDetailById(userId)
+	if err != nil {
+		return err
+	}
+
+	name := users.FirstName
+	if users.LastName != "" {
+		name = users.FirstName + " " + users.LastName
+	}
+
+	fileBody := os.Getenv("PATH_EMAIL_TEMPLATE") + "refresh-token-reuse.html"
+
+	tmpl, err := template.ParseFiles(fileBody)
+	if err != nil {
+		return err
+	}
+
+	dataEmail := map[string]interface{}{
+		"name":                name,
+		"user_agent":          userAgent,
+		"detected_time":       time.Now().Format("02 Jan 2006 15:04:05"),
+		"reset_password_link": os.Getenv("URL_RESET_PASSWORD"),
+	}
+
+	var buffer bytes.Buffer
+
+	if err = tmpl.Execute(&buffer, dataEmail); err != nil {
+		return err
+	}
+
+	emailBody := buffer.String()
+
+	err = helper.SendMail(users.Email, "Suspicious Activity Detected on Your Account", emailBody)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SendMailResetPassword membuat token reset password sekali pakai lalu mengirimkannya ke user.
+// Token hanya disimpan dalam bentuk hash dan token sebelumnya milik user langsung tidak berlaku.
+func (uc *MailUseCase) SendMailResetPassword(userId int64) error {
+	users, err := uc.RepoUser.GetUserDetailById(userId)
+	if err != nil {
+		return err
+	}
+
+	name := users.FirstName
+	if users.LastName != "" {
+		name = users.FirstName + " " + users.LastName
+	}
+
+	token, err := helper.RandomToken(32)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	hashed := helper.HashToken(token)
+	userResetKey := fmt.Sprintf("%s:%d", common.ResetPasswordUserKey, userId)
+
+	previous, _ := uc.Redis.GetData(ctx, userResetKey)
+	if previous != "" {
+		_ = uc.Redis.DeleteData(ctx, fmt.Sprintf("%s:%s", common.ResetPasswordKey, previous))
+	}
+
+	err = uc.Redis.SetData(ctx, fmt.Sprintf("%s:%s", common.ResetPasswordKey, hashed), strconv.FormatInt(userId, 10), common.ResetPasswordExp)
+	if err != nil {
+		return err
+	}
+
+	err = uc.Redis.SetData(ctx, userResetKey, hashed, common.ResetPasswordExp)
+	if err != nil {
+		return err
+	}
+
+	resetLink, err := url.Parse(os.Getenv("URL_RESET_PASSWORD"))
+	if err != nil {
+		return err
+	}
+	query := resetLink.Query()
+	query.Set("token", token)
+	resetLink.RawQuery = query.Encode()
+
+	fileBody := os.Getenv("PATH_EMAIL_TEMPLATE") + "reset-password.html"
+
+	tmpl, err := template.ParseFiles(fileBody)
+	if err != nil {
+		return err
+	}
+
+	dataEmail := map[string]interface{}{
+		"name":                name,
+		"reset_password_link": resetLink.String(),
+		"expires_in":          int(common.ResetPasswordExp.Minutes()),
+	}
+
+	var buffer bytes.Buffer
+
+	if err = tmpl.Execute(&buffer, dataEmail); err != nil {
+		return err
+	}
+
+	emailBody := buffer.String()
+
+	err = helper.SendMail(users.Email, "Reset Your Password", emailBody)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SendMailVerification mengirim ulang link verifikasi email
+func (uc *MailUseCase) SendMailVerification(userId int64) error {
+	users, err := uc.RepoUser.GetUserDetailById(userId)
+	if err != nil {
+		return err
+	}
+
+	name := users.FirstName
+	if users.LastName != "" {
+		name = users.FirstName + " " + users.LastName
+	}
+
+	verifyLink, err := uc.verifyLink(userId, users.Email)
+	if err != nil {
+		return err
+	}
+
+	fileBody := os.Getenv("PATH_EMAIL_TEMPLATE") + "verify-email.html"
+
+	tmpl, err := template.ParseFiles(fileBody)
+	if err != nil {
+		return err
+	}
+
+	dataEmail := map[string]interface{}{
+		"name":        name,
+		"verify_link": verifyLink,
+		"expires_in":  int(common.VerifyEmailExp.Hours()),
+	}
+
+	var buffer bytes.Buffer
+
+	if err = tmpl.Execute(&buffer, dataEmail); err != nil {
+		return err
+	}
+
+	emailBody := buffer.String()
+
+	err = helper.SendMail(users.Email, "Verify Your Email Address", emailBody)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SendMailRecoveryCodeUsed memberi tahu user bahwa recovery code dipakai untuk login
+// beserta sisa recovery code yang belum terpakai
+func (uc *MailUseCase) SendMailRecoveryCodeUsed(userId int64, ipAddress, userAgent string) error {
+	users, err := uc.RepoUser.GetUserDetailById(userId)
+	if err != nil {
+		return err
+	}
+
+	name := users.FirstName
+	if users.LastName != "" {
+		name = users.FirstName + " " + users.LastName
+	}
+
+	remaining, err := uc.RepoRecoveryCode.CountUnusedByUserId(userId)
+	if err != nil {
+		return err
+	}
+
+	fileBody := os.Getenv("PATH_EMAIL_TEMPLATE") + "recovery-code-used.html"
+
+	tmpl, err := template.ParseFiles(fileBody)
+	if err != nil {
+		return err
+	}
+
+	dataEmail := map[string]interface{}{
+		"name":                name,
+		"ip_address":          ipAddress,
+		"user_agent":          userAgent,
+		"used_time":           time.Now().Format("02 Jan 2006 15:04:05"),
+		"remaining":           remaining,
+		"reset_password_link": os.Getenv("URL_RESET_PASSWORD"),
+	}
+
+	var buffer bytes.Buffer
+
+	if err = tmpl.Execute(&buffer, dataEmail); err != nil {
+		return err
+	}
+
+	emailBody := buffer.String()
+
+	err = helper.SendMail(users.Email, "A Recovery Code Was Used to Sign In", emailBody)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SendMailMagicLink membuat link login sekali pakai yang terikat ke perangkat peminta.
+// Link sebelumnya milik user langsung tidak berlaku.
+func (uc *MailUseCase) SendMailMagicLink(userId int64, deviceBinding, ipAddress, userAgent string) error {
+	users, err := uc.RepoUser.GetUserDetailById(userId)
+	if err != nil {
+		return err
+	}
+
+	name := users.FirstName
+	if users.LastName != "" {
+		name = users.FirstName + " " + users.LastName
+	}
+
+	nonce, err := helper.RandomToken(16)
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(user.MagicLink{
+		UserId:        userId,
+		Nonce:         nonce,
+		DeviceHash:    deviceBinding,
+		UserAgentHash: helper.HashToken(userAgent),
+	})
+	if err != nil {
+		return err
+	}
+
+	token, err := helper.Encrypt(common.PurposeMagicLink, string(payload), common.MagicLinkExp)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	userMagicLinkKey := fmt.Sprintf("%s:%d", common.MagicLinkUserKey, userId)
+
+	previous, _ := uc.Redis.GetData(ctx, userMagicLinkKey)
+	if previous != "" {
+		_ = uc.Redis.DeleteData(ctx, fmt.Sprintf("%s:%s", common.MagicLinkKey, previous))
+	}
+
+	err = uc.Redis.SetData(ctx, fmt.Sprintf("%s:%s", common.MagicLinkKey, nonce), strconv.FormatInt(userId, 10), common.MagicLinkExp)
+	if err != nil {
+		return err
+	}
+
+	err = uc.Redis.SetData(ctx, userMagicLinkKey, nonce, common.MagicLinkExp)
+	if err != nil {
+		return err
+	}
+
+	magicLink, err := url.Parse(os.Getenv("URL_MAGIC_LINK"))
+	if err != nil {
+		return err
+	}
+	query := magicLink.Query()
+	query.Set("token", token)
+	magicLink.RawQuery = query.Encode()
+
+	fileBody := os.Getenv("PATH_EMAIL_TEMPLATE") + "magic-link.html"
+
+	tmpl, err := template.ParseFiles(fileBody)
+	if err != nil {
+		return err
+	}
+
+	dataEmail := map[string]interface{}{
+		"name":       name,
+		"login_link": magicLink.String(),
+		"ip_address": ipAddress,
+		"user_agent": userAgent,
+		"expires_in": int(common.MagicLinkExp.Minutes()),
+	}
+
+	var buffer bytes.Buffer
+
+	if err = tmpl.Execute(&buffer, dataEmail); err != nil {
+		return err
+	}
+
+	emailBody := buffer.String()
+
+	err = helper.SendMail(users.Email, "Your Sign-In Link", emailBody)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SendMailAccountLocked memberi tahu user bahwa akun dikunci sementara setelah
+// terlalu banyak percobaan login gagal
+func (uc *MailUseCase) SendMailAccountLocked(userId int64, ipAddress string, lockedUntil int64) error {
+	users, err := uc.RepoUser.GetUserDetailById(userId)
+	if err != nil {
+		return err
+	}
+
+	name := users.FirstName
+	if users.LastName != "" {
+		name = users.FirstName + " " + users.LastName
+	}
+
+	fileBody := os.Getenv("PATH_EMAIL_TEMPLATE") + "account-locked.html"
+
+	tmpl, err := template.ParseFiles(fileBody)
+	if err != nil {
+		return err
+	}
+
+	dataEmail := map[string]interface{}{
+		"name":                name,
+		"ip_address":          ipAddress,
+		"locked_until":        time.Unix(lockedUntil, 0).Format("02 Jan 2006 15:04:05"),
+		"reset_password_link": os.Getenv("URL_RESET_PASSWORD"),
+	}
+
+	var buffer bytes.Buffer
+
+	if err = tmpl.Execute(&buffer, dataEmail); err != nil {
+		return err
+	}
+
+	emailBody := buffer.String()
+
+	err = helper.SendMail(users.Email, "Your Account Has Been Temporarily Locked", emailBody)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// verifyLink membuat link verifikasi email yang ditandatangani dan berlaku selama VerifyEmailExp
+func (uc *MailUseCase) verifyLink(userId int64, email string) (string, error) {
+	payload, err := json.Marshal(user.VerifyEmailLink{
+		UserId: userId,
+		Email:  email,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	token, err := helper.Encrypt(common.PurposeVerifyEmail, string(payload), common.VerifyEmailExp)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s/api/auth/verify-email?token=%s", os.Getenv("URL_API"), url.QueryEscape(token)), nil
+}
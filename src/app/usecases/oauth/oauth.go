@@ -0,0 +1,448 @@
+package oauth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-auth-service/src/app/dto/oauth"
+	"go-auth-service/src/app/dto/user"
+	userUC "go-auth-service/src/app/usecases/user"
+	"go-auth-service/src/infra/constants/common"
+	errorMessage "go-auth-service/src/infra/constants/error_message"
+	"go-auth-service/src/infra/helper"
+	"go-auth-service/src/infra/models"
+	repoOAuthClient "go-auth-service/src/infra/persistence/postgres/oauth_client"
+	repoRefreshToken "go-auth-service/src/infra/persistence/postgres/refresh_token"
+	redis "go-auth-service/src/infra/persistence/redis/service"
+)
+
+type OAuthUCInterface interface {
+	ValidateAuthorize(data *oauth.AuthorizeReq) error
+	Authorize(data *oauth.AuthorizeReq, login *user.LoginReq, otp, ipAddress, userAgent string) (string, error)
+	Token(data *oauth.TokenReq, userAgent string) (*oauth.TokenResp, error)
+	UserInfo(userId int64, scope string) (*oauth.UserInfoResp, error)
+	Introspect(data *oauth.IntrospectReq) (*oauth.IntrospectResp, error)
+}
+
+type oauthUseCase struct {
+	Redis            redis.ServRedisInterface
+	RepoOAuthClient  repoOAuthClient.OAuthClientRepository
+	RepoRefreshToken repoRefreshToken.RefreshTokenRepository
+	UserUC           userUC.UserUCInterface
+}
+
+func NewOAuthUseCase(
+	redisService redis.ServRedisInterface,
+	repoOAuthClient repoOAuthClient.OAuthClientRepository,
+	repoRefreshToken repoRefreshToken.RefreshTokenRepository,
+	userUseCase userUC.UserUCInterface,
+) OAuthUCInterface {
+	return &oauthUseCase{
+		Redis:            redisService,
+		RepoOAuthClient:  repoOAuthClient,
+		RepoRefreshToken: repoRefreshToken,
+		UserUC:           userUseCase,
+	}
+}
+
+// ValidateAuthorize memeriksa client dan redirect_uri terlebih dahulu, karena jika salah satunya
+// tidak valid error tidak boleh dikirim ke redirect_uri.
+func (uc *oauthUseCase) ValidateAuthorize(data *oauth.AuthorizeReq) error {
+	client, err := uc.RepoOAuthClient.GetByClientId(data.ClientId)
+	if err != nil {
+		return errors.New(errorMessage.InvalidClient)
+	}
+
+	if data.RedirectUri == "" || !hasValue(client.RedirectUris, data.RedirectUri) {
+		return errors.New(errorMessage.InvalidRedirectUri)
+	}
+
+	if data.ResponseType != common.ResponseTypeCode {
+		return errors.New(errorMessage.UnsupportedResponseType)
+	}
+
+	if err = data.Validate(); err != nil {
+		return err
+	}
+
+	if !hasValue(client.GrantTypes, common.GrantTypeAuthorizationCode) {
+		return errors.New(errorMessage.UnauthorizedClient)
+	}
+
+	for _, scope := range strings.Fields(data.Scope) {
+		if !hasValue(client.Scopes, scope) {
+			return errors.New(errorMessage.InvalidScope)
+		}
+	}
+
+	return nil
+}
+
+// Authorize hanya mengautentikasi user (password, rate limit, lockout dan faktor kedua) lalu
+// menyimpan hasilnya di balik authorization code sekali pakai. Sesi dan token dibuat saat code
+// ditukar di exchangeCode. Akun dengan faktor kedua wajib mengisi otp (code TOTP/SMS atau
+// recovery code) pada form yang sama.
+func (uc *oauthUseCase) Authorize(data *oauth.AuthorizeReq, login *user.LoginReq, otp, ipAddress, userAgent string) (string, error) {
+	if err := uc.ValidateAuthorize(data); err != nil {
+		return "", err
+	}
+
+	userId, authMethod, err := uc.UserUC.Authenticate(login, otp, ipAddress, userAgent)
+	if err != nil {
+		return "", err
+	}
+
+	code, err := helper.RandomToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	authorizationCode := oauth.AuthorizationCode{
+		ClientId:      data.ClientId,
+		RedirectUri:   data.RedirectUri,
+		Scope:         data.Scope,
+		Nonce:         data.Nonce,
+		CodeChallenge: data.CodeChallenge,
+		UserId:        userId,
+		AuthMethod:    authMethod,
+		AuthTime:      time.Now().Unix(),
+		IpAddress:     ipAddress,
+		UserAgent:     userAgent,
+	}
+
+	dataRedis, _ := json.Marshal(authorizationCode)
+	codeKey := fmt.Sprintf("%s:%s", common.OAuthCodeKey, helper.HashToken(code))
+	err = uc.Redis.SetData(context.Background(), codeKey, dataRedis, common.AuthorizationCodeExp)
+	if err != nil {
+		return "", err
+	}
+
+	return code, nil
+}
+
+func (uc *oauthUseCase) Token(data *oauth.TokenReq, userAgent string) (*oauth.TokenResp, error) {
+	client, err := uc.authenticateClient(data.ClientId, data.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	switch data.GrantType {
+	case common.GrantTypeAuthorizationCode, common.GrantTypeRefreshToken, common.GrantTypeClientCredentials:
+	default:
+		return nil, errors.New(errorMessage.UnsupportedGrantType)
+	}
+
+	if !hasValue(client.GrantTypes, data.GrantType) {
+		return nil, errors.New(errorMessage.UnauthorizedClient)
+	}
+
+	if data.GrantType == common.GrantTypeClientCredentials {
+		return uc.clientCredentials(client, data.Scope)
+	}
+
+	if data.GrantType == common.GrantTypeRefreshToken {
+		refreshResp, scope, err := uc.UserUC.RefreshOAuthToken(data.RefreshToken, client.ClientId, userAgent)
+		if err != nil {
+			return nil, errors.New(errorMessage.InvalidGrant)
+		}
+
+		return &oauth.TokenResp{
+			AccessToken:  refreshResp.AccessToken,
+			TokenType:    common.TokenTypeBearer,
+			ExpiresIn:    int64(common.AccessTokenExp.Seconds()),
+			RefreshToken: refreshResp.RefreshToken,
+			Scope:        scope,
+		}, nil
+	}
+
+	return uc.exchangeCode(data)
+}
+
+// clientCredentials menerbitkan access token untuk service. Hanya confidential client
+// yang boleh memakai grant ini, dan tanpa scope yang diminta seluruh scope client diberikan.
+func (uc *oauthUseCase) clientCredentials(client *models.OAuthClient, scope string) (*oauth.TokenResp, error) {
+	if !client.ClientSecretHash.Valid || client.ClientSecretHash.String == "" {
+		return nil, errors.New(errorMessage.UnauthorizedClient)
+	}
+
+	scopes := strings.Fields(scope)
+	if len(scopes) == 0 {
+		scopes = strings.Fields(client.Scopes)
+	}
+
+	for _, item := range scopes {
+		if !hasValue(client.Scopes, item) || item == common.ScopeOpenId {
+			return nil, errors.New(errorMessage.InvalidScope)
+		}
+	}
+
+	grantedScope := strings.Join(scopes, " ")
+	accessToken, err := helper.GenerateClientToken(client.ClientId, grantedScope)
+	if err != nil {
+		return nil, err
+	}
+
+	return &oauth.TokenResp{
+		AccessToken: accessToken,
+		TokenType:   common.TokenTypeBearer,
+		ExpiresIn:   int64(common.ClientTokenExp.Seconds()),
+		Scope:       grantedScope,
+	}, nil
+}
+
+func (uc *oauthUseCase) exchangeCode(data *oauth.TokenReq) (*oauth.TokenResp, error) {
+	codeKey := fmt.Sprintf("%s:%s", common.OAuthCodeKey, helper.HashToken(data.Code))
+	cache, err := uc.Redis.GetDeleteData(context.Background(), codeKey)
+	if err != nil || cache == "" {
+		return nil, errors.New(errorMessage.InvalidGrant)
+	}
+
+	authorizationCode := oauth.AuthorizationCode{}
+	if err = json.Unmarshal([]byte(cache), &authorizationCode); err != nil {
+		return nil, errors.New(errorMessage.InvalidGrant)
+	}
+
+	if authorizationCode.ClientId != data.ClientId || authorizationCode.RedirectUri != data.RedirectUri {
+		return nil, errors.New(errorMessage.InvalidGrant)
+	}
+
+	challenge := sha256.Sum256([]byte(data.CodeVerifier))
+	expected := base64.RawURLEncoding.EncodeToString(challenge[:])
+	if subtle.ConstantTimeCompare([]byte(expected), []byte(authorizationCode.CodeChallenge)) != 1 {
+		return nil, errors.New(errorMessage.InvalidCodeVerifier)
+	}
+
+	// sesi dicatat dengan IP dan user agent browser user saat authorize, bukan milik client
+	loginResp, err := uc.UserUC.CreateOAuthLogin(authorizationCode.UserId, data.ClientId, authorizationCode.Scope,
+		authorizationCode.AuthMethod, authorizationCode.IpAddress, authorizationCode.UserAgent)
+	if err != nil {
+		return nil, errors.New(errorMessage.InvalidGrant)
+	}
+
+	resp := &oauth.TokenResp{
+		AccessToken:  loginResp.AccessToken,
+		TokenType:    common.TokenTypeBearer,
+		ExpiresIn:    int64(common.AccessTokenExp.Seconds()),
+		RefreshToken: loginResp.RefreshToken,
+		Scope:        authorizationCode.Scope,
+	}
+
+	if hasValue(authorizationCode.Scope, common.ScopeOpenId) {
+		idClaims := &helper.IDTokenClaims{
+			Nonce:    authorizationCode.Nonce,
+			AuthTime: authorizationCode.AuthTime,
+		}
+
+		if hasValue(authorizationCode.Scope, common.ScopeEmail) {
+			userDetail, err := uc.UserUC.Me(authorizationCode.UserId)
+			if err != nil {
+				return nil, err
+			}
+
+			verified := userDetail.Verified == "true"
+			idClaims.Email = userDetail.Email
+			idClaims.EmailVerified = &verified
+		}
+
+		resp.IdToken, err = helper.GenerateIDToken(authorizationCode.UserId, data.ClientId, idClaims)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return resp, nil
+}
+
+// UserInfo mengembalikan klaim OIDC sesuai scope token. Tanpa scope email atau profile
+// hanya sub yang dikembalikan.
+func (uc *oauthUseCase) UserInfo(userId int64, scope string) (*oauth.UserInfoResp, error) {
+	userDetail, err := uc.UserUC.Me(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &oauth.UserInfoResp{
+		Sub: strconv.FormatInt(userDetail.UserId, 10),
+	}
+
+	if hasValue(scope, common.ScopeEmail) {
+		verified := userDetail.Verified == "true"
+		resp.Email = userDetail.Email
+		resp.EmailVerified = &verified
+	}
+
+	if hasValue(scope, common.ScopeProfile) {
+		resp.GivenName = userDetail.FirstName
+		resp.FamilyName = userDetail.LastName
+		resp.Name = strings.TrimSpace(userDetail.FirstName + " " + userDetail.LastName)
+		resp.Picture = userDetail.Picture
+		resp.Gender = userDetail.Gender
+
+		if birthDate, err := time.Parse("02-01-2006", userDetail.BirthDate); err == nil {
+			resp.Birthdate = birthDate.Format("2006-01-02")
+		}
+	}
+
+	return resp, nil
+}
+
+// Introspect mengembalikan status token untuk resource server (RFC 7662). Hanya confidential
+// client yang boleh memanggilnya. Hasil disimpan sebentar di Redis agar latency tetap rendah,
+// sehingga pencabutan token paling lambat terlihat setelah common.IntrospectionExp.
+func (uc *oauthUseCase) Introspect(data *oauth.IntrospectReq) (*oauth.IntrospectResp, error) {
+	client, err := uc.authenticateClient(data.ClientId, data.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	if !client.ClientSecretHash.Valid || client.ClientSecretHash.String == "" {
+		return nil, errors.New(errorMessage.InvalidClient)
+	}
+
+	cacheKey := fmt.Sprintf("%s:%s", common.IntrospectKey, helper.HashToken(data.Token))
+	cache, err := uc.Redis.GetData(context.Background(), cacheKey)
+	if err == nil && cache != "" {
+		result := &oauth.IntrospectResp{}
+		if err = json.Unmarshal([]byte(cache), result); err == nil {
+			return result, nil
+		}
+	}
+
+	var resp *oauth.IntrospectResp
+	if data.TokenTypeHint == common.TokenTypeHintRefresh {
+		resp = uc.introspectRefreshToken(data.Token)
+		if !resp.Active {
+			resp = uc.introspectAccessToken(data.Token)
+		}
+	} else {
+		resp = uc.introspectAccessToken(data.Token)
+		if !resp.Active {
+			resp = uc.introspectRefreshToken(data.Token)
+		}
+	}
+
+	ttl := common.IntrospectionExp
+	if resp.Active {
+		if remaining := time.Until(time.Unix(resp.Exp, 0)); remaining < ttl {
+			ttl = remaining
+		}
+	}
+
+	if ttl > 0 {
+		dataRedis, _ := json.Marshal(resp)
+		if err = uc.Redis.SetData(context.Background(), cacheKey, dataRedis, ttl); err != nil {
+			log.Println("Failed to save introspection result to Redis", err)
+		}
+	}
+
+	return resp, nil
+}
+
+// introspectAccessToken memakai VerifyAccessToken sehingga token yang ada di denylist
+// (logout, ganti password, revoke) dianggap tidak aktif
+func (uc *oauthUseCase) introspectAccessToken(token string) *oauth.IntrospectResp {
+	claims, err := helper.VerifyAccessToken(token)
+	if err != nil {
+		return &oauth.IntrospectResp{Active: false}
+	}
+
+	if claims.SubjectType == common.SubjectTypeClient {
+		if _, err = uc.RepoOAuthClient.GetByClientId(claims.ClientID); err != nil {
+			return &oauth.IntrospectResp{Active: false}
+		}
+	}
+
+	return &oauth.IntrospectResp{
+		Active:      true,
+		Scope:       claims.Scope,
+		ClientId:    claims.ClientID,
+		Username:    claims.Email,
+		TokenType:   common.TokenTypeHintAccess,
+		Exp:         claims.ExpiresAt,
+		Iat:         claims.IssuedAt,
+		Sub:         claims.Subject,
+		Aud:         claims.Audience,
+		Iss:         claims.Issuer,
+		SubjectType: claims.SubjectType,
+		Email:       claims.Email,
+		Sid:         claims.SessionID,
+	}
+}
+
+// introspectRefreshToken memeriksa status refresh token di tabel user_refresh_token
+func (uc *oauthUseCase) introspectRefreshToken(token string) *oauth.IntrospectResp {
+	claims, err := helper.VerifyRefreshToken(token)
+	if err != nil {
+		return &oauth.IntrospectResp{Active: false}
+	}
+
+	hashed, err := helper.HashRefreshToken(token)
+	if err != nil {
+		return &oauth.IntrospectResp{Active: false}
+	}
+
+	refreshTokenDb, err := uc.RepoRefreshToken.GetByHash(hashed)
+	if err != nil || !refreshTokenDb.IsActive || refreshTokenDb.UserId != claims.UserID {
+		return &oauth.IntrospectResp{Active: false}
+	}
+
+	if refreshTokenDb.ExpiresAt.Valid && refreshTokenDb.ExpiresAt.Time.Before(time.Now()) {
+		return &oauth.IntrospectResp{Active: false}
+	}
+
+	return &oauth.IntrospectResp{
+		Active:      true,
+		Username:    claims.Email,
+		TokenType:   common.TokenTypeHintRefresh,
+		Exp:         claims.ExpiresAt,
+		Sub:         strconv.FormatInt(claims.UserID, 10),
+		SubjectType: common.SubjectTypeUser,
+		Email:       claims.Email,
+		Sid:         claims.SessionID,
+	}
+}
+
+// authenticateClient memverifikasi client_secret untuk confidential client.
+// Public client (tanpa secret) hanya diamankan oleh PKCE.
+func (uc *oauthUseCase) authenticateClient(clientId, clientSecret string) (*models.OAuthClient, error) {
+	client, err := uc.RepoOAuthClient.GetByClientId(clientId)
+	if err != nil {
+		log.Println(err)
+		return nil, errors.New(errorMessage.InvalidClient)
+	}
+
+	if client.ClientSecretHash.Valid && client.ClientSecretHash.String != "" {
+		if clientSecret == "" {
+			return nil, errors.New(errorMessage.InvalidClient)
+		}
+
+		err = helper.VerifyPassword(client.ClientSecretHash.String, clientSecret)
+		if errors.Is(err, helper.ErrPasswordHashBusy) {
+			return nil, err
+		}
+		if err != nil {
+			return nil, errors.New(errorMessage.InvalidClient)
+		}
+	}
+
+	return client, nil
+}
+
+// hasValue memeriksa apakah value ada di dalam daftar yang dipisahkan spasi
+func hasValue(list, value string) bool {
+	for _, item := range strings.Fields(list) {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
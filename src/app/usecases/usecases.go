@@ -2,10 +2,12 @@ package usecases
 
 import (
 	mailUC "go-auth-service/src/app/usecases/mail"
+	oauthUC "go-auth-service/src/app/usecases/oauth"
 	userUC "go-auth-service/src/app/usecases/user"
 )
 
 type AllUseCases struct {
-	MailUC mailUC.MailUCInterface
-	UserUC userUC.UserUCInterface
+	MailUC  mailUC.MailUCInterface
+	UserUC  userUC.UserUCInterface
+	OAuthUC oauthUC.OAuthUCInterface
 }
@@ -0,0 +1,352 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	errorMessage "go-auth-service/src/infra/constants/error_message"
+	"go-auth-service/src/infra/models"
+	repoHistory "go-auth-service/src/infra/persistence/postgres/history"
+	repoRecoveryCode "go-auth-service/src/infra/persistence/postgres/recovery_code"
+	reporefreshToken "go-auth-service/src/infra/persistence/postgres/refresh_token"
+	repoSession "go-auth-service/src/infra/persistence/postgres/session"
+	repoTotp "go-auth-service/src/infra/persistence/postgres/totp"
+	repoUser "go-auth-service/src/infra/persistence/postgres/user"
+	redis "go-auth-service/src/infra/persistence/redis/service"
+)
+
+// fakeRedis menyimpan data di memori. Waktu tidak berjalan, sehingga TTL hanya dicatat untuk
+// diperiksa test. Jika err diisi, seluruh operasi gagal seperti Redis yang mati.
+type fakeRedis struct {
+	mu   sync.Mutex
+	data map[string]string
+	ttls map[string]time.Duration
+	err  error
+}
+
+var _ redis.ServRedisInterface = (*fakeRedis)(nil)
+
+func newFakeRedis() *fakeRedis {
+	return &fakeRedis{data: map[string]string{}, ttls: map[string]time.Duration{}}
+}
+
+func (f *fakeRedis) SetData(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.err != nil {
+		return f.err
+	}
+	f.data[key] = toString(value)
+	f.ttls[key] = ttl
+	return nil
+}
+
+func (f *fakeRedis) GetData(ctx context.Context, key string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.err != nil {
+		return "", f.err
+	}
+	return f.data[key], nil
+}
+
+func (f *fakeRedis) DeleteData(ctx context.Context, key string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.err != nil {
+		return f.err
+	}
+	delete(f.data, key)
+	delete(f.ttls, key)
+	return nil
+}
+
+func (f *fakeRedis) GetDeleteData(ctx context.Context, key string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.err != nil {
+		return "", f.err
+	}
+	value := f.data[key]
+	delete(f.data, key)
+	delete(f.ttls, key)
+	return value, nil
+}
+
+func (f *fakeRedis) GetMultiData(ctx context.Context, keys ...string) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.err != nil {
+		return nil, f.err
+	}
+	values := make([]string, 0, len(keys))
+	for _, key := range keys {
+		values = append(values, f.data[key])
+	}
+	return values, nil
+}
+
+func (f *fakeRedis) IsAllowed(ctx context.Context, key string, limit int, duration time.Duration) (bool, error) {
+	count, err := f.Increment(ctx, key, duration)
+	if err != nil {
+		return false, err
+	}
+	return count <= int64(limit), nil
+}
+
+func (f *fakeRedis) SetDataNX(ctx context.Context, key string, value interface{}, ttl time.Duration) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.err != nil {
+		return false, f.err
+	}
+	if _, ok := f.data[key]; ok {
+		return false, nil
+	}
+	f.data[key] = toString(value)
+	f.ttls[key] = ttl
+	return true, nil
+}
+
+// Increment memasang TTL hanya saat counter dibuat, sama seperti ServiceRedis
+func (f *fakeRedis) Increment(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.err != nil {
+		return 0, f.err
+	}
+	count, _ := strconv.ParseInt(f.data[key], 10, 64)
+	count++
+	f.data[key] = strconv.FormatInt(count, 10)
+	if count == 1 {
+		f.ttls[key] = ttl
+	}
+	return count, nil
+}
+
+func (f *fakeRedis) GetTTL(ctx context.Context, key string) (time.Duration, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.err != nil {
+		return 0, f.err
+	}
+	if _, ok := f.data[key]; !ok {
+		return 0, nil
+	}
+	return f.ttls[key], nil
+}
+
+func (f *fakeRedis) SlidingWindow(ctx context.Context, key string, limit int, window time.Duration) (*redis.RateLimitResult, error) {
+	allowed, err := f.IsAllowed(ctx, key, limit, window)
+	if err != nil {
+		return nil, err
+	}
+	return &redis.RateLimitResult{Allowed: allowed, Limit: limit}, nil
+}
+
+func (f *fakeRedis) has(key string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	_, ok := f.data[key]
+	return ok
+}
+
+func toString(value interface{}) string {
+	if v, ok := value.([]byte); ok {
+		return string(v)
+	}
+	return fmt.Sprint(value)
+}
+
+// fakePublisher mencatat setiap pesan yang dipublish
+type fakePublisher struct {
+	mu       sync.Mutex
+	messages [][]byte
+}
+
+func (f *fakePublisher) Nats(data []byte, subject string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.messages = append(f.messages, data)
+	return nil
+}
+
+// fakeRefreshTokenRepo menyimpan satu refresh token. Method lain dari interface tidak dipakai
+// test sehingga memanggilnya akan panic.
+type fakeRefreshTokenRepo struct {
+	reporefreshToken.RefreshTokenRepository
+
+	token       *models.UserRefreshToken
+	marked      bool
+	deactivated []string
+}
+
+func (f *fakeRefreshTokenRepo) GetByHash(refreshTokenHash string) (*models.UserRefreshToken, error) {
+	if f.token == nil || f.token.RefreshTokenHash != refreshTokenHash {
+		return nil, errors.New("sql: no rows in result set")
+	}
+	copied := *f.token
+	return &copied, nil
+}
+
+func (f *fakeRefreshTokenRepo) MarkUsed(id int64) (bool, error) {
+	return f.marked, nil
+}
+
+func (f *fakeRefreshTokenRepo) UpdateStatusBySessionId(sessionId string) error {
+	f.deactivated = append(f.deactivated, sessionId)
+	return nil
+}
+
+func (f *fakeRefreshTokenRepo) Create(userId int64, sessionId, refreshTokenHash string) error {
+	return nil
+}
+
+// fakeSessionRepo mengembalikan satu sesi aktif dan mencatat sesi yang dicabut beserta alasannya
+type fakeSessionRepo struct {
+	repoSession.SessionRepository
+
+	session *models.UserSession
+	revoked map[string]string
+}
+
+func (f *fakeSessionRepo) GetActiveById(id string) (*models.UserSession, error) {
+	if f.session == nil || f.session.Id != id {
+		return nil, errors.New(errorMessage.SessionNotFound)
+	}
+	copied := *f.session
+	return &copied, nil
+}
+
+func (f *fakeSessionRepo) UpdateLastSeen(id string) error {
+	return nil
+}
+
+func (f *fakeSessionRepo) Revoke(id, revokeReason string) error {
+	if f.revoked == nil {
+		f.revoked = map[string]string{}
+	}
+	f.revoked[id] = revokeReason
+	return nil
+}
+
+// fakeHistoryRepo mencatat sesi yang di-logout pada riwayat login
+type fakeHistoryRepo struct {
+	repoHistory.HistoryRepository
+
+	loggedOut map[string]string
+}
+
+func (f *fakeHistoryRepo) UpdateLogoutBySessionId(sessionId, logoutReason string) error {
+	if f.loggedOut == nil {
+		f.loggedOut = map[string]string{}
+	}
+	f.loggedOut[sessionId] = logoutReason
+	return nil
+}
+
+// fakeUserRepo mengembalikan satu user dan mencatat hash password yang diganti saat rehash.
+// getErr dikembalikan oleh GetByEmail seperti database yang tidak dapat diakses.
+type fakeUserRepo struct {
+	repoUser.UserRepository
+
+	user     *models.User
+	err      error
+	getErr   error
+	rehashed map[int64]string
+}
+
+func (f *fakeUserRepo) GetByEmail(email string) (*models.User, error) {
+	if f.getErr != nil {
+		return nil, f.getErr
+	}
+	if f.user == nil || f.user.Email != email {
+		return nil, errors.New(errorMessage.UserNotFound)
+	}
+	copied := *f.user
+	return &copied, nil
+}
+
+func (f *fakeUserRepo) GetById(userId int64) (*models.User, error) {
+	if f.user == nil || f.user.Id != userId {
+		return nil, errors.New(errorMessage.UserNotFound)
+	}
+	copied := *f.user
+	return &copied, nil
+}
+
+func (f *fakeUserRepo) GetByPhone(phone string) (*models.User, error) {
+	if f.user == nil {
+		return nil, errors.New("sql: no rows in result set")
+	}
+	copied := *f.user
+	return &copied, nil
+}
+
+func (f *fakeUserRepo) RehashPasswordByUserId(userId int64, oldPassword, newPassword string) error {
+	if f.err != nil {
+		return f.err
+	}
+	if f.rehashed == nil {
+		f.rehashed = map[int64]string{}
+	}
+	f.rehashed[userId] = newPassword
+	return nil
+}
+
+// fakeTotpRepo mengembalikan satu secret TOTP
+type fakeTotpRepo struct {
+	repoTotp.TotpRepository
+
+	totp *models.UserTotp
+}
+
+func (f *fakeTotpRepo) GetByUserId(userId int64) (*models.UserTotp, error) {
+	if f.totp == nil || f.totp.UserId != userId {
+		return nil, errors.New("sql: no rows in result set")
+	}
+	copied := *f.totp
+	return &copied, nil
+}
+
+// fakeRecoveryCodeRepo menyimpan hash recovery code yang belum dipakai dan mencatat yang sudah dipakai
+type fakeRecoveryCodeRepo struct {
+	repoRecoveryCode.RecoveryCodeRepository
+
+	unused map[string]bool
+	used   []string
+}
+
+func (f *fakeRecoveryCodeRepo) Use(userId int64, codeHash string) (bool, error) {
+	if !f.unused[codeHash] {
+		return false, nil
+	}
+	delete(f.unused, codeHash)
+	f.used = append(f.used, codeHash)
+	return true, nil
+}
+
+// fakeSmsSender mencatat SMS terakhir yang dikirim
+type fakeSmsSender struct {
+	to      string
+	message string
+}
+
+func (f *fakeSmsSender) Send(to, message string) error {
+	f.to, f.message = to, message
+	return nil
+}
@@ -0,0 +1,204 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	dtoNats "go-auth-service/src/app/dto/broker"
+	"go-auth-service/src/infra/constants/common"
+	errorMessage "go-auth-service/src/infra/constants/error_message"
+	"go-auth-service/src/infra/models"
+)
+
+// LockoutError dikembalikan saat login ditolak karena delay atau lockout. Error() tetap berupa
+// pesan errorMessage sehingga handler bisa membandingkan err.Error() seperti error lain,
+// RetryAfter dipakai untuk header Retry-After.
+type LockoutError struct {
+	Message    string
+	RetryAfter time.Duration
+}
+
+func (e *LockoutError) Error() string {
+	return e.Message
+}
+
+// loginLockKeys adalah key counter percobaan login gagal untuk satu akun (email)
+type loginLockKeys struct {
+	fail      string
+	delay     string
+	lock      string
+	lockCount string
+}
+
+func newLoginLockKeys(email string) loginLockKeys {
+	identity := strings.ToLower(strings.TrimSpace(email))
+
+	return loginLockKeys{
+		fail:      fmt.Sprintf("%s:%s", common.LoginFailKey, identity),
+		delay:     fmt.Sprintf("%s:%s", common.LoginDelayKey, identity),
+		lock:      fmt.Sprintf("%s:%s", common.LoginLockKey, identity),
+		lockCount: fmt.Sprintf("%s:%s", common.LoginLockCountKey, identity),
+	}
+}
+
+// checkLoginLockout menolak login sebelum password diperiksa jika IP atau akun sedang dikunci,
+// atau akun masih dalam delay setelah percobaan gagal terakhir
+func (uc *userUseCase) checkLoginLockout(email, ipAddress string) error {
+	ctx := context.Background()
+	keys := newLoginLockKeys(email)
+
+	ipLockKey := fmt.Sprintf("%s:%s", common.LoginLockIpKey, ipAddress)
+	if ttl, _ := uc.Redis.GetTTL(ctx, ipLockKey); ttl > 0 {
+		return &LockoutError{Message: errorMessage.ToManyRequest, RetryAfter: ttl}
+	}
+
+	if ttl, _ := uc.Redis.GetTTL(ctx, keys.lock); ttl > 0 {
+		return &LockoutError{Message: errorMessage.AccountLocked, RetryAfter: ttl}
+	}
+
+	if ttl, _ := uc.Redis.GetTTL(ctx, keys.delay); ttl > 0 {
+		return &LockoutError{Message: errorMessage.ToManyRequest, RetryAfter: ttl}
+	}
+
+	return nil
+}
+
+// loginFailed mencatat percobaan login gagal per akun dan per IP. Email yang tidak terdaftar
+// tetap dihitung (users nil) agar respons tidak bisa dipakai untuk enumerasi akun.
+func (uc *userUseCase) loginFailed(users *models.User, email, ipAddress string) error {
+	ctx := context.Background()
+	conf := uc.Lockout
+	keys := newLoginLockKeys(email)
+
+	if conf.IpThreshold > 0 {
+		ipFailKey := fmt.Sprintf("%s:%s", common.LoginFailIpKey, ipAddress)
+		ipCount, _ := uc.Redis.Increment(ctx, ipFailKey, common.LoginFailureIpWindow)
+		if ipCount >= int64(conf.IpThreshold) {
+			ipLockKey := fmt.Sprintf("%s:%s", common.LoginLockIpKey, ipAddress)
+			_ = uc.Redis.SetData(ctx, ipLockKey, 1, conf.Duration)
+			_ = uc.Redis.DeleteData(ctx, ipFailKey)
+		}
+	}
+
+	if conf.Threshold <= 0 {
+		return nil
+	}
+
+	count, err := uc.Redis.Increment(ctx, keys.fail, common.LoginFailureWindow)
+	if err != nil {
+		return err
+	}
+
+	if count >= int64(conf.Threshold) {
+		lockCount, _ := uc.Redis.Increment(ctx, keys.lockCount, common.LoginFailureWindow)
+		duration := lockoutDuration(conf.Duration, conf.MaxDuration, lockCount)
+
+		err = uc.Redis.SetData(ctx, keys.lock, 1, duration)
+		if err != nil {
+			return err
+		}
+
+		_ = uc.Redis.DeleteData(ctx, keys.fail)
+		_ = uc.Redis.DeleteData(ctx, keys.delay)
+
+		if users != nil {
+			sendMailDto := dtoNats.AuthBrokerDto{
+				UserId:      users.Id,
+				IpAddress:   ipAddress,
+				Event:       common.EventAccountLocked,
+				LockedUntil: time.Now().Add(duration).Unix(),
+			}
+
+			dataPublishMarshal, _ := json.Marshal(sendMailDto)
+			err = uc.NatsPublisher.Nats(dataPublishMarshal, common.NatsAuthSubject)
+			if err != nil {
+				log.Println(err)
+			}
+		}
+
+		return &LockoutError{Message: errorMessage.AccountLocked, RetryAfter: duration}
+	}
+
+	if conf.DelayAfter > 0 && count > int64(conf.DelayAfter) {
+		// 1s, 2s, 4s, ... sampai LoginMaxDelay
+		delay := time.Second
+		for i := int64(conf.DelayAfter) + 1; i < count && delay < common.LoginMaxDelay; i++ {
+			delay *= 2
+		}
+		if delay > common.LoginMaxDelay {
+			delay = common.LoginMaxDelay
+		}
+
+		_ = uc.Redis.SetData(ctx, keys.delay, 1, delay)
+	}
+
+	return nil
+}
+
+// loginCodeFailed mencatat code login yang salah (TOTP, recovery code, SMS OTP) ke counter lockout
+// akun, sama seperti password yang salah. Error lain (misalnya Redis) dikembalikan apa adanya tanpa dihitung.
+func (uc *userUseCase) loginCodeFailed(users *models.User, ipAddress string, err error) error {
+	switch err.Error() {
+	case errorMessage.InvalidTotpCode, errorMessage.InvalidRecoveryCode, errorMessage.InvalidSmsOtp:
+		if lockErr := uc.loginFailed(users, users.Email, ipAddress); lockErr != nil {
+			return lockErr
+		}
+	}
+
+	return err
+}
+
+// loginSucceeded mereset counter akun setelah login selesai (termasuk faktor kedua) dan sesi
+// dibuat. Counter IP tidak direset agar login ke akun milik penyerang tidak bisa dipakai untuk
+// menghapus jejak percobaan.
+func (uc *userUseCase) loginSucceeded(email string) {
+	ctx := context.Background()
+	keys := newLoginLockKeys(email)
+
+	_ = uc.Redis.DeleteData(ctx, keys.fail)
+	_ = uc.Redis.DeleteData(ctx, keys.delay)
+	_ = uc.Redis.DeleteData(ctx, keys.lockCount)
+}
+
+// UnlockAccount menghapus lockout dan seluruh counter percobaan gagal milik user. Hanya admin.
+func (uc *userUseCase) UnlockAccount(adminId, userId int64) error {
+	admin, err := uc.RepoUser.GetById(adminId)
+	if err != nil {
+		return err
+	}
+
+	if admin.UserTypeId != common.SuperAdmin && admin.UserTypeId != common.Admin {
+		return errors.New(errorMessage.Forbidden)
+	}
+
+	users, err := uc.RepoUser.GetById(userId)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	keys := newLoginLockKeys(users.Email)
+
+	_ = uc.Redis.DeleteData(ctx, keys.lock)
+	uc.loginSucceeded(users.Email)
+
+	return nil
+}
+
+// lockoutDuration menggandakan durasi lockout untuk setiap lockout berulang, dibatasi maxDuration
+func lockoutDuration(duration, maxDuration time.Duration, lockCount int64) time.Duration {
+	for i := int64(1); i < lockCount && duration < maxDuration; i++ {
+		duration *= 2
+	}
+
+	if duration > maxDuration {
+		return maxDuration
+	}
+
+	return duration
+}
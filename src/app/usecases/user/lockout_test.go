@@ -0,0 +1,328 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	dtoNats "go-auth-service/src/app/dto/broker"
+	"go-auth-service/src/infra/config"
+	"go-auth-service/src/infra/constants/common"
+	errorMessage "go-auth-service/src/infra/constants/error_message"
+	"go-auth-service/src/infra/models"
+)
+
+func testLockoutConf() config.LockoutConf {
+	return config.LockoutConf{
+		Threshold:   10,
+		DelayAfter:  3,
+		Duration:    15 * time.Minute,
+		MaxDuration: 24 * time.Hour,
+		IpThreshold: 0,
+	}
+}
+
+func newLockoutUseCase(conf config.LockoutConf) (*userUseCase, *fakeRedis, *fakePublisher) {
+	fake := newFakeRedis()
+	publisher := &fakePublisher{}
+
+	return &userUseCase{Redis: fake, NatsPublisher: publisher, Lockout: conf}, fake, publisher
+}
+
+func TestLockoutDuration(t *testing.T) {
+	tests := []struct {
+		name        string
+		duration    time.Duration
+		maxDuration time.Duration
+		lockCount   int64
+		want        time.Duration
+	}{
+		{name: "first lockout", duration: 15 * time.Minute, maxDuration: 24 * time.Hour, lockCount: 1, want: 15 * time.Minute},
+		{name: "second lockout doubles", duration: 15 * time.Minute, maxDuration: 24 * time.Hour, lockCount: 2, want: 30 * time.Minute},
+		{name: "third lockout doubles again", duration: 15 * time.Minute, maxDuration: 24 * time.Hour, lockCount: 3, want: time.Hour},
+		{name: "capped at max duration", duration: 15 * time.Minute, maxDuration: 24 * time.Hour, lockCount: 8, want: 24 * time.Hour},
+		{name: "very high lock count", duration: 15 * time.Minute, maxDuration: 24 * time.Hour, lockCount: 1000, want: 24 * time.Hour},
+		{name: "max below duration", duration: time.Hour, maxDuration: 30 * time.Minute, lockCount: 1, want: 30 * time.Minute},
+		{name: "zero lock count", duration: 15 * time.Minute, maxDuration: 24 * time.Hour, lockCount: 0, want: 15 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := lockoutDuration(tt.duration, tt.maxDuration, tt.lockCount); got != tt.want {
+				t.Fatalf("lockoutDuration = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// Delay dimulai setelah DelayAfter kegagalan, berlipat dua dan dibatasi LoginMaxDelay
+func TestLoginFailedProgressiveDelay(t *testing.T) {
+	conf := testLockoutConf()
+	conf.Threshold = 20
+
+	tests := []struct {
+		failures  int
+		wantDelay time.Duration
+	}{
+		{failures: 1, wantDelay: 0},
+		{failures: 3, wantDelay: 0},
+		{failures: 4, wantDelay: time.Second},
+		{failures: 5, wantDelay: 2 * time.Second},
+		{failures: 6, wantDelay: 4 * time.Second},
+		{failures: 7, wantDelay: 8 * time.Second},
+		{failures: 8, wantDelay: 16 * time.Second},
+		{failures: 9, wantDelay: common.LoginMaxDelay},
+		{failures: 19, wantDelay: common.LoginMaxDelay},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d failures", tt.failures), func(t *testing.T) {
+			uc, fake, _ := newLockoutUseCase(conf)
+
+			for i := 0; i < tt.failures; i++ {
+				if err := uc.loginFailed(nil, "user@mail.com", "10.0.0.1"); err != nil {
+					t.Fatalf("failure %d: unexpected error %v", i+1, err)
+				}
+			}
+
+			delay, _ := fake.GetTTL(context.Background(), newLoginLockKeys("user@mail.com").delay)
+			if delay != tt.wantDelay {
+				t.Fatalf("delay = %s, want %s", delay, tt.wantDelay)
+			}
+
+			err := uc.checkLoginLockout("user@mail.com", "10.0.0.1")
+			if tt.wantDelay == 0 {
+				if err != nil {
+					t.Fatalf("unexpected lockout %v", err)
+				}
+				return
+			}
+
+			var lockout *LockoutError
+			if !errors.As(err, &lockout) || lockout.Message != errorMessage.ToManyRequest || lockout.RetryAfter != tt.wantDelay {
+				t.Fatalf("err = %v, want delay of %s", err, tt.wantDelay)
+			}
+		})
+	}
+}
+
+// Setiap lockout berulang menggandakan durasi sampai MaxDuration
+func TestLoginFailedLockout(t *testing.T) {
+	conf := testLockoutConf()
+
+	tests := []struct {
+		name         string
+		users        *models.User
+		lockouts     int
+		wantDuration time.Duration
+		wantEvent    bool
+	}{
+		{name: "first lockout", users: &models.User{Id: 7}, lockouts: 1, wantDuration: 15 * time.Minute, wantEvent: true},
+		{name: "second lockout", users: &models.User{Id: 7}, lockouts: 2, wantDuration: 30 * time.Minute, wantEvent: true},
+		{name: "fifth lockout", users: &models.User{Id: 7}, lockouts: 5, wantDuration: 4 * time.Hour, wantEvent: true},
+		{name: "unknown email is locked without event", users: nil, lockouts: 1, wantDuration: 15 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc, fake, publisher := newLockoutUseCase(conf)
+			keys := newLoginLockKeys("user@mail.com")
+
+			var err error
+			for lockout := 0; lockout < tt.lockouts; lockout++ {
+				// lockout sebelumnya dianggap sudah berakhir
+				_ = fake.DeleteData(context.Background(), keys.lock)
+
+				for i := 0; i < conf.Threshold; i++ {
+					err = uc.loginFailed(tt.users, "user@mail.com", "10.0.0.1")
+					if i < conf.Threshold-1 && err != nil {
+						t.Fatalf("failure %d: unexpected error %v", i+1, err)
+					}
+				}
+			}
+
+			var lockout *LockoutError
+			if !errors.As(err, &lockout) || lockout.Message != errorMessage.AccountLocked || lockout.RetryAfter != tt.wantDuration {
+				t.Fatalf("err = %v, want lockout of %s", err, tt.wantDuration)
+			}
+
+			if ttl, _ := fake.GetTTL(context.Background(), keys.lock); ttl != tt.wantDuration {
+				t.Fatalf("lock ttl = %s, want %s", ttl, tt.wantDuration)
+			}
+
+			// counter kegagalan dan delay direset, lockout yang menahan login berikutnya
+			if fake.has(keys.fail) || fake.has(keys.delay) {
+				t.Fatal("failure counter or delay was not cleared on lockout")
+			}
+
+			err = uc.checkLoginLockout("USER@mail.com ", "10.0.0.2")
+			if !errors.As(err, &lockout) || lockout.Message != errorMessage.AccountLocked {
+				t.Fatalf("checkLoginLockout = %v, want account locked", err)
+			}
+
+			if !tt.wantEvent {
+				if len(publisher.messages) != 0 {
+					t.Fatalf("published %d messages for an unknown email", len(publisher.messages))
+				}
+				return
+			}
+
+			if len(publisher.messages) != tt.lockouts {
+				t.Fatalf("published %d messages, want %d", len(publisher.messages), tt.lockouts)
+			}
+
+			var event dtoNats.AuthBrokerDto
+			if err = json.Unmarshal(publisher.messages[len(publisher.messages)-1], &event); err != nil {
+				t.Fatal(err)
+			}
+			if event.Event != common.EventAccountLocked || event.UserId != tt.users.Id || event.LockedUntil <= time.Now().Unix() {
+				t.Fatalf("unexpected lockout event %+v", event)
+			}
+		})
+	}
+}
+
+// Kegagalan dari satu IP ke banyak akun mengunci IP tersebut, bukan IP lain
+func TestLoginFailedIpLockout(t *testing.T) {
+	conf := testLockoutConf()
+	conf.IpThreshold = 3
+
+	uc, _, _ := newLockoutUseCase(conf)
+
+	for i := 0; i < conf.IpThreshold; i++ {
+		if err := uc.checkLoginLockout(fmt.Sprintf("user%d@mail.com", i), "10.0.0.1"); err != nil {
+			t.Fatalf("attempt %d: unexpected lockout %v", i+1, err)
+		}
+		if err := uc.loginFailed(nil, fmt.Sprintf("user%d@mail.com", i), "10.0.0.1"); err != nil {
+			t.Fatalf("attempt %d: unexpected error %v", i+1, err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		email   string
+		ip      string
+		wantErr string
+	}{
+		{name: "locked ip, new account", email: "other@mail.com", ip: "10.0.0.1", wantErr: errorMessage.ToManyRequest},
+		{name: "other ip, same account", email: "user0@mail.com", ip: "10.0.0.2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := uc.checkLoginLockout(tt.email, tt.ip)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected lockout %v", err)
+				}
+				return
+			}
+
+			var lockout *LockoutError
+			if !errors.As(err, &lockout) || lockout.Message != tt.wantErr || lockout.RetryAfter != conf.Duration {
+				t.Fatalf("err = %v, want %q for %s", err, tt.wantErr, conf.Duration)
+			}
+		})
+	}
+}
+
+func TestLoginSucceededResetsAccountCounters(t *testing.T) {
+	conf := testLockoutConf()
+	conf.IpThreshold = 100
+
+	uc, fake, _ := newLockoutUseCase(conf)
+	keys := newLoginLockKeys("user@mail.com")
+
+	for i := 0; i < conf.DelayAfter+1; i++ {
+		_ = uc.loginFailed(nil, "user@mail.com", "10.0.0.1")
+	}
+	_ = fake.SetData(context.Background(), keys.lockCount, 2, time.Hour)
+
+	uc.loginSucceeded("User@Mail.com")
+
+	for _, key := range []string{keys.fail, keys.delay, keys.lockCount} {
+		if fake.has(key) {
+			t.Fatalf("%s was not reset", key)
+		}
+	}
+
+	if !fake.has(fmt.Sprintf("%s:%s", common.LoginFailIpKey, "10.0.0.1")) {
+		t.Fatal("ip failure counter must survive a successful login")
+	}
+}
+
+func TestLoginFailedDisabled(t *testing.T) {
+	conf := testLockoutConf()
+	conf.Threshold = 0
+	conf.DelayAfter = 0
+
+	uc, fake, _ := newLockoutUseCase(conf)
+
+	for i := 0; i < 50; i++ {
+		if err := uc.loginFailed(nil, "user@mail.com", "10.0.0.1"); err != nil {
+			t.Fatalf("failure %d: unexpected error %v", i+1, err)
+		}
+	}
+
+	if err := uc.checkLoginLockout("user@mail.com", "10.0.0.1"); err != nil {
+		t.Fatalf("unexpected lockout %v", err)
+	}
+	if len(fake.data) != 0 {
+		t.Fatalf("counters written while lockout is disabled: %v", fake.data)
+	}
+}
+
+// Code TOTP, SMS OTP atau recovery code yang salah dihitung ke counter lockout akun
+func TestLoginCodeFailed(t *testing.T) {
+	users := &models.User{Id: 7, Email: "user@mail.com"}
+	redisDown := errors.New("redis: connection refused")
+
+	tests := []struct {
+		name      string
+		err       error
+		wantCount bool
+	}{
+		{name: "wrong totp code", err: errors.New(errorMessage.InvalidTotpCode), wantCount: true},
+		{name: "wrong recovery code", err: errors.New(errorMessage.InvalidRecoveryCode), wantCount: true},
+		{name: "wrong sms otp", err: errors.New(errorMessage.InvalidSmsOtp), wantCount: true},
+		{name: "redis error", err: redisDown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc, fake, _ := newLockoutUseCase(testLockoutConf())
+
+			if err := uc.loginCodeFailed(users, "10.0.0.1", tt.err); err != tt.err {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+
+			if counted := fake.has(newLoginLockKeys(users.Email).fail); counted != tt.wantCount {
+				t.Fatalf("counted = %v, want %v", counted, tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestLoginCodeFailedLocksAccount(t *testing.T) {
+	conf := testLockoutConf()
+	uc, _, _ := newLockoutUseCase(conf)
+	users := &models.User{Id: 7, Email: "user@mail.com"}
+
+	var err error
+	for i := 0; i < conf.Threshold; i++ {
+		err = uc.loginCodeFailed(users, "10.0.0.1", errors.New(errorMessage.InvalidTotpCode))
+	}
+
+	var lockout *LockoutError
+	if !errors.As(err, &lockout) || lockout.Message != errorMessage.AccountLocked || lockout.RetryAfter != conf.Duration {
+		t.Fatalf("err = %v, want account locked for %s", err, conf.Duration)
+	}
+
+	// password yang benar pun ditolak selama lockout
+	if err = uc.checkLoginLockout(users.Email, "10.0.0.2"); !errors.As(err, &lockout) {
+		t.Fatalf("checkLoginLockout = %v, want lockout", err)
+	}
+}
@@ -0,0 +1,137 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+
+	dtoNats "go-auth-service/src/app/dto/broker"
+	"go-auth-service/src/app/dto/user"
+	"go-auth-service/src/infra/constants/common"
+	errorMessage "go-auth-service/src/infra/constants/error_message"
+	"go-auth-service/src/infra/helper"
+)
+
+// RequestMagicLink mengirim link login lewat NATS. Device token pada response mengikat link
+// ke perangkat peminta. Seperti ForgotPassword, email yang tidak terdaftar atau terkena
+// rate limit per email tidak menghasilkan error agar respons selalu sama.
+func (uc *userUseCase) RequestMagicLink(email, ipAddress, userAgent string) (*user.MagicLinkResp, error) {
+	if !uc.MagicLinkEnabled {
+		return nil, errors.New(errorMessage.MagicLinkDisabled)
+	}
+
+	ctx := context.Background()
+
+	ipKey := fmt.Sprintf("%s:%s", common.MagicLinkIpKey, ipAddress)
+	allowed, _ := uc.Redis.IsAllowed(ctx, ipKey, 10, common.RateLimit)
+	if !allowed {
+		return nil, errors.New(errorMessage.ToManyRequest)
+	}
+
+	deviceToken, err := helper.RandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &user.MagicLinkResp{DeviceToken: deviceToken}
+
+	emailKey := fmt.Sprintf("%s:%s", common.MagicLinkEmailKey, email)
+	allowed, _ = uc.Redis.IsAllowed(ctx, emailKey, 3, common.RateLimit)
+	if !allowed {
+		return resp, nil
+	}
+
+	users, err := uc.RepoUser.GetByEmail(email)
+	if err != nil {
+		// error database hanya dicatat agar respons tidak membedakan email yang terdaftar
+		if err.Error() != errorMessage.UserNotFound {
+			log.Println(err)
+		}
+		return resp, nil
+	}
+
+	sendMailDto := dtoNats.AuthBrokerDto{
+		UserId:    users.Id,
+		IpAddress: ipAddress,
+		Device:    userAgent,
+		Event:     common.EventMagicLink,
+
+		DeviceBinding: helper.HashToken(deviceToken),
+	}
+
+	// gagal publish hanya dicatat, respons harus sama dengan email yang tidak terdaftar
+	dataPublishMarshal, _ := json.Marshal(sendMailDto)
+	err = uc.NatsPublisher.Nats(dataPublishMarshal, common.NatsAuthSubject)
+	if err != nil {
+		log.Println(err)
+	}
+
+	return resp, nil
+}
+
+// LoginMagicLink memakai link login (sekali pakai) lalu melanjutkan seperti Login: akun dengan
+// faktor kedua tetap harus melewati LoginMfa. Jika device token tidak dikirim (link dibuka di
+// browser tanpa penyimpanan client), user agent harus sama dengan saat link diminta.
+func (uc *userUseCase) LoginMagicLink(data *user.MagicLinkLoginReq, ipAddress, userAgent string) (*user.LoginResp, error) {
+	if !uc.MagicLinkEnabled {
+		return nil, errors.New(errorMessage.MagicLinkDisabled)
+	}
+
+	invalid := errors.New(errorMessage.InvalidMagicLink)
+
+	payload, err := helper.Decrypt(common.PurposeMagicLink, data.Token)
+	if err != nil {
+		return nil, invalid
+	}
+
+	var link user.MagicLink
+	if err = json.Unmarshal([]byte(payload), &link); err != nil {
+		return nil, invalid
+	}
+
+	if data.DeviceToken != "" {
+		if link.DeviceHash == "" || helper.HashToken(data.DeviceToken) != link.DeviceHash {
+			return nil, errors.New(errorMessage.MagicLinkDeviceMismatch)
+		}
+	} else if helper.HashToken(userAgent) != link.UserAgentHash {
+		return nil, errors.New(errorMessage.MagicLinkDeviceMismatch)
+	}
+
+	// pengecekan perangkat dilakukan lebih dulu agar link tidak hangus dibuka di perangkat lain
+	magicLinkKey := fmt.Sprintf("%s:%s", common.MagicLinkKey, link.Nonce)
+	userIdStr, _ := uc.Redis.GetDeleteData(context.Background(), magicLinkKey)
+	if userIdStr == "" || userIdStr != strconv.FormatInt(link.UserId, 10) {
+		return nil, invalid
+	}
+
+	users, err := uc.RepoUser.GetById(link.UserId)
+	if err != nil {
+		return nil, invalid
+	}
+
+	if users.PasswordResetRequired {
+		return nil, errors.New(errorMessage.PasswordResetRequired)
+	}
+
+	// link yang berhasil dibuka membuktikan kepemilikan email
+	if !users.Verified {
+		err = uc.RepoUser.UpdateVerifiedByUserId(users.Id)
+		if err != nil {
+			return nil, err
+		}
+
+		users.Verified = true
+		userKey := fmt.Sprintf("%s:%d", common.UserIdKey, users.Id)
+		_ = uc.Redis.DeleteData(context.Background(), userKey)
+	}
+
+	mfa, err := uc.secondFactor(users, true)
+	if err != nil || mfa != nil {
+		return mfa, err
+	}
+
+	return uc.createLogin(users, ipAddress, userAgent, common.AuthMethodMagicLink)
+}
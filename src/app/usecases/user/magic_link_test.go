@@ -0,0 +1,48 @@
+package user
+
+import (
+	"errors"
+	"testing"
+
+	"go-auth-service/src/infra/models"
+)
+
+func TestRequestMagicLink(t *testing.T) {
+	owner := &models.User{Id: 7, Email: "user@mail.com"}
+
+	tests := []struct {
+		name         string
+		email        string
+		getErr       error
+		wantMessages int
+	}{
+		{name: "registered email", email: owner.Email, wantMessages: 1},
+		{name: "unknown email", email: "other@mail.com"},
+		{name: "database unavailable", email: owner.Email, getErr: errors.New("pq: connection refused")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			publisher := &fakePublisher{}
+			uc := &userUseCase{
+				MagicLinkEnabled: true,
+				NatsPublisher:    publisher,
+				Redis:            newFakeRedis(),
+				RepoUser:         &fakeUserRepo{user: owner, getErr: tt.getErr},
+			}
+
+			// respons selalu berisi device token agar email yang terdaftar tidak bisa ditebak
+			resp, err := uc.RequestMagicLink(tt.email, "10.0.0.1", "Mozilla/5.0")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if resp == nil || resp.DeviceToken == "" {
+				t.Fatalf("resp = %+v, want a device token", resp)
+			}
+
+			if len(publisher.messages) != tt.wantMessages {
+				t.Fatalf("published %d messages, want %d", len(publisher.messages), tt.wantMessages)
+			}
+		})
+	}
+}
@@ -0,0 +1,348 @@
+package user
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	dtoNats "go-auth-service/src/app/dto/broker"
+	"go-auth-service/src/app/dto/user"
+	"go-auth-service/src/infra/constants/common"
+	errorMessage "go-auth-service/src/infra/constants/error_message"
+	"go-auth-service/src/infra/helper"
+	"go-auth-service/src/infra/models"
+)
+
+// EnrollTotp membuat secret TOTP baru yang belum aktif sampai dikonfirmasi dengan ConfirmTotp.
+// Enrollment ulang sebelum konfirmasi mengganti secret sebelumnya.
+func (uc *userUseCase) EnrollTotp(userId int64) (*user.TotpEnrollResp, error) {
+	totp, err := uc.RepoTotp.GetByUserId(userId)
+	if err == nil && totp.ConfirmedAt.Valid {
+		return nil, errors.New(errorMessage.TotpAlreadyEnabled)
+	}
+
+	users, err := uc.RepoUser.GetById(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := helper.GenerateTotpSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	encrypted, err := helper.Encrypt(common.PurposeTotpSecret, secret, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	err = uc.RepoTotp.Upsert(userId, encrypted)
+	if err != nil {
+		return nil, err
+	}
+
+	uri := helper.TotpUri(os.Getenv("APP_NAME"), users.Email, secret)
+
+	qrCode, err := helper.TotpQRCode(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	return &user.TotpEnrollResp{
+		Secret:     secret,
+		OtpauthUri: uri,
+		QrCode:     "data:image/png;base64," + base64.StdEncoding.EncodeToString(qrCode),
+	}, nil
+}
+
+// ConfirmTotp mengaktifkan TOTP dengan code pertama dari aplikasi authenticator
+// dan mengembalikan set recovery code pertama. Recovery code hanya ditampilkan sekali.
+func (uc *userUseCase) ConfirmTotp(userId int64, code string) ([]string, error) {
+	totp, err := uc.RepoTotp.GetByUserId(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	if totp.ConfirmedAt.Valid {
+		return nil, errors.New(errorMessage.TotpAlreadyEnabled)
+	}
+
+	err = uc.verifyTotp(userId, totp.Secret, code)
+	if err != nil {
+		return nil, err
+	}
+
+	err = uc.RepoTotp.Confirm(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	return uc.replaceRecoveryCodes(userId)
+}
+
+// RegenerateRecoveryCodes membuat set recovery code baru; seluruh code lama langsung tidak berlaku
+func (uc *userUseCase) RegenerateRecoveryCodes(userId int64) ([]string, error) {
+	totp, err := uc.RepoTotp.GetByUserId(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	if !totp.ConfirmedAt.Valid {
+		return nil, errors.New(errorMessage.TotpNotFound)
+	}
+
+	return uc.replaceRecoveryCodes(userId)
+}
+
+func (uc *userUseCase) replaceRecoveryCodes(userId int64) ([]string, error) {
+	codes, err := helper.GenerateRecoveryCodes(common.RecoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+
+	hashes := make([]string, 0, len(codes))
+	for _, code := range codes {
+		hashes = append(hashes, helper.HashRecoveryCode(code))
+	}
+
+	err = uc.RepoRecoveryCode.ReplaceByUserId(userId, hashes)
+	if err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// secondFactor mengembalikan MFA challenge jika user punya faktor kedua: TOTP aktif, atau
+// nomor telepon terverifikasi saat SMS_OTP_MFA aktif (allowSms false jika faktor pertama sudah SMS).
+// Hasil nil berarti login bisa langsung dilanjutkan.
+func (uc *userUseCase) secondFactor(users *models.User, allowSms bool) (*user.LoginResp, error) {
+	totp, err := uc.RepoTotp.GetByUserId(users.Id)
+	if err != nil && err.Error() != errorMessage.TotpNotFound {
+		return nil, err
+	}
+
+	if err == nil && totp.ConfirmedAt.Valid {
+		return uc.mfaChallenge(users.Id, common.MfaMethodTotp)
+	}
+
+	if allowSms && uc.SmsMfa && users.Phone.Valid {
+		// OTP yang masih berlaku tidak dikirim ulang sehingga form yang dikirim ulang
+		// (misalnya halaman /oauth/authorize) tetap memakai code yang sama
+		otpKey := fmt.Sprintf("%s:%s:%d", common.SmsOtpKey, common.SmsOtpPurposeLogin, users.Id)
+		pending, _ := uc.Redis.GetData(context.Background(), otpKey)
+		if pending == "" {
+			err = uc.sendSmsOtp(users.Id, common.SmsOtpPurposeLogin, users.Phone.String)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return uc.mfaChallenge(users.Id, common.MfaMethodSms)
+	}
+
+	return nil, nil
+}
+
+// mfaChallenge membuat token sementara untuk langkah kedua login. Token hanya disimpan dalam bentuk hash.
+func (uc *userUseCase) mfaChallenge(userId int64, method string) (*user.LoginResp, error) {
+	token, err := helper.RandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	dataRedis, _ := json.Marshal(user.MfaChallenge{
+		UserId: userId,
+		Method: method,
+	})
+
+	challengeKey := fmt.Sprintf("%s:%s", common.MfaChallengeKey, helper.HashToken(token))
+	err = uc.Redis.SetData(context.Background(), challengeKey, dataRedis, common.MfaChallengeExp)
+	if err != nil {
+		return nil, err
+	}
+
+	return &user.LoginResp{
+		MfaRequired: true,
+		MfaToken:    token,
+		MfaMethod:   method,
+	}, nil
+}
+
+// LoginMfa menukar MFA challenge token dan code TOTP/SMS (atau recovery code) dengan LoginResp biasa.
+// Percobaan code per challenge dibatasi dan challenge hanya bisa dipakai sekali. Code yang salah
+// juga dihitung ke lockout akun (loginFailed), sehingga meminta challenge baru lewat Login tidak
+// menambah jatah tebakan.
+func (uc *userUseCase) LoginMfa(data *user.LoginMfaReq, ipAddress, userAgent string) (*user.LoginResp, error) {
+	users, authMethod, err := uc.verifyMfaLogin(data, ipAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := uc.createLogin(users, ipAddress, userAgent, authMethod)
+	if err != nil {
+		return nil, err
+	}
+
+	uc.loginSucceeded(users.Email)
+
+	if authMethod == common.AuthMethodRecoveryCode {
+		uc.recoveryCodeUsed(users, ipAddress, userAgent)
+	}
+
+	return resp, nil
+}
+
+// verifyMfaLogin memeriksa code faktor kedua untuk MFA challenge dan menghapus challenge
+// tersebut. Mengembalikan user beserta metode autentikasi yang dipakai.
+func (uc *userUseCase) verifyMfaLogin(data *user.LoginMfaReq, ipAddress string) (*models.User, string, error) {
+	ctx := context.Background()
+	hashed := helper.HashToken(data.MfaToken)
+
+	// fail closed: tanpa Redis jumlah tebakan code tidak bisa dibatasi
+	attemptKey := fmt.Sprintf("%s:%s", common.MfaAttemptKey, hashed)
+	allowed, err := uc.Redis.IsAllowed(ctx, attemptKey, 5, common.MfaChallengeExp)
+	if err != nil {
+		return nil, "", errors.New(errorMessage.RateLimitUnavailable)
+	}
+	if !allowed {
+		return nil, "", errors.New(errorMessage.ToManyRequest)
+	}
+
+	challengeKey := fmt.Sprintf("%s:%s", common.MfaChallengeKey, hashed)
+	dataRedis, _ := uc.Redis.GetData(ctx, challengeKey)
+	if dataRedis == "" {
+		return nil, "", errors.New(errorMessage.InvalidMfaToken)
+	}
+
+	var challenge user.MfaChallenge
+	if err = json.Unmarshal([]byte(dataRedis), &challenge); err != nil {
+		return nil, "", errors.New(errorMessage.InvalidMfaToken)
+	}
+	userId := challenge.UserId
+
+	users, err := uc.RepoUser.GetById(userId)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err = uc.checkLoginLockout(users.Email, ipAddress); err != nil {
+		return nil, "", err
+	}
+
+	var authMethod string
+	var claimed bool
+	if challenge.Method == common.MfaMethodSms {
+		// recovery code hanya berlaku untuk TOTP
+		if data.RecoveryCode != "" {
+			return nil, "", uc.loginCodeFailed(users, ipAddress, errors.New(errorMessage.InvalidRecoveryCode))
+		}
+
+		authMethod = common.AuthMethodSms
+		_, err = uc.verifySmsOtp(userId, common.SmsOtpPurposeLogin, data.Code)
+		if err != nil {
+			return nil, "", uc.loginCodeFailed(users, ipAddress, err)
+		}
+	} else {
+		totp, err := uc.RepoTotp.GetByUserId(userId)
+		if err != nil || !totp.ConfirmedAt.Valid {
+			return nil, "", errors.New(errorMessage.InvalidMfaToken)
+		}
+
+		authMethod = common.AuthMethodTotp
+		if data.RecoveryCode != "" {
+			// challenge diklaim sebelum recovery code dipakai, agar request paralel yang kalah
+			// tidak menghabiskan recovery code tanpa menghasilkan sesi
+			if err = uc.claimMfaChallenge(ctx, challengeKey); err != nil {
+				return nil, "", err
+			}
+			claimed = true
+
+			authMethod = common.AuthMethodRecoveryCode
+			err = uc.useRecoveryCode(userId, data.RecoveryCode)
+		} else {
+			err = uc.verifyTotp(userId, totp.Secret, data.Code)
+		}
+		if err != nil {
+			return nil, "", uc.loginCodeFailed(users, ipAddress, err)
+		}
+	}
+
+	if !claimed {
+		if err = uc.claimMfaChallenge(ctx, challengeKey); err != nil {
+			return nil, "", err
+		}
+	}
+
+	return users, authMethod, nil
+}
+
+// claimMfaChallenge menghapus challenge; dari request paralel dengan challenge yang sama hanya satu yang berhasil
+func (uc *userUseCase) claimMfaChallenge(ctx context.Context, challengeKey string) error {
+	dataRedis, _ := uc.Redis.GetDeleteData(ctx, challengeKey)
+	if dataRedis == "" {
+		return errors.New(errorMessage.InvalidMfaToken)
+	}
+
+	return nil
+}
+
+// recoveryCodeUsed mengirim event agar user diberi tahu bahwa recovery code dipakai untuk login
+func (uc *userUseCase) recoveryCodeUsed(users *models.User, ipAddress, userAgent string) {
+	securityEventDto := dtoNats.AuthBrokerDto{
+		UserId:    users.Id,
+		IpAddress: ipAddress,
+		Device:    userAgent,
+		Event:     common.EventRecoveryCodeUsed,
+	}
+
+	dataPublishMarshal, _ := json.Marshal(securityEventDto)
+	if err := uc.NatsPublisher.Nats(dataPublishMarshal, common.NatsAuthSubject); err != nil {
+		log.Println(err)
+	}
+}
+
+// useRecoveryCode menandai recovery code sudah dipakai; code yang sama tidak bisa dipakai lagi
+func (uc *userUseCase) useRecoveryCode(userId int64, code string) error {
+	used, err := uc.RepoRecoveryCode.Use(userId, helper.HashRecoveryCode(code))
+	if err != nil {
+		return err
+	}
+
+	if !used {
+		return errors.New(errorMessage.InvalidRecoveryCode)
+	}
+
+	return nil
+}
+
+// verifyTotp mencocokkan code dengan secret terenkripsi. Code yang sudah pernah
+// berhasil dipakai (counter yang sama) ditolak agar tidak bisa di-replay.
+func (uc *userUseCase) verifyTotp(userId int64, encryptedSecret, code string) error {
+	secret, err := helper.Decrypt(common.PurposeTotpSecret, encryptedSecret)
+	if err != nil {
+		return err
+	}
+
+	counter, ok := helper.ValidateTotp(secret, code, time.Now())
+	if !ok {
+		return errors.New(errorMessage.InvalidTotpCode)
+	}
+
+	usedKey := fmt.Sprintf("%s:%d:%d", common.TotpUsedKey, userId, counter)
+	fresh, err := uc.Redis.SetDataNX(context.Background(), usedKey, 1, common.TotpUsedExp)
+	if err != nil {
+		return err
+	}
+
+	if !fresh {
+		return errors.New(errorMessage.InvalidTotpCode)
+	}
+
+	return nil
+}
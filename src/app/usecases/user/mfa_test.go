@@ -0,0 +1,183 @@
+package user
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"database/sql"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"go-auth-service/src/app/dto/user"
+	"go-auth-service/src/infra/constants/common"
+	errorMessage "go-auth-service/src/infra/constants/error_message"
+	"go-auth-service/src/infra/helper"
+	"go-auth-service/src/infra/models"
+	redis "go-auth-service/src/infra/persistence/redis/service"
+)
+
+// testTotpCode menghitung code TOTP (RFC 6238, SHA1, 6 digit, 30 detik) seperti aplikasi authenticator
+func testTotpCode(t *testing.T, secret string, at time.Time) string {
+	t.Helper()
+
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := binary.BigEndian.AppendUint64(nil, uint64(at.Unix()/30))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%06d", value%1000000)
+}
+
+// Kasus dijalankan berurutan dengan Redis yang sama, sehingga code yang sudah dipakai
+// pada kasus sebelumnya harus ditolak sebagai replay
+func TestVerifyTotpReplay(t *testing.T) {
+	loadTestSecrets(t)
+
+	secret, err := helper.GenerateTotpSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encrypted, err := helper.Encrypt(common.PurposeTotpSecret, secret, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	otherPurpose, err := helper.Encrypt(common.PurposeRevokeLink, secret, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	current := testTotpCode(t, secret, now)
+	previous := testTotpCode(t, secret, now.Add(-30*time.Second))
+	wrong := current[:5] + string('0'+(current[5]-'0'+1)%10)
+
+	redisDown := errors.New("redis: connection refused")
+	fake := newFakeRedis()
+	uc := &userUseCase{Redis: fake}
+
+	tests := []struct {
+		name     string
+		userId   int64
+		secret   string
+		code     string
+		redisErr error
+		wantErr  string
+	}{
+		{name: "current code", userId: 1, secret: encrypted, code: current},
+		{name: "replay current code", userId: 1, secret: encrypted, code: current, wantErr: errorMessage.InvalidTotpCode},
+		{name: "same code for another user", userId: 2, secret: encrypted, code: current},
+		{name: "previous period code", userId: 1, secret: encrypted, code: previous},
+		{name: "replay previous period code", userId: 1, secret: encrypted, code: previous, wantErr: errorMessage.InvalidTotpCode},
+		{name: "wrong code", userId: 3, secret: encrypted, code: wrong, wantErr: errorMessage.InvalidTotpCode},
+		{name: "secret encrypted for another purpose", userId: 3, secret: otherPurpose, code: current, wantErr: errorMessage.InvalidEncryptedData},
+		{name: "redis unavailable", userId: 3, secret: encrypted, code: current, redisErr: redisDown, wantErr: redisDown.Error()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake.err = tt.redisErr
+
+			err := uc.verifyTotp(tt.userId, tt.secret, tt.code)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// lostClaimRedis mensimulasikan request paralel yang lebih dulu mengklaim challenge
+type lostClaimRedis struct {
+	*fakeRedis
+}
+
+func (f *lostClaimRedis) GetDeleteData(ctx context.Context, key string) (string, error) {
+	return "", nil
+}
+
+func TestVerifyMfaLoginRecoveryCode(t *testing.T) {
+	const (
+		mfaToken     = "mfa-token"
+		recoveryCode = "abcd-efgh-ijkl"
+	)
+	owner := &models.User{Id: 7, Email: "user@mail.com"}
+	challengeKey := fmt.Sprintf("%s:%s", common.MfaChallengeKey, helper.HashToken(mfaToken))
+
+	tests := []struct {
+		name      string
+		lostClaim bool
+		wantErr   string
+		wantUsed  int
+	}{
+		{name: "recovery code accepted", wantUsed: 1},
+		{name: "challenge claimed by a parallel request", lostClaim: true, wantErr: errorMessage.InvalidMfaToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeRedis()
+			challenge, _ := json.Marshal(user.MfaChallenge{UserId: owner.Id, Method: common.MfaMethodTotp})
+			if err := fake.SetData(context.Background(), challengeKey, challenge, common.MfaChallengeExp); err != nil {
+				t.Fatal(err)
+			}
+
+			var servRedis redis.ServRedisInterface = fake
+			if tt.lostClaim {
+				servRedis = &lostClaimRedis{fake}
+			}
+
+			recoveryCodes := &fakeRecoveryCodeRepo{unused: map[string]bool{helper.HashRecoveryCode(recoveryCode): true}}
+			uc := &userUseCase{
+				Redis:            servRedis,
+				RepoUser:         &fakeUserRepo{user: owner},
+				RepoTotp:         &fakeTotpRepo{totp: &models.UserTotp{UserId: owner.Id, ConfirmedAt: sql.NullTime{Time: time.Now(), Valid: true}}},
+				RepoRecoveryCode: recoveryCodes,
+			}
+
+			users, authMethod, err := uc.verifyMfaLogin(&user.LoginMfaReq{MfaToken: mfaToken, RecoveryCode: recoveryCode}, "10.0.0.1")
+
+			// recovery code tidak boleh terpakai jika challenge sudah diklaim request lain
+			if len(recoveryCodes.used) != tt.wantUsed {
+				t.Fatalf("recovery codes used = %d, want %d", len(recoveryCodes.used), tt.wantUsed)
+			}
+
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if users.Id != owner.Id || authMethod != common.AuthMethodRecoveryCode {
+				t.Fatalf("user = %d, auth method = %q", users.Id, authMethod)
+			}
+			if fake.has(challengeKey) {
+				t.Fatal("challenge was not claimed")
+			}
+		})
+	}
+}
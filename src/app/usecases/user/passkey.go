@@ -0,0 +1,337 @@
+package user
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go-auth-service/src/app/dto/user"
+	"go-auth-service/src/infra/constants/common"
+	errorMessage "go-auth-service/src/infra/constants/error_message"
+	"go-auth-service/src/infra/helper"
+	"go-auth-service/src/infra/models"
+)
+
+const passkeyCredentialType = "public-key"
+
+// BeginPasskeyRegistration membuat options untuk navigator.credentials.create().
+// Passkey yang sudah terdaftar dikirim sebagai excludeCredentials.
+func (uc *userUseCase) BeginPasskeyRegistration(userId int64) (*user.PasskeyRegisterOptions, error) {
+	rpId, rpName, err := helper.WebAuthnRelyingParty()
+	if err != nil {
+		return nil, err
+	}
+
+	users, err := uc.RepoUser.GetById(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	credentials, err := uc.RepoWebauthn.GetByUserId(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	challenge, err := uc.passkeyChallenge(userId, common.WebAuthnCeremonyRegister)
+	if err != nil {
+		return nil, err
+	}
+
+	params := make([]user.PasskeyCredentialParam, 0, len(helper.WebAuthnAlgorithms))
+	for _, alg := range helper.WebAuthnAlgorithms {
+		params = append(params, user.PasskeyCredentialParam{Type: passkeyCredentialType, Alg: alg})
+	}
+
+	return &user.PasskeyRegisterOptions{
+		Challenge: challenge,
+		Rp: user.PasskeyRelyingParty{
+			Id:   rpId,
+			Name: rpName,
+		},
+		User: user.PasskeyUser{
+			Id:          passkeyUserHandle(userId),
+			Name:        users.Email,
+			DisplayName: users.Email,
+		},
+		PubKeyCredParams: params,
+		Timeout:          common.WebAuthnChallengeExp.Milliseconds(),
+		Attestation:      "none",
+		AuthenticatorSelection: user.PasskeyAuthenticatorSelection{
+			ResidentKey:      "required",
+			UserVerification: "required",
+		},
+		ExcludeCredentials: passkeyDescriptors(credentials),
+	}, nil
+}
+
+// FinishPasskeyRegistration memverifikasi hasil navigator.credentials.create() lalu menyimpan passkey
+func (uc *userUseCase) FinishPasskeyRegistration(userId int64, data *user.PasskeyRegisterReq) error {
+	clientDataJSON, err := helper.WebAuthnDecode(data.Response.ClientDataJSON)
+	if err != nil {
+		return err
+	}
+
+	attestationObject, err := helper.WebAuthnDecode(data.Response.AttestationObject)
+	if err != nil {
+		return err
+	}
+
+	challenge, err := uc.consumePasskeyChallenge(clientDataJSON, common.WebAuthnCeremonyRegister)
+	if err != nil {
+		return err
+	}
+
+	if challenge.UserId != userId {
+		return errors.New(errorMessage.InvalidWebAuthnChallenge)
+	}
+
+	credential, err := helper.VerifyWebAuthnRegistration(clientDataJSON, attestationObject, challenge.Challenge)
+	if err != nil {
+		return err
+	}
+
+	credentialId := base64.RawURLEncoding.EncodeToString(credential.CredentialId)
+	if credentialId != strings.TrimRight(data.Id, "=") {
+		return errors.New(errorMessage.InvalidWebAuthnResponse)
+	}
+
+	if _, err = uc.RepoWebauthn.GetByCredentialId(credentialId); err == nil {
+		return errors.New(errorMessage.PasskeyAlreadyRegistered)
+	}
+
+	nickname := data.Nickname
+	if nickname == "" {
+		nickname = "Passkey"
+	}
+
+	return uc.RepoWebauthn.Create(&models.UserWebauthnCredential{
+		UserId:       userId,
+		CredentialId: credentialId,
+		PublicKey:    credential.PublicKey,
+		SignCount:    int64(credential.SignCount),
+		Transports:   strings.Join(data.Response.Transports, ","),
+		Nickname:     nickname,
+	})
+}
+
+func (uc *userUseCase) ListPasskeys(userId int64) ([]*user.PasskeyResp, error) {
+	credentials, err := uc.RepoWebauthn.GetByUserId(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*user.PasskeyResp, 0, len(credentials))
+	for _, credential := range credentials {
+		result = append(result, &user.PasskeyResp{
+			Id:         credential.Id,
+			Nickname:   credential.Nickname,
+			Transports: passkeyTransports(credential.Transports),
+			CreatedAt:  helper.DateToStringByFormat(credential.CreatedAt, "02-01-2006 15:04:05"),
+			LastUsedAt: helper.DateToStringByFormat(credential.LastUsedAt, "02-01-2006 15:04:05"),
+		})
+	}
+
+	return result, nil
+}
+
+func (uc *userUseCase) DeletePasskey(userId, id int64) error {
+	deleted, err := uc.RepoWebauthn.Delete(id, userId)
+	if err != nil {
+		return err
+	}
+
+	if !deleted {
+		return errors.New(errorMessage.PasskeyNotFound)
+	}
+
+	return nil
+}
+
+// BeginPasskeyLogin membuat options untuk navigator.credentials.get(). Tanpa email,
+// allowCredentials kosong sehingga browser menawarkan passkey discoverable. Email yang
+// tidak terdaftar juga menghasilkan allowCredentials kosong agar tidak bisa dipakai untuk enumerasi.
+func (uc *userUseCase) BeginPasskeyLogin(email string) (*user.PasskeyLoginOptions, error) {
+	rpId, _, err := helper.WebAuthnRelyingParty()
+	if err != nil {
+		return nil, err
+	}
+
+	var userId int64
+	allowCredentials := []user.PasskeyDescriptor{}
+	if email != "" {
+		users, err := uc.RepoUser.GetByEmail(email)
+		if err == nil {
+			credentials, err := uc.RepoWebauthn.GetByUserId(users.Id)
+			if err != nil {
+				return nil, err
+			}
+
+			userId = users.Id
+			allowCredentials = passkeyDescriptors(credentials)
+		}
+	}
+
+	challenge, err := uc.passkeyChallenge(userId, common.WebAuthnCeremonyLogin)
+	if err != nil {
+		return nil, err
+	}
+
+	return &user.PasskeyLoginOptions{
+		Challenge:        challenge,
+		RpId:             rpId,
+		Timeout:          common.WebAuthnChallengeExp.Milliseconds(),
+		UserVerification: "required",
+		AllowCredentials: allowCredentials,
+	}, nil
+}
+
+// FinishPasskeyLogin memverifikasi hasil navigator.credentials.get() lalu menerbitkan sesi
+// dan token seperti Login. Passkey dengan user verification sudah multi-factor sehingga
+// tidak ada langkah TOTP.
+func (uc *userUseCase) FinishPasskeyLogin(data *user.PasskeyLoginReq, ipAddress, userAgent string) (*user.LoginResp, error) {
+	invalid := errors.New(errorMessage.InvalidWebAuthnResponse)
+
+	clientDataJSON, err := helper.WebAuthnDecode(data.Response.ClientDataJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	authenticatorData, err := helper.WebAuthnDecode(data.Response.AuthenticatorData)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := helper.WebAuthnDecode(data.Response.Signature)
+	if err != nil {
+		return nil, err
+	}
+
+	challenge, err := uc.consumePasskeyChallenge(clientDataJSON, common.WebAuthnCeremonyLogin)
+	if err != nil {
+		return nil, err
+	}
+
+	credential, err := uc.RepoWebauthn.GetByCredentialId(strings.TrimRight(data.Id, "="))
+	if err != nil {
+		return nil, invalid
+	}
+
+	if challenge.UserId != 0 && challenge.UserId != credential.UserId {
+		return nil, invalid
+	}
+
+	if data.Response.UserHandle != "" && strings.TrimRight(data.Response.UserHandle, "=") != passkeyUserHandle(credential.UserId) {
+		return nil, invalid
+	}
+
+	signCount, err := helper.VerifyWebAuthnAssertion(clientDataJSON, authenticatorData, signature, credential.PublicKey, challenge.Challenge, uint32(credential.SignCount))
+	if err != nil {
+		return nil, err
+	}
+
+	err = uc.RepoWebauthn.UpdateSignCount(credential.Id, int64(signCount))
+	if err != nil {
+		return nil, err
+	}
+
+	users, err := uc.RepoUser.GetById(credential.UserId)
+	if err != nil {
+		return nil, invalid
+	}
+
+	// passkey bisa didaftarkan oleh pihak yang mengambil alih akun, jadi ikut diblokir
+	// sampai pemilik akun mereset password (lihat ResetPassword)
+	if users.PasswordResetRequired {
+		return nil, errors.New(errorMessage.PasswordResetRequired)
+	}
+
+	if uc.VerificationPolicy == common.VerificationPolicyLogin && !users.Verified {
+		return nil, errors.New(errorMessage.EmailNotVerified)
+	}
+
+	return uc.createLogin(users, ipAddress, userAgent, common.AuthMethodPasskey)
+}
+
+// passkeyCeremony adalah challenge yang sudah diambil dari Redis
+type passkeyCeremony struct {
+	user.PasskeyChallenge
+	Challenge string
+}
+
+func (uc *userUseCase) passkeyChallenge(userId int64, ceremony string) (string, error) {
+	challenge, err := helper.RandomToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	dataRedis, _ := json.Marshal(user.PasskeyChallenge{
+		UserId:   userId,
+		Ceremony: ceremony,
+	})
+
+	challengeKey := fmt.Sprintf("%s:%s", common.WebAuthnChallengeKey, challenge)
+	err = uc.Redis.SetData(context.Background(), challengeKey, dataRedis, common.WebAuthnChallengeExp)
+	if err != nil {
+		return "", err
+	}
+
+	return challenge, nil
+}
+
+// consumePasskeyChallenge mengambil challenge dari clientDataJSON dan menghapusnya dari Redis
+// sehingga setiap challenge hanya bisa dipakai sekali
+func (uc *userUseCase) consumePasskeyChallenge(clientDataJSON []byte, ceremony string) (*passkeyCeremony, error) {
+	invalid := errors.New(errorMessage.InvalidWebAuthnChallenge)
+
+	challenge, err := helper.WebAuthnChallenge(clientDataJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	challengeKey := fmt.Sprintf("%s:%s", common.WebAuthnChallengeKey, challenge)
+	dataRedis, _ := uc.Redis.GetDeleteData(context.Background(), challengeKey)
+	if dataRedis == "" {
+		return nil, invalid
+	}
+
+	result := &passkeyCeremony{Challenge: challenge}
+	if err = json.Unmarshal([]byte(dataRedis), &result.PasskeyChallenge); err != nil {
+		return nil, invalid
+	}
+
+	if result.Ceremony != ceremony {
+		return nil, invalid
+	}
+
+	return result, nil
+}
+
+// passkeyUserHandle adalah user.id WebAuthn (base64url dari id user)
+func passkeyUserHandle(userId int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(userId, 10)))
+}
+
+func passkeyDescriptors(credentials []*models.UserWebauthnCredential) []user.PasskeyDescriptor {
+	descriptors := make([]user.PasskeyDescriptor, 0, len(credentials))
+	for _, credential := range credentials {
+		descriptors = append(descriptors, user.PasskeyDescriptor{
+			Type:       passkeyCredentialType,
+			Id:         credential.CredentialId,
+			Transports: passkeyTransports(credential.Transports),
+		})
+	}
+
+	return descriptors
+}
+
+func passkeyTransports(value string) []string {
+	if value == "" {
+		return []string{}
+	}
+
+	return strings.Split(value, ",")
+}
@@ -0,0 +1,123 @@
+package user
+
+import (
+	"errors"
+	"log"
+
+	"go-auth-service/src/infra/constants/common"
+	errorMessage "go-auth-service/src/infra/constants/error_message"
+	"go-auth-service/src/infra/helper"
+	"go-auth-service/src/infra/models"
+	"go-auth-service/src/infra/password"
+)
+
+// newPasswordHash memeriksa password baru terhadap password policy dan N password terakhir
+// (termasuk password saat ini) lalu mengembalikan hash-nya
+func (uc *userUseCase) newPasswordHash(users *models.User, newPassword string) (string, error) {
+	userInputs := []string{users.Email}
+	detail, err := uc.RepoUser.GetUserDetailById(users.Id)
+	if err == nil {
+		userInputs = append(userInputs, detail.FirstName, detail.LastName)
+	}
+
+	err = validatePassword(uc.PasswordPolicy, newPassword, userInputs...)
+	if err != nil {
+		return "", err
+	}
+
+	if history := uc.PasswordPolicy.History(); history > 0 {
+		// akun lama belum punya riwayat, password saat ini tetap dicek
+		hashes := []string{users.Password}
+
+		recent, err := uc.RepoPasswordHistory.GetRecentByUserId(users.Id, history)
+		if err != nil {
+			return "", err
+		}
+		hashes = append(hashes, recent...)
+
+		for _, hash := range hashes {
+			err = helper.VerifyPassword(hash, newPassword)
+			if err == nil {
+				return "", &password.PolicyError{Message: errorMessage.PasswordReused}
+			}
+			if errors.Is(err, helper.ErrPasswordHashBusy) {
+				return "", err
+			}
+		}
+	}
+
+	return helper.HashPassword(newPassword)
+}
+
+// savePasswordHistory mencatat hash password yang baru dipasang dan menghapus riwayat
+// yang melebihi PASSWORD_HISTORY
+func (uc *userUseCase) savePasswordHistory(userId int64, passwordHash string) error {
+	history := uc.PasswordPolicy.History()
+	if history <= 0 {
+		return nil
+	}
+
+	err := uc.RepoPasswordHistory.Create(userId, passwordHash)
+	if err != nil {
+		return err
+	}
+
+	return uc.RepoPasswordHistory.DeleteOlderByUserId(userId, history)
+}
+
+// validatePassword menjalankan password policy lalu menolak password yang ada di dataset
+// password bocor. Dipanggil sebelum HashPassword di setiap tempat password dipasang.
+func validatePassword(policy password.PasswordPolicy, newPassword string, userInputs ...string) error {
+	err := policy.Validate(newPassword, userInputs...)
+	if err != nil {
+		return err
+	}
+
+	breached, err := helper.IsBreachedPassword(newPassword)
+	if err != nil {
+		return err
+	}
+
+	if breached {
+		return &password.PolicyError{Message: errorMessage.PasswordBreached}
+	}
+
+	return nil
+}
+
+// rehashPassword mengganti hash password yang dibuat dengan algoritma atau parameter lama
+// setelah login berhasil. Kegagalan hanya dicatat karena hash lama masih valid.
+func (uc *userUseCase) rehashPassword(users *models.User, plainPassword string) {
+	if !helper.PasswordNeedsRehash(users.Password) {
+		return
+	}
+
+	passwordHash, err := helper.HashPassword(plainPassword)
+	if err != nil {
+		log.Println("rehash password failed:", err)
+		return
+	}
+
+	err = uc.RepoUser.RehashPasswordByUserId(users.Id, users.Password, passwordHash)
+	if err != nil {
+		log.Println("rehash password failed:", err)
+		return
+	}
+
+	users.Password = passwordHash
+}
+
+// PasswordHashMetrics mengembalikan kondisi worker pool hashing password. Hanya admin.
+func (uc *userUseCase) PasswordHashMetrics(adminId int64) (*helper.PasswordHashMetrics, error) {
+	admin, err := uc.RepoUser.GetById(adminId)
+	if err != nil {
+		return nil, err
+	}
+
+	if admin.UserTypeId != common.SuperAdmin && admin.UserTypeId != common.Admin {
+		return nil, errors.New(errorMessage.Forbidden)
+	}
+
+	metrics := helper.GetPasswordHashMetrics()
+	return &metrics, nil
+}
@@ -0,0 +1,82 @@
+package user
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"go-auth-service/src/infra/config"
+	"go-auth-service/src/infra/helper"
+	"go-auth-service/src/infra/models"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func loadTestPasswordHasher(t *testing.T, algorithm string) {
+	t.Helper()
+
+	conf := config.PasswordHashConf{Algorithm: algorithm, Argon2Memory: 64, Argon2Time: 1, Argon2Parallelism: 1, BcryptCost: bcrypt.MinCost, Workers: 1, QueueSize: 4}
+	if err := helper.LoadPasswordHasher(conf); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRehashPassword(t *testing.T) {
+	t.Cleanup(func() { loadTestPasswordHasher(t, "bcrypt") })
+
+	loadTestPasswordHasher(t, "bcrypt")
+	bcryptHash, err := helper.HashPassword("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loadTestPasswordHasher(t, "argon2id")
+	argon2Hash, err := helper.HashPassword("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		algorithm  string
+		hash       string
+		repoErr    error
+		wantRehash bool
+		wantPrefix string
+	}{
+		{name: "bcrypt to argon2id", algorithm: "argon2id", hash: bcryptHash, wantRehash: true, wantPrefix: "$argon2id$"},
+		{name: "argon2id to bcrypt", algorithm: "bcrypt", hash: argon2Hash, wantRehash: true, wantPrefix: "$2a$"},
+		{name: "already current", algorithm: "argon2id", hash: argon2Hash},
+		{name: "update failed keeps old hash", algorithm: "argon2id", hash: bcryptHash, repoErr: errors.New("db down")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loadTestPasswordHasher(t, tt.algorithm)
+
+			repo := &fakeUserRepo{err: tt.repoErr}
+			uc := &userUseCase{RepoUser: repo}
+			users := &models.User{Id: 7, Password: tt.hash}
+
+			uc.rehashPassword(users, "correct horse")
+
+			newHash, rehashed := repo.rehashed[users.Id]
+			if rehashed != tt.wantRehash {
+				t.Fatalf("rehashed = %v, want %v", rehashed, tt.wantRehash)
+			}
+
+			if !tt.wantRehash {
+				if users.Password != tt.hash {
+					t.Fatal("password hash changed without a rehash")
+				}
+				return
+			}
+
+			if users.Password != newHash || !strings.HasPrefix(newHash, tt.wantPrefix) {
+				t.Fatalf("new hash %q, want prefix %q", newHash, tt.wantPrefix)
+			}
+			if err := helper.VerifyPassword(newHash, "correct horse"); err != nil {
+				t.Fatalf("new hash does not verify: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,226 @@
+package user
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"go-auth-service/src/app/dto/user"
+	"go-auth-service/src/infra/constants/common"
+	errorMessage "go-auth-service/src/infra/constants/error_message"
+	"go-auth-service/src/infra/helper"
+)
+
+// RequestPhoneVerification mengirim OTP ke nomor baru. Nomor baru disimpan setelah
+// OTP dikonfirmasi lewat VerifyPhone.
+func (uc *userUseCase) RequestPhoneVerification(userId int64, phone string) error {
+	users, err := uc.RepoUser.GetById(userId)
+	if err != nil {
+		return err
+	}
+
+	if users.Phone.String == phone {
+		return errors.New(errorMessage.PhoneAlready)
+	}
+
+	owner, err := uc.RepoUser.GetByPhone(phone)
+	if err == nil && owner.Id != userId {
+		return errors.New(errorMessage.PhoneAlready)
+	}
+
+	return uc.sendSmsOtp(userId, common.SmsOtpPurposePhone, phone)
+}
+
+// VerifyPhone memakai OTP dari RequestPhoneVerification lalu menyimpan nomor tersebut
+func (uc *userUseCase) VerifyPhone(userId int64, code string) error {
+	phone, err := uc.verifySmsOtp(userId, common.SmsOtpPurposePhone, code)
+	if err != nil {
+		return err
+	}
+
+	err = uc.RepoUser.UpdatePhoneByUserId(userId, phone)
+	if err != nil {
+		return err
+	}
+
+	userKey := fmt.Sprintf("%s:%d", common.UserIdKey, userId)
+	_ = uc.Redis.DeleteData(context.Background(), userKey)
+
+	return nil
+}
+
+func (uc *userUseCase) RemovePhone(userId int64) error {
+	users, err := uc.RepoUser.GetById(userId)
+	if err != nil {
+		return err
+	}
+
+	if !users.Phone.Valid {
+		return errors.New(errorMessage.PhoneNotFound)
+	}
+
+	err = uc.RepoUser.DeletePhoneByUserId(userId)
+	if err != nil {
+		return err
+	}
+
+	userKey := fmt.Sprintf("%s:%d", common.UserIdKey, userId)
+	_ = uc.Redis.DeleteData(context.Background(), userKey)
+
+	return nil
+}
+
+// RequestSmsLogin mengirim OTP login ke nomor terverifikasi. Seperti ForgotPassword, nomor
+// yang tidak terdaftar atau terkena rate limit per user tidak menghasilkan error.
+func (uc *userUseCase) RequestSmsLogin(phone, ipAddress string) error {
+	if !uc.SmsLogin {
+		return errors.New(errorMessage.SmsLoginDisabled)
+	}
+
+	ipKey := fmt.Sprintf("%s:%s", common.SmsLoginIpKey, ipAddress)
+	allowed, _ := uc.Redis.IsAllowed(context.Background(), ipKey, 10, common.RateLimit)
+	if !allowed {
+		return errors.New(errorMessage.ToManyRequest)
+	}
+
+	users, err := uc.RepoUser.GetByPhone(phone)
+	if err != nil {
+		if err.Error() == errorMessage.UserNotFound {
+			return nil
+		}
+		return err
+	}
+
+	err = uc.sendSmsOtp(users.Id, common.SmsOtpPurposeLogin, phone)
+	if err != nil && err.Error() != errorMessage.ToManyRequest {
+		return err
+	}
+
+	return nil
+}
+
+// LoginSms memakai OTP dari RequestSmsLogin lalu melanjutkan seperti Login.
+// Akun dengan TOTP aktif tetap harus melewati LoginMfa. OTP yang salah dihitung ke lockout akun.
+func (uc *userUseCase) LoginSms(data *user.SmsLoginReq, ipAddress, userAgent string) (*user.LoginResp, error) {
+	if !uc.SmsLogin {
+		return nil, errors.New(errorMessage.SmsLoginDisabled)
+	}
+
+	users, err := uc.RepoUser.GetByPhone(data.Phone)
+	if err != nil {
+		return nil, errors.New(errorMessage.InvalidSmsOtp)
+	}
+
+	if err = uc.checkLoginLockout(users.Email, ipAddress); err != nil {
+		return nil, err
+	}
+
+	phone, err := uc.verifySmsOtp(users.Id, common.SmsOtpPurposeLogin, data.Code)
+	if err != nil {
+		return nil, uc.loginCodeFailed(users, ipAddress, err)
+	}
+
+	if phone != data.Phone {
+		return nil, errors.New(errorMessage.InvalidSmsOtp)
+	}
+
+	if users.PasswordResetRequired {
+		return nil, errors.New(errorMessage.PasswordResetRequired)
+	}
+
+	if uc.VerificationPolicy == common.VerificationPolicyLogin && !users.Verified {
+		return nil, errors.New(errorMessage.EmailNotVerified)
+	}
+
+	mfa, err := uc.secondFactor(users, false)
+	if err != nil || mfa != nil {
+		return mfa, err
+	}
+
+	resp, err := uc.createLogin(users, ipAddress, userAgent, common.AuthMethodSms)
+	if err != nil {
+		return nil, err
+	}
+
+	uc.loginSucceeded(users.Email)
+	return resp, nil
+}
+
+// sendSmsOtp membuat OTP baru untuk purpose tertentu dan mengirimkannya lewat SMSSender.
+// OTP sebelumnya dengan purpose yang sama langsung tidak berlaku.
+func (uc *userUseCase) sendSmsOtp(userId int64, purpose, phone string) error {
+	ctx := context.Background()
+
+	sendKey := fmt.Sprintf("%s:%s:%d", common.SmsOtpSendKey, purpose, userId)
+	allowed, _ := uc.Redis.IsAllowed(ctx, sendKey, 3, common.RateLimit)
+	if !allowed {
+		return errors.New(errorMessage.ToManyRequest)
+	}
+
+	code, err := helper.RandomDigits(6)
+	if err != nil {
+		return err
+	}
+
+	dataRedis, _ := json.Marshal(user.SmsOtp{
+		Phone:    phone,
+		CodeHash: helper.HashToken(code),
+	})
+
+	otpKey := fmt.Sprintf("%s:%s:%d", common.SmsOtpKey, purpose, userId)
+	err = uc.Redis.SetData(ctx, otpKey, dataRedis, common.SmsOtpExp)
+	if err != nil {
+		return err
+	}
+
+	message := fmt.Sprintf("%s: your verification code is %s. It expires in %d minutes. Do not share this code.",
+		os.Getenv("APP_NAME"), code, int(common.SmsOtpExp.Minutes()))
+
+	return uc.SmsSender.Send(phone, message)
+}
+
+// verifySmsOtp mencocokkan OTP lalu menghapusnya (sekali pakai) dan mengembalikan nomor tujuan OTP.
+// Percobaan code dibatasi per user dan purpose dalam SmsOtpAttemptWindow, tidak direset saat OTP
+// dikirim ulang, sehingga meminta OTP baru tidak menambah jatah tebakan.
+func (uc *userUseCase) verifySmsOtp(userId int64, purpose, code string) (string, error) {
+	ctx := context.Background()
+	invalid := errors.New(errorMessage.InvalidSmsOtp)
+
+	// fail closed: tanpa Redis jumlah tebakan code tidak bisa dibatasi
+	attemptKey := fmt.Sprintf("%s:%s:%d", common.SmsOtpAttemptKey, purpose, userId)
+	allowed, err := uc.Redis.IsAllowed(ctx, attemptKey, 10, common.SmsOtpAttemptWindow)
+	if err != nil {
+		return "", errors.New(errorMessage.RateLimitUnavailable)
+	}
+	if !allowed {
+		return "", errors.New(errorMessage.ToManyRequest)
+	}
+
+	otpKey := fmt.Sprintf("%s:%s:%d", common.SmsOtpKey, purpose, userId)
+	dataRedis, _ := uc.Redis.GetData(ctx, otpKey)
+	if dataRedis == "" {
+		return "", invalid
+	}
+
+	var otp user.SmsOtp
+	if err = json.Unmarshal([]byte(dataRedis), &otp); err != nil {
+		return "", invalid
+	}
+
+	if subtle.ConstantTimeCompare([]byte(helper.HashToken(code)), []byte(otp.CodeHash)) != 1 {
+		return "", invalid
+	}
+
+	// request paralel dengan OTP yang sama hanya satu yang berhasil
+	dataRedis, _ = uc.Redis.GetDeleteData(ctx, otpKey)
+	if dataRedis == "" {
+		return "", invalid
+	}
+
+	_ = uc.Redis.DeleteData(ctx, attemptKey)
+
+	return otp.Phone, nil
+}
@@ -0,0 +1,128 @@
+package user
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"testing"
+
+	"go-auth-service/src/app/dto/user"
+	"go-auth-service/src/infra/constants/common"
+	errorMessage "go-auth-service/src/infra/constants/error_message"
+	"go-auth-service/src/infra/models"
+)
+
+var smsOtpCode = regexp.MustCompile(`\b\d{6}\b`)
+
+func newSmsUseCase(t *testing.T) (*userUseCase, *fakeRedis, *fakeSmsSender) {
+	t.Helper()
+
+	fake := newFakeRedis()
+	sender := &fakeSmsSender{}
+	uc := &userUseCase{Redis: fake, SmsSender: sender, NatsPublisher: &fakePublisher{}, Lockout: testLockoutConf()}
+
+	return uc, fake, sender
+}
+
+// sendTestSmsOtp mengirim OTP baru dan mengembalikan code dari isi SMS
+func sendTestSmsOtp(t *testing.T, uc *userUseCase, sender *fakeSmsSender, userId int64) string {
+	t.Helper()
+
+	if err := uc.sendSmsOtp(userId, common.SmsOtpPurposeLogin, "+6281234567890"); err != nil {
+		t.Fatal(err)
+	}
+
+	code := smsOtpCode.FindString(sender.message)
+	if code == "" {
+		t.Fatalf("no code in SMS %q", sender.message)
+	}
+	return code
+}
+
+func wrongSmsOtp(code string) string {
+	return code[:5] + string('0'+(code[5]-'0'+1)%10)
+}
+
+// Jatah tebakan tidak direset saat OTP dikirim ulang
+func TestVerifySmsOtpAttemptsSurviveResend(t *testing.T) {
+	uc, _, sender := newSmsUseCase(t)
+
+	code := sendTestSmsOtp(t, uc, sender, 7)
+	for i := 0; i < 9; i++ {
+		if _, err := uc.verifySmsOtp(7, common.SmsOtpPurposeLogin, wrongSmsOtp(code)); err == nil || err.Error() != errorMessage.InvalidSmsOtp {
+			t.Fatalf("guess %d: err = %v, want invalid OTP", i+1, err)
+		}
+	}
+
+	code = sendTestSmsOtp(t, uc, sender, 7)
+	if _, err := uc.verifySmsOtp(7, common.SmsOtpPurposeLogin, wrongSmsOtp(code)); err == nil || err.Error() != errorMessage.InvalidSmsOtp {
+		t.Fatalf("10th guess: err = %v, want invalid OTP", err)
+	}
+
+	// tebakan ke-11 ditolak walaupun code-nya benar
+	if _, err := uc.verifySmsOtp(7, common.SmsOtpPurposeLogin, code); err == nil || err.Error() != errorMessage.ToManyRequest {
+		t.Fatalf("11th guess: err = %v, want too many requests", err)
+	}
+
+	// user lain punya jatah sendiri
+	otherCode := sendTestSmsOtp(t, uc, sender, 8)
+	if phone, err := uc.verifySmsOtp(8, common.SmsOtpPurposeLogin, otherCode); err != nil || phone != "+6281234567890" {
+		t.Fatalf("other user: phone = %q, err = %v", phone, err)
+	}
+}
+
+func TestVerifySmsOtp(t *testing.T) {
+	uc, fake, sender := newSmsUseCase(t)
+	attemptKey := fmt.Sprintf("%s:%s:%d", common.SmsOtpAttemptKey, common.SmsOtpPurposeLogin, 7)
+
+	code := sendTestSmsOtp(t, uc, sender, 7)
+	if _, err := uc.verifySmsOtp(7, common.SmsOtpPurposeLogin, wrongSmsOtp(code)); err == nil {
+		t.Fatal("wrong code accepted")
+	}
+
+	phone, err := uc.verifySmsOtp(7, common.SmsOtpPurposeLogin, code)
+	if err != nil || phone != "+6281234567890" {
+		t.Fatalf("phone = %q, err = %v", phone, err)
+	}
+	if fake.has(attemptKey) {
+		t.Fatal("attempt counter was not reset after a correct code")
+	}
+
+	// OTP hanya bisa dipakai sekali
+	if _, err = uc.verifySmsOtp(7, common.SmsOtpPurposeLogin, code); err == nil || err.Error() != errorMessage.InvalidSmsOtp {
+		t.Fatalf("reused OTP: err = %v, want invalid OTP", err)
+	}
+
+	fake.err = errors.New("redis: connection refused")
+	if _, err = uc.verifySmsOtp(7, common.SmsOtpPurposeLogin, code); err == nil || err.Error() != errorMessage.RateLimitUnavailable {
+		t.Fatalf("redis down: err = %v, want rate limit unavailable", err)
+	}
+}
+
+// OTP login yang salah dihitung ke lockout akun seperti password yang salah
+func TestLoginSmsWrongCodeCountsTowardLockout(t *testing.T) {
+	uc, _, sender := newSmsUseCase(t)
+	uc.SmsLogin = true
+	// tanpa delay progresif agar setiap percobaan sampai ke verifikasi OTP
+	uc.Lockout.DelayAfter = 0
+	uc.RepoUser = &fakeUserRepo{user: &models.User{Id: 7, Email: "user@mail.com"}}
+
+	code := sendTestSmsOtp(t, uc, sender, 7)
+	req := &user.SmsLoginReq{Phone: "+6281234567890", Code: wrongSmsOtp(code)}
+
+	var err error
+	for i := 0; i < uc.Lockout.Threshold; i++ {
+		_, err = uc.LoginSms(req, "10.0.0.1", "test")
+	}
+
+	var lockout *LockoutError
+	if !errors.As(err, &lockout) || lockout.Message != errorMessage.AccountLocked {
+		t.Fatalf("err = %v, want account locked", err)
+	}
+
+	// code yang benar pun ditolak selama lockout
+	req.Code = code
+	if _, err = uc.LoginSms(req, "10.0.0.1", "test"); !errors.As(err, &lockout) {
+		t.Fatalf("err = %v, want lockout", err)
+	}
+}
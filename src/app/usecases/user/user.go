@@ -7,19 +7,28 @@ import (
 	"fmt"
 	"log"
 	"mime/multipart"
+	"strconv"
 	"time"
 
 	dtoNats "go-auth-service/src/app/dto/broker"
 	"go-auth-service/src/app/dto/user"
 	natsPublisher "go-auth-service/src/infra/broker/nats/publisher"
+	"go-auth-service/src/infra/config"
 	"go-auth-service/src/infra/constants/common"
 	errorMessage "go-auth-service/src/infra/constants/error_message"
 	"go-auth-service/src/infra/helper"
 	"go-auth-service/src/infra/models"
+	"go-auth-service/src/infra/password"
 	repoHistory "go-auth-service/src/infra/persistence/postgres/history"
+	repoPasswordHistory "go-auth-service/src/infra/persistence/postgres/password_history"
+	repoRecoveryCode "go-auth-service/src/infra/persistence/postgres/recovery_code"
 	reporefreshToken "go-auth-service/src/infra/persistence/postgres/refresh_token"
+	repoSession "go-auth-service/src/infra/persistence/postgres/session"
+	repoTotp "go-auth-service/src/infra/persistence/postgres/totp"
 	repoUser "go-auth-service/src/infra/persistence/postgres/user"
+	repoWebauthn "go-auth-service/src/infra/persistence/postgres/webauthn"
 	redis "go-auth-service/src/infra/persistence/redis/service"
+	"go-auth-service/src/infra/sms"
 )
 
 type UserUCInterface interface {
@@ -27,19 +36,65 @@ type UserUCInterface interface {
 	Login(data *user.LoginReq, ipAddress, userAgent string) (*user.LoginResp, error)
 	Me(userId int64) (*user.UserDetails, error)
 	RefreshToken(refreshToken, userAgent string) (*user.RefreshTokenResp, error)
-	Logout(userId int64, email, userAgent string) error
-	RevokeToken(emailEncrypt string) error
+	Logout(claims *helper.TokenClaims) error
+	ListSessions(claims *helper.TokenClaims) ([]*user.SessionResp, error)
+	RevokeSession(claims *helper.TokenClaims, sessionId string) error
+	RevokeOtherSessions(claims *helper.TokenClaims) error
+	RevokeToken(token string, all bool) error
 	UpdateUserProfile(userId int64, data *user.UpdateUserProfileReq) error
 	UpdateProfilePicture(userId int64, fileHeader *multipart.FileHeader) error
 	UpdatePassword(userId int64, oldPassword, newPassword string) error
+	ForgotPassword(email string) error
+	ResetPassword(token, newPassword string) error
+	VerifyEmail(token string) error
+	ResendVerification(email string) error
+	EnrollTotp(userId int64) (*user.TotpEnrollResp, error)
+	ConfirmTotp(userId int64, code string) ([]string, error)
+	RegenerateRecoveryCodes(userId int64) ([]string, error)
+	LoginMfa(data *user.LoginMfaReq, ipAddress, userAgent string) (*user.LoginResp, error)
+	Authenticate(data *user.LoginReq, otp, ipAddress, userAgent string) (int64, string, error)
+	CreateOAuthLogin(userId int64, clientId, scope, authMethod, ipAddress, userAgent string) (*user.LoginResp, error)
+	RefreshOAuthToken(refreshToken, clientId, userAgent string) (*user.RefreshTokenResp, string, error)
+	BeginPasskeyRegistration(userId int64) (*user.PasskeyRegisterOptions, error)
+	FinishPasskeyRegistration(userId int64, data *user.PasskeyRegisterReq) error
+	ListPasskeys(userId int64) ([]*user.PasskeyResp, error)
+	DeletePasskey(userId, id int64) error
+	BeginPasskeyLogin(email string) (*user.PasskeyLoginOptions, error)
+	FinishPasskeyLogin(data *user.PasskeyLoginReq, ipAddress, userAgent string) (*user.LoginResp, error)
+	RequestMagicLink(email, ipAddress, userAgent string) (*user.MagicLinkResp, error)
+	LoginMagicLink(data *user.MagicLinkLoginReq, ipAddress, userAgent string) (*user.LoginResp, error)
+	RequestPhoneVerification(userId int64, phone string) error
+	VerifyPhone(userId int64, code string) error
+	RemovePhone(userId int64) error
+	RequestSmsLogin(phone, ipAddress string) error
+	LoginSms(data *user.SmsLoginReq, ipAddress, userAgent string) (*user.LoginResp, error)
+	UnlockAccount(adminId, userId int64) error
+	PasswordHashMetrics(adminId int64) (*helper.PasswordHashMetrics, error)
 }
 
 type userUseCase struct {
-	NatsPublisher    natsPublisher.PublisherInterface
-	Redis            redis.ServRedisInterface
-	RepoUser         repoUser.UserRepository
-	RepoHistory      repoHistory.HistoryRepository
-	RepoRefreshToken reporefreshToken.RefreshTokenRepository
+	NatsPublisher       natsPublisher.PublisherInterface
+	Redis               redis.ServRedisInterface
+	RepoUser            repoUser.UserRepository
+	RepoHistory         repoHistory.HistoryRepository
+	RepoRefreshToken    reporefreshToken.RefreshTokenRepository
+	RepoSession         repoSession.SessionRepository
+	RepoTotp            repoTotp.TotpRepository
+	RepoWebauthn        repoWebauthn.WebauthnRepository
+	RepoRecoveryCode    repoRecoveryCode.RecoveryCodeRepository
+	RepoPasswordHistory repoPasswordHistory.PasswordHistoryRepository
+	SmsSender           sms.SMSSender
+	PasswordPolicy      password.PasswordPolicy
+
+	// VerificationPolicy adalah nilai EMAIL_VERIFICATION_POLICY (off, login, endpoints)
+	VerificationPolicy string
+	// MagicLinkEnabled adalah nilai MAGIC_LINK_ENABLED
+	MagicLinkEnabled bool
+	// SmsLogin dan SmsMfa adalah nilai SMS_OTP_LOGIN dan SMS_OTP_MFA
+	SmsLogin bool
+	SmsMfa   bool
+
+	Lockout config.LockoutConf
 }
 
 func NewUserUseCase(
@@ -48,13 +103,39 @@ func NewUserUseCase(
 	repoUser repoUser.UserRepository,
 	repoHistory repoHistory.HistoryRepository,
 	repoRefreshToken reporefreshToken.RefreshTokenRepository,
+	repoSession repoSession.SessionRepository,
+	repoTotp repoTotp.TotpRepository,
+	repoWebauthn repoWebauthn.WebauthnRepository,
+	repoRecoveryCode repoRecoveryCode.RecoveryCodeRepository,
+	repoPasswordHistory repoPasswordHistory.PasswordHistoryRepository,
+	smsSender sms.SMSSender,
+	passwordPolicy password.PasswordPolicy,
+	verificationPolicy string,
+	magicLinkEnabled bool,
+	smsLogin bool,
+	smsMfa bool,
+	lockout config.LockoutConf,
 ) UserUCInterface {
 	return &userUseCase{
-		NatsPublisher:    natsPublisher,
-		Redis:            redisService,
-		RepoUser:         repoUser,
-		RepoHistory:      repoHistory,
-		RepoRefreshToken: repoRefreshToken,
+		NatsPublisher:       natsPublisher,
+		Redis:               redisService,
+		RepoUser:            repoUser,
+		RepoHistory:         repoHistory,
+		RepoRefreshToken:    repoRefreshToken,
+		RepoSession:         repoSession,
+		RepoTotp:            repoTotp,
+		RepoWebauthn:        repoWebauthn,
+		RepoRecoveryCode:    repoRecoveryCode,
+		RepoPasswordHistory: repoPasswordHistory,
+		SmsSender:           smsSender,
+		PasswordPolicy:      passwordPolicy,
+
+		VerificationPolicy: verificationPolicy,
+		MagicLinkEnabled:   magicLinkEnabled,
+		SmsLogin:           smsLogin,
+		SmsMfa:             smsMfa,
+
+		Lockout: lockout,
 	}
 }
 
@@ -64,12 +145,27 @@ func (uc *userUseCase) Register(data *user.RegisterReq) error {
 		return errors.New(errorMessage.EmailAlready)
 	}
 
-	userId, err := uc.RepoUser.Create(data)
+	err = validatePassword(uc.PasswordPolicy, data.Password, data.Email, data.FirstName, data.LastName)
+	if err != nil {
+		return err
+	}
+
+	passwordHash, err := helper.HashPassword(data.Password)
+	if err != nil {
+		return err
+	}
+
+	userId, err := uc.RepoUser.Create(data, passwordHash)
 	if err != nil {
 		log.Println(err)
 		return err
 	}
 
+	err = uc.savePasswordHistory(userId, passwordHash)
+	if err != nil {
+		log.Println(err)
+	}
+
 	sendMailDto := dtoNats.AuthBrokerDto{
 		UserId: userId,
 		Event:  common.EventRegister,
@@ -88,55 +184,190 @@ func (uc *userUseCase) Register(data *user.RegisterReq) error {
 }
 
 func (uc *userUseCase) Login(data *user.LoginReq, ipAddress, userAgent string) (*user.LoginResp, error) {
+	users, mfa, err := uc.verifyPasswordLogin(data, ipAddress)
+	if err != nil || mfa != nil {
+		return mfa, err
+	}
+
+	resp, err := uc.createLogin(users, ipAddress, userAgent, common.AuthMethodPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	uc.loginSucceeded(data.Email)
+	return resp, nil
+}
+
+// Authenticate memeriksa password dan faktor kedua (otp berisi code TOTP/SMS atau recovery code)
+// tanpa membuat sesi, dipakai oleh /oauth/authorize. Sesi dan token dibuat saat authorization code
+// ditukar lewat CreateOAuthLogin. Mengembalikan id user dan metode autentikasi.
+func (uc *userUseCase) Authenticate(data *user.LoginReq, otp, ipAddress, userAgent string) (int64, string, error) {
+	users, mfa, err := uc.verifyPasswordLogin(data, ipAddress)
+	if err != nil {
+		return 0, "", err
+	}
+
+	authMethod := common.AuthMethodPassword
+	if mfa != nil {
+		if otp == "" {
+			return 0, "", errors.New(errorMessage.MfaRequired)
+		}
+
+		mfaReq := &user.LoginMfaReq{MfaToken: mfa.MfaToken, Code: otp}
+		if len(otp) != 6 {
+			mfaReq = &user.LoginMfaReq{MfaToken: mfa.MfaToken, RecoveryCode: otp}
+		}
+
+		users, authMethod, err = uc.verifyMfaLogin(mfaReq, ipAddress)
+		if err != nil {
+			return 0, "", err
+		}
+	}
+
+	uc.loginSucceeded(users.Email)
+
+	if authMethod == common.AuthMethodRecoveryCode {
+		uc.recoveryCodeUsed(users, ipAddress, userAgent)
+	}
+
+	return users.Id, authMethod, nil
+}
+
+// CreateOAuthLogin membuat sesi dan refresh token untuk user yang sudah terautentikasi di
+// /oauth/authorize, dengan access token untuk client OAuth (audience) dan scope yang diberikan
+func (uc *userUseCase) CreateOAuthLogin(userId int64, clientId, scope, authMethod, ipAddress, userAgent string) (*user.LoginResp, error) {
+	users, err := uc.RepoUser.GetById(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	// password bisa saja dianggap bocor setelah authorization code diterbitkan
+	if users.PasswordResetRequired {
+		return nil, errors.New(errorMessage.PasswordResetRequired)
+	}
+
+	return uc.createSession(users, ipAddress, userAgent, authMethod, clientId, scope)
+}
+
+// verifyPasswordLogin memeriksa lockout, password dan status akun. Jika user punya faktor kedua,
+// MFA challenge dikembalikan dan login harus dilanjutkan dengan code faktor kedua.
+func (uc *userUseCase) verifyPasswordLogin(data *user.LoginReq, ipAddress string) (*models.User, *user.LoginResp, error) {
 	var err error
-	var resp user.LoginResp
 	var users *models.User
 
-	// Rate limiting
-	loginKey := fmt.Sprintf("%s:%s", common.LoginKey, data.Email)
-	allowed, _ := uc.Redis.IsAllowed(context.Background(), loginKey, 5, common.RateLimit)
-	if !allowed {
-		return nil, fmt.Errorf(errorMessage.ToManyRequest)
+	// hanya percobaan gagal yang dihitung, lihat loginFailed
+	if err = uc.checkLoginLockout(data.Email, ipAddress); err != nil {
+		return nil, nil, err
 	}
 
+	// email tidak terdaftar dan password salah mendapat error, hashing dan counter lockout yang
+	// sama sehingga respons login tidak bisa dipakai untuk enumerasi akun
 	users, err = uc.RepoUser.GetByEmail(data.Email)
 	if err != nil {
-		return nil, err
+		if err.Error() != errorMessage.UserNotFound {
+			return nil, nil, err
+		}
+
+		if err = helper.VerifyDummyPassword(data.Password); errors.Is(err, helper.ErrPasswordHashBusy) {
+			return nil, nil, err
+		}
+
+		if lockErr := uc.loginFailed(nil, data.Email, ipAddress); lockErr != nil {
+			return nil, nil, lockErr
+		}
+		return nil, nil, errors.New(errorMessage.InvalidCredentials)
 	}
 
 	if err = helper.VerifyPassword(users.Password, data.Password); err != nil {
-		return nil, fmt.Errorf(errorMessage.InvalidPassword)
+		// antrean hashing penuh bukan percobaan gagal, jangan dihitung ke lockout
+		if errors.Is(err, helper.ErrPasswordHashBusy) {
+			return nil, nil, err
+		}
+
+		if lockErr := uc.loginFailed(users, data.Email, ipAddress); lockErr != nil {
+			return nil, nil, lockErr
+		}
+		return nil, nil, errors.New(errorMessage.InvalidCredentials)
+	}
+
+	uc.rehashPassword(users, data.Password)
+
+	// sesi dicabut lewat link "this wasn't me", password dianggap bocor
+	if users.PasswordResetRequired {
+		return nil, nil, errors.New(errorMessage.PasswordResetRequired)
+	}
+
+	if uc.VerificationPolicy == common.VerificationPolicyLogin && !users.Verified {
+		return nil, nil, errors.New(errorMessage.EmailNotVerified)
+	}
+
+	// akun dengan faktor kedua harus melanjutkan login lewat LoginMfa, counter lockout baru
+	// direset setelah faktor kedua lolos agar lockout juga berlaku untuk tebakan code
+	mfa, err := uc.secondFactor(users, true)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	resp.AccessToken, err = helper.GenerateToken(users)
+	return users, mfa, nil
+}
+
+// createLogin membuat sesi, access token dan refresh token untuk user yang sudah terautentikasi
+func (uc *userUseCase) createLogin(users *models.User, ipAddress, userAgent, authMethod string) (*user.LoginResp, error) {
+	return uc.createSession(users, ipAddress, userAgent, authMethod, "", "")
+}
+
+// createSession membuat sesi dan refresh token. Access token untuk client OAuth membawa audience
+// clientId dan scope, sedangkan login first-party memakai clientId dan scope kosong.
+func (uc *userUseCase) createSession(users *models.User, ipAddress, userAgent, authMethod, clientId, scope string) (*user.LoginResp, error) {
+	var resp user.LoginResp
+
+	sessionId, err := helper.RandomToken(16)
 	if err != nil {
 		return nil, err
 	}
 
-	resp.RefreshToken, err = helper.GenerateRefreshToken(users)
+	err = uc.RepoSession.Create(&models.UserSession{
+		Id:          sessionId,
+		UserId:      users.Id,
+		DeviceLabel: helper.DeviceLabel(userAgent),
+		IpAddress:   ipAddress,
+		UserAgent:   userAgent,
+		ClientId:    clientId,
+		Scope:       scope,
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	refreshTokenHash, err := helper.HashRefreshToken(resp.RefreshToken)
+	resp.AccessToken, err = helper.GenerateScopedToken(users, sessionId, clientId, scope)
 	if err != nil {
 		return nil, err
 	}
 
-	err = uc.RepoRefreshToken.Create(users.Id, refreshTokenHash, userAgent)
+	resp.RefreshToken, err = helper.GenerateRefreshToken(users, sessionId)
 	if err != nil {
 		return nil, err
 	}
 
-	deviceKey := helper.NormalizeUserAgent(userAgent)
-	refreshTokenKey := fmt.Sprintf("%s:%s:%s", common.RefreshTokenKey, data.Email, deviceKey)
-	_ = uc.Redis.SetData(context.Background(), refreshTokenKey, refreshTokenHash, common.RefreshTokenExp)
+	refreshTokenHash, err := helper.HashRefreshToken(resp.RefreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	// seluruh rotasi refresh token dari login ini berada dalam satu sesi (family)
+	err = uc.RepoRefreshToken.Create(users.Id, sessionId, refreshTokenHash)
+	if err != nil {
+		return nil, err
+	}
 
 	sendMailDto := dtoNats.AuthBrokerDto{
 		UserId:    users.Id,
+		SessionId: sessionId,
 		IpAddress: ipAddress,
 		Device:    userAgent,
 		Event:     common.EventLogin,
+
+		AuthMethod: authMethod,
 	}
 
 	dataPublishMarshal, _ := json.Marshal(sendMailDto)
@@ -174,100 +405,305 @@ func (uc *userUseCase) Me(userId int64) (*user.UserDetails, error) {
 	return result, nil
 }
 
+// RefreshToken merotasi refresh token: token lama ditandai sudah dipakai dan token baru
+// diterbitkan untuk sesi yang sama. Token yang dipakai ulang dianggap dicuri sehingga
+// seluruh token sesi dicabut dan sesi diakhiri.
 func (uc *userUseCase) RefreshToken(refreshToken, userAgent string) (*user.RefreshTokenResp, error) {
+	resp, _, err := uc.rotateRefreshToken(refreshToken, "", userAgent)
+	return resp, err
+}
+
+// RefreshOAuthToken memutar refresh token milik client OAuth. Token hanya bisa dipakai oleh client
+// yang menerimanya, dan access token baru diterbitkan dengan scope yang diberikan saat authorize.
+// Mengembalikan scope tersebut untuk response /oauth/token.
+func (uc *userUseCase) RefreshOAuthToken(refreshToken, clientId, userAgent string) (*user.RefreshTokenResp, string, error) {
+	if clientId == "" {
+		return nil, "", errors.New(errorMessage.InvalidToken)
+	}
+
+	return uc.rotateRefreshToken(refreshToken, clientId, userAgent)
+}
+
+// rotateRefreshToken menukar refresh token dengan pasangan token baru. clientId harus sama dengan
+// client pemilik sesi; login first-party memakai clientId kosong sehingga refresh token client
+// OAuth tidak bisa ditukar dengan token first-party dan sebaliknya.
+func (uc *userUseCase) rotateRefreshToken(refreshToken, clientId, userAgent string) (*user.RefreshTokenResp, string, error) {
 	var resp user.RefreshTokenResp
 
 	claims, err := helper.VerifyRefreshToken(refreshToken)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
 	hashed, err := helper.HashRefreshToken(refreshToken)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
-	deviceKey := helper.NormalizeUserAgent(userAgent)
-	refreshTokenKey := fmt.Sprintf("%s:%s:%s", common.RefreshTokenKey, claims.Email, deviceKey)
-	cacheRefreshToken, err := uc.Redis.GetData(context.Background(), refreshTokenKey)
-	if err == nil && cacheRefreshToken != "" {
-		if hashed != cacheRefreshToken {
-			return nil, errors.New(errorMessage.InvalidToken)
-		}
-	} else {
-		refreshTokenDb, err := uc.RepoRefreshToken.GetTokenActive(claims.UserID, userAgent)
-		if err != nil {
-			return nil, err
-		}
+	refreshTokenDb, err := uc.RepoRefreshToken.GetByHash(hashed)
+	if err != nil {
+		return nil, "", errors.New(errorMessage.InvalidToken)
+	}
 
-		if hashed != refreshTokenDb.RefreshTokenHash {
-			return nil, errors.New(errorMessage.InvalidToken)
-		}
+	if refreshTokenDb.UserId != claims.UserID || refreshTokenDb.SessionId != claims.SessionID {
+		return nil, "", errors.New(errorMessage.InvalidToken)
+	}
 
-		if refreshTokenDb.ExpiresAt.Valid && refreshTokenDb.ExpiresAt.Time.Before(time.Now()) {
-			return nil, errors.New(errorMessage.ExpiredToken)
-		}
+	if refreshTokenDb.UsedAt.Valid {
+		return nil, "", uc.refreshTokenReuse(refreshTokenDb, userAgent)
 	}
 
-	accessToken, err := helper.GenerateToken(&models.User{
-		Id:    claims.UserID,
-		Email: claims.Email,
-	})
+	if !refreshTokenDb.IsActive {
+		return nil, "", errors.New(errorMessage.InvalidToken)
+	}
+
+	if refreshTokenDb.ExpiresAt.Valid && refreshTokenDb.ExpiresAt.Time.Before(time.Now()) {
+		return nil, "", errors.New(errorMessage.ExpiredToken)
+	}
+
+	// hanya satu request yang berhasil menandai token; request lain dengan token yang sama dianggap reuse
+	marked, err := uc.RepoRefreshToken.MarkUsed(refreshTokenDb.Id)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if !marked {
+		return nil, "", uc.refreshTokenReuse(refreshTokenDb, userAgent)
+	}
+
+	session, err := uc.RepoSession.GetActiveById(refreshTokenDb.SessionId)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if session.ClientId != clientId {
+		return nil, "", errors.New(errorMessage.InvalidToken)
+	}
+
+	err = uc.RepoSession.UpdateLastSeen(refreshTokenDb.SessionId)
+	if err != nil {
+		log.Println(err)
+	}
+
+	// user dibaca ulang agar status verifikasi pada access token baru selalu terkini
+	users, err := uc.RepoUser.GetById(claims.UserID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	resp.AccessToken, err = helper.GenerateScopedToken(users, claims.SessionID, session.ClientId, session.Scope)
+	if err != nil {
+		return nil, "", err
+	}
+
+	resp.RefreshToken, err = helper.GenerateRefreshToken(users, claims.SessionID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	newRefreshTokenHash, err := helper.HashRefreshToken(resp.RefreshToken)
+	if err != nil {
+		return nil, "", err
+	}
+
+	err = uc.RepoRefreshToken.Create(claims.UserID, refreshTokenDb.SessionId, newRefreshTokenHash)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &resp, session.Scope, nil
+}
+
+// refreshTokenReuse mengakhiri sesi pemilik refresh token dan mengirim event keamanan ke pemilik akun
+func (uc *userUseCase) refreshTokenReuse(refreshTokenDb *models.UserRefreshToken, userAgent string) error {
+	err := uc.endSession(refreshTokenDb.SessionId, common.Refresh_Token_Reuse)
+	if err != nil {
+		return err
+	}
+
+	securityEventDto := dtoNats.AuthBrokerDto{
+		UserId:    refreshTokenDb.UserId,
+		SessionId: refreshTokenDb.SessionId,
+		Device:    userAgent,
+		Event:     common.EventRefreshTokenReuse,
+	}
+
+	dataPublishMarshal, _ := json.Marshal(securityEventDto)
+	err = uc.NatsPublisher.Nats(dataPublishMarshal, common.NatsAuthSubject)
+	if err != nil {
+		log.Println(err)
+	}
+
+	return errors.New(errorMessage.RefreshTokenReused)
+}
+
+func (uc *userUseCase) Logout(claims *helper.TokenClaims) error {
+	err := uc.endSession(claims.SessionID, common.User_Logout)
+	if err != nil {
+		return err
+	}
+
+	err = helper.RevokeAccessToken(claims)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ListSessions menampilkan sesi aktif milik user, sesi dari token yang dipakai ditandai current
+func (uc *userUseCase) ListSessions(claims *helper.TokenClaims) ([]*user.SessionResp, error) {
+	sessions, err := uc.RepoSession.GetActiveByUserId(claims.UserID)
 	if err != nil {
 		return nil, err
 	}
 
-	resp.AccessToken = accessToken
+	result := make([]*user.SessionResp, 0, len(sessions))
+	for _, session := range sessions {
+		result = append(result, &user.SessionResp{
+			Id:           session.Id,
+			Device:       session.DeviceLabel,
+			IpAddress:    session.IpAddress,
+			UserAgent:    session.UserAgent,
+			LoginTime:    helper.DateToStringByFormat(session.CreatedAt, "02-01-2006 15:04:05"),
+			LastActivity: helper.DateToStringByFormat(session.LastSeenAt, "02-01-2006 15:04:05"),
+			ExpiresAt:    helper.DateToStringByFormat(session.ExpiresAt, "02-01-2006 15:04:05"),
+			Current:      session.Id == claims.SessionID,
+		})
+	}
 
-	return &resp, nil
+	return result, nil
+}
+
+// RevokeSession mencabut satu sesi milik user
+func (uc *userUseCase) RevokeSession(claims *helper.TokenClaims, sessionId string) error {
+	session, err := uc.RepoSession.GetActiveById(sessionId)
+	if err != nil {
+		return err
+	}
+
+	// sesi milik user lain diperlakukan seperti tidak ada
+	if session.UserId != claims.UserID {
+		return errors.New(errorMessage.SessionNotFound)
+	}
+
+	return uc.endSession(session.Id, common.Session_Revoked)
 }
 
-func (uc *userUseCase) Logout(userId int64, email, userAgent string) error {
-	err := uc.RepoHistory.UpdateLogoutByUserIdAndUserAgent(userId, common.User_Logout, userAgent)
+// RevokeOtherSessions mencabut seluruh sesi user kecuali sesi yang sedang dipakai
+func (uc *userUseCase) RevokeOtherSessions(claims *helper.TokenClaims) error {
+	sessions, err := uc.RepoSession.GetActiveByUserId(claims.UserID)
 	if err != nil {
 		return err
 	}
 
-	err = uc.RepoRefreshToken.UpdateStatus(userId, userAgent)
+	for _, session := range sessions {
+		if session.Id == claims.SessionID {
+			continue
+		}
+
+		err = uc.endSession(session.Id, common.Session_Revoked)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// endAllSessions mencabut seluruh sesi user beserta refresh token dan access token-nya
+func (uc *userUseCase) endAllSessions(userId int64, reason string) error {
+	err := uc.RepoSession.RevokeByUserId(userId, reason)
+	if err != nil {
+		return err
+	}
+
+	err = uc.RepoRefreshToken.UpdateStatusByUserId(userId)
 	if err != nil {
 		return err
 	}
 
-	deviceKey := helper.NormalizeUserAgent(userAgent)
-	refreshTokenKey := fmt.Sprintf("%s:%s:%s", common.RefreshTokenKey, email, deviceKey)
-	_ = uc.Redis.DeleteData(context.Background(), refreshTokenKey)
+	err = helper.RevokeUserTokens(userId)
+	if err != nil {
+		return err
+	}
+
+	err = uc.RepoHistory.UpdateLogoutByUserId(userId, reason)
+	if err != nil {
+		return err
+	}
 
 	return nil
 }
 
-func (uc *userUseCase) RevokeToken(emailEncrypt string) error {
-	email, err := helper.Decrypt(emailEncrypt)
+// endSession mencabut sesi beserta seluruh refresh token dan access token miliknya,
+// lalu menutup login history sesi tersebut
+func (uc *userUseCase) endSession(sessionId, reason string) error {
+	err := uc.RepoSession.Revoke(sessionId, reason)
 	if err != nil {
 		return err
 	}
 
-	revokeToken := fmt.Sprintf("%s:%s", common.RevokeTokenKey, email)
-	cache, _ := uc.Redis.GetData(context.Background(), revokeToken)
-	if cache == "" {
-		return errors.New(errorMessage.ExpiredToken)
+	err = uc.RepoRefreshToken.UpdateStatusBySessionId(sessionId)
+	if err != nil {
+		return err
 	}
 
-	users, err := uc.RepoUser.GetByEmail(email)
+	err = helper.RevokeSession(sessionId)
 	if err != nil {
 		return err
 	}
 
-	err = uc.RepoHistory.UpdateLogoutByUserId(users.Id, common.Token_Revoked)
+	err = uc.RepoHistory.UpdateLogoutBySessionId(sessionId, reason)
 	if err != nil {
 		return err
 	}
 
-	accessTokenKey := fmt.Sprintf("%s:%s", common.AccessTokenKey, email)
-	refreshTokenKey := fmt.Sprintf("%s:%s", common.RefreshTokenKey, email)
+	return nil
+}
 
-	_ = uc.Redis.DeleteData(context.Background(), accessTokenKey)
-	_ = uc.Redis.DeleteData(context.Background(), refreshTokenKey)
-	_ = uc.Redis.DeleteData(context.Background(), revokeToken)
+// RevokeToken memproses link "this wasn't me" dari email login. Link hanya bisa dipakai
+// sekali; sesi terkait (atau seluruh sesi jika all) diakhiri dan user wajib reset password.
+func (uc *userUseCase) RevokeToken(token string, all bool) error {
+	payload, err := helper.Decrypt(common.PurposeRevokeLink, token)
+	if err != nil {
+		return err
+	}
+
+	var link user.RevokeLink
+	if err = json.Unmarshal([]byte(payload), &link); err != nil {
+		return errors.New(errorMessage.InvalidEncryptedData)
+	}
+
+	revokeTokenKey := fmt.Sprintf("%s:%s", common.RevokeTokenKey, link.Nonce)
+	sessionId, _ := uc.Redis.GetDeleteData(context.Background(), revokeTokenKey)
+	if sessionId == "" || sessionId != link.SessionId {
+		return errors.New(errorMessage.RevokeLinkUsed)
+	}
+
+	if all {
+		err = uc.endAllSessions(link.UserId, common.Token_Revoked)
+		if err != nil {
+			return err
+		}
+	} else {
+		// sesi yang sudah berakhir tetap diproses agar user tetap wajib reset password
+		session, err := uc.RepoSession.GetActiveById(link.SessionId)
+		if err == nil && session.UserId == link.UserId {
+			err = uc.endSession(session.Id, common.Token_Revoked)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	err = uc.RepoUser.SetPasswordResetRequired(link.UserId)
+	if err != nil {
+		return err
+	}
+
+	userKey := fmt.Sprintf("%s:%d", common.UserIdKey, link.UserId)
+	_ = uc.Redis.DeleteData(context.Background(), userKey)
 
 	return nil
 }
@@ -366,20 +802,28 @@ func (uc *userUseCase) UpdatePassword(userId int64, oldPassword, newPassword str
 	}
 
 	if err = helper.VerifyPassword(users.Password, oldPassword); err != nil {
+		if errors.Is(err, helper.ErrPasswordHashBusy) {
+			return err
+		}
 		return fmt.Errorf(errorMessage.InvalidPassword)
 	}
 
-	password, err := helper.HashPassword(newPassword)
+	passwordHash, err := uc.newPasswordHash(users, newPassword)
 	if err != nil {
 		return err
 	}
 
-	err = uc.RepoUser.UpdatePasswordByUserId(users.Id, password)
+	err = uc.RepoUser.UpdatePasswordByUserId(users.Id, passwordHash)
 	if err != nil {
 		return err
 	}
 
-	err = uc.RepoRefreshToken.UpdateStatusByUserId(users.Id)
+	err = uc.savePasswordHistory(users.Id, passwordHash)
+	if err != nil {
+		log.Println(err)
+	}
+
+	err = uc.endAllSessions(users.Id, common.Password_Changed)
 	if err != nil {
 		return err
 	}
@@ -401,3 +845,187 @@ func (uc *userUseCase) UpdatePassword(userId int64, oldPassword, newPassword str
 
 	return nil
 }
+
+// ForgotPassword mengirim email reset password lewat NATS. Email yang tidak terdaftar
+// dan request yang terkena rate limit tidak menghasilkan error agar respons selalu sama.
+func (uc *userUseCase) ForgotPassword(email string) error {
+	forgotPasswordKey := fmt.Sprintf("%s:%s", common.ForgotPasswordKey, email)
+	allowed, _ := uc.Redis.IsAllowed(context.Background(), forgotPasswordKey, 3, common.RateLimit)
+	if !allowed {
+		return nil
+	}
+
+	users, err := uc.RepoUser.GetByEmail(email)
+	if err != nil {
+		// error database hanya dicatat agar respons tidak membedakan email yang terdaftar
+		if err.Error() != errorMessage.UserNotFound {
+			log.Println(err)
+		}
+		return nil
+	}
+
+	sendMailDto := dtoNats.AuthBrokerDto{
+		UserId: users.Id,
+		Event:  common.EventForgotPassword,
+	}
+
+	// gagal publish hanya dicatat, respons harus sama dengan email yang tidak terdaftar
+	dataPublishMarshal, _ := json.Marshal(sendMailDto)
+	err = uc.NatsPublisher.Nats(dataPublishMarshal, common.NatsAuthSubject)
+	if err != nil {
+		log.Println(err)
+	}
+
+	return nil
+}
+
+// ResetPassword memakai token reset password (sekali pakai), mengganti password,
+// mencabut seluruh sesi dan mengirim notifikasi update password
+func (uc *userUseCase) ResetPassword(token, newPassword string) error {
+	ctx := context.Background()
+	resetPasswordKey := fmt.Sprintf("%s:%s", common.ResetPasswordKey, helper.HashToken(token))
+
+	userIdStr, _ := uc.Redis.GetData(ctx, resetPasswordKey)
+	if userIdStr == "" {
+		return errors.New(errorMessage.InvalidResetToken)
+	}
+
+	userId, err := strconv.ParseInt(userIdStr, 10, 64)
+	if err != nil {
+		return errors.New(errorMessage.InvalidResetToken)
+	}
+
+	users, err := uc.RepoUser.GetById(userId)
+	if err != nil {
+		return err
+	}
+
+	// password yang ditolak policy tidak menghanguskan token, user cukup mencoba password lain
+	passwordHash, err := uc.newPasswordHash(users, newPassword)
+	if err != nil {
+		return err
+	}
+
+	// request paralel dengan token yang sama hanya satu yang berhasil
+	userIdStr, _ = uc.Redis.GetDeleteData(ctx, resetPasswordKey)
+	if userIdStr != strconv.FormatInt(userId, 10) {
+		return errors.New(errorMessage.InvalidResetToken)
+	}
+
+	_ = uc.Redis.DeleteData(ctx, fmt.Sprintf("%s:%d", common.ResetPasswordUserKey, userId))
+
+	// reset setelah link "this wasn't me": passkey yang ada tidak bisa dibedakan dari passkey
+	// milik penyerang, pemilik akun harus mendaftarkan ulang passkey-nya
+	if users.PasswordResetRequired {
+		err = uc.RepoWebauthn.DeleteByUserId(users.Id)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = uc.RepoUser.UpdatePasswordByUserId(users.Id, passwordHash)
+	if err != nil {
+		return err
+	}
+
+	err = uc.savePasswordHistory(users.Id, passwordHash)
+	if err != nil {
+		log.Println(err)
+	}
+
+	err = uc.endAllSessions(users.Id, common.Password_Reset)
+	if err != nil {
+		return err
+	}
+
+	userKey := fmt.Sprintf("%s:%d", common.UserIdKey, users.Id)
+	_ = uc.Redis.DeleteData(ctx, userKey)
+
+	sendMailDto := dtoNats.AuthBrokerDto{
+		UserId: users.Id,
+		Event:  common.EventUpdatePassword,
+	}
+
+	dataPublishMarshal, _ := json.Marshal(sendMailDto)
+	err = uc.NatsPublisher.Nats(dataPublishMarshal, common.NatsAuthSubject)
+	if err != nil {
+		log.Println(err)
+	}
+
+	return nil
+}
+
+// VerifyEmail memproses link verifikasi dari email. Link yang dipakai ulang untuk akun
+// yang sudah terverifikasi tetap dianggap berhasil.
+func (uc *userUseCase) VerifyEmail(token string) error {
+	payload, err := helper.Decrypt(common.PurposeVerifyEmail, token)
+	if err != nil {
+		return err
+	}
+
+	var link user.VerifyEmailLink
+	if err = json.Unmarshal([]byte(payload), &link); err != nil {
+		return errors.New(errorMessage.InvalidEncryptedData)
+	}
+
+	users, err := uc.RepoUser.GetById(link.UserId)
+	if err != nil {
+		return errors.New(errorMessage.InvalidEncryptedData)
+	}
+
+	// email sudah diganti setelah link dibuat
+	if users.Email != link.Email {
+		return errors.New(errorMessage.InvalidEncryptedData)
+	}
+
+	if users.Verified {
+		return nil
+	}
+
+	err = uc.RepoUser.UpdateVerifiedByUserId(users.Id)
+	if err != nil {
+		return err
+	}
+
+	userKey := fmt.Sprintf("%s:%d", common.UserIdKey, users.Id)
+	_ = uc.Redis.DeleteData(context.Background(), userKey)
+
+	return nil
+}
+
+// ResendVerification mengirim ulang email verifikasi lewat NATS. Seperti ForgotPassword,
+// email yang tidak terdaftar, sudah terverifikasi atau terkena throttle tidak menghasilkan error.
+func (uc *userUseCase) ResendVerification(email string) error {
+	verifyEmailKey := fmt.Sprintf("%s:%s", common.VerifyEmailKey, email)
+	allowed, _ := uc.Redis.IsAllowed(context.Background(), verifyEmailKey, 1, common.VerifyEmailResendLimit)
+	if !allowed {
+		return nil
+	}
+
+	users, err := uc.RepoUser.GetByEmail(email)
+	if err != nil {
+		// error database hanya dicatat agar respons tidak membedakan email yang terdaftar
+		if err.Error() != errorMessage.UserNotFound {
+			log.Println(err)
+		}
+		return nil
+	}
+
+	if users.Verified {
+		return nil
+	}
+
+	sendMailDto := dtoNats.AuthBrokerDto{
+		UserId: users.Id,
+		Event:  common.EventVerifyEmail,
+	}
+
+	// gagal publish hanya dicatat, respons harus sama dengan email yang tidak terdaftar
+	dataPublishMarshal, _ := json.Marshal(sendMailDto)
+	err = uc.NatsPublisher.Nats(dataPublishMarshal, common.NatsAuthSubject)
+	if err != nil {
+		log.Println(err)
+	}
+
+	return nil
+}
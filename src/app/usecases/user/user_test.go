@@ -0,0 +1,336 @@
+package user
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	dtoNats "go-auth-service/src/app/dto/broker"
+	"go-auth-service/src/app/dto/user"
+	"go-auth-service/src/infra/config"
+	"go-auth-service/src/infra/constants/common"
+	errorMessage "go-auth-service/src/infra/constants/error_message"
+	"go-auth-service/src/infra/helper"
+	"go-auth-service/src/infra/models"
+)
+
+// loadTestSecrets memakai secret sementara untuk enkripsi dan refresh token
+func loadTestSecrets(t *testing.T) {
+	t.Helper()
+
+	if err := helper.LoadSecrets(config.SecretsConf{AllowEphemeral: true}); err != nil {
+		t.Fatalf("LoadSecrets: %v", err)
+	}
+}
+
+func TestRefreshTokenReuse(t *testing.T) {
+	loadTestSecrets(t)
+
+	const sessionId = "session-1"
+	owner := &models.User{Id: 7, Email: "user@mail.com"}
+
+	refreshToken, err := helper.GenerateRefreshToken(owner, sessionId)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hashed, err := helper.HashRefreshToken(refreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stored := func(modify func(token *models.UserRefreshToken)) *models.UserRefreshToken {
+		token := &models.UserRefreshToken{
+			Id:               1,
+			UserId:           owner.Id,
+			SessionId:        sessionId,
+			RefreshTokenHash: hashed,
+			ExpiresAt:        sql.NullTime{Time: time.Now().Add(time.Hour), Valid: true},
+			IsActive:         true,
+		}
+		if modify != nil {
+			modify(token)
+		}
+		return token
+	}
+
+	tests := []struct {
+		name             string
+		token            *models.UserRefreshToken
+		marked           bool
+		wantErr          string
+		wantSessionEnded bool
+	}{
+		{
+			name: "rotated token used again",
+			token: stored(func(token *models.UserRefreshToken) {
+				token.IsActive = false
+				token.UsedAt = sql.NullTime{Time: time.Now().Add(-time.Minute), Valid: true}
+			}),
+			wantErr:          errorMessage.RefreshTokenReused,
+			wantSessionEnded: true,
+		},
+		{
+			name:             "parallel refresh with the same token",
+			token:            stored(nil),
+			marked:           false,
+			wantErr:          errorMessage.RefreshTokenReused,
+			wantSessionEnded: true,
+		},
+		{
+			name:    "unknown token",
+			token:   nil,
+			wantErr: errorMessage.InvalidToken,
+		},
+		{
+			name: "token from another session",
+			token: stored(func(token *models.UserRefreshToken) {
+				token.SessionId = "session-2"
+			}),
+			wantErr: errorMessage.InvalidToken,
+		},
+		{
+			name: "token of an ended session",
+			token: stored(func(token *models.UserRefreshToken) {
+				token.IsActive = false
+			}),
+			wantErr: errorMessage.InvalidToken,
+		},
+		{
+			name: "expired token",
+			token: stored(func(token *models.UserRefreshToken) {
+				token.ExpiresAt = sql.NullTime{Time: time.Now().Add(-time.Minute), Valid: true}
+			}),
+			wantErr: errorMessage.ExpiredToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeRedis()
+			helper.InitTokenDenylist(fake)
+
+			refreshTokenRepo := &fakeRefreshTokenRepo{token: tt.token, marked: tt.marked}
+			sessionRepo := &fakeSessionRepo{}
+			historyRepo := &fakeHistoryRepo{}
+			publisher := &fakePublisher{}
+
+			uc := &userUseCase{
+				NatsPublisher:    publisher,
+				Redis:            fake,
+				RepoRefreshToken: refreshTokenRepo,
+				RepoSession:      sessionRepo,
+				RepoHistory:      historyRepo,
+			}
+
+			_, err := uc.RefreshToken(refreshToken, "Mozilla/5.0")
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("err = %v, want %q", err, tt.wantErr)
+			}
+
+			ended := sessionRepo.revoked[sessionId] == common.Refresh_Token_Reuse
+			if ended != tt.wantSessionEnded {
+				t.Fatalf("session ended = %v, want %v", ended, tt.wantSessionEnded)
+			}
+
+			if !tt.wantSessionEnded {
+				if len(sessionRepo.revoked) != 0 || len(refreshTokenRepo.deactivated) != 0 || len(publisher.messages) != 0 {
+					t.Fatal("session was touched although the token was not reused")
+				}
+				return
+			}
+
+			if len(refreshTokenRepo.deactivated) != 1 || refreshTokenRepo.deactivated[0] != sessionId {
+				t.Fatalf("refresh tokens deactivated for %v, want [%s]", refreshTokenRepo.deactivated, sessionId)
+			}
+
+			if historyRepo.loggedOut[sessionId] != common.Refresh_Token_Reuse {
+				t.Fatal("login history was not closed")
+			}
+
+			if !fake.has(fmt.Sprintf("%s:sid:%s", common.AccessDenylistKey, sessionId)) {
+				t.Fatal("access tokens of the session were not denylisted")
+			}
+
+			if len(publisher.messages) != 1 {
+				t.Fatalf("published %d messages, want 1", len(publisher.messages))
+			}
+
+			var event dtoNats.AuthBrokerDto
+			if err = json.Unmarshal(publisher.messages[0], &event); err != nil {
+				t.Fatal(err)
+			}
+			if event.Event != common.EventRefreshTokenReuse || event.UserId != owner.Id || event.SessionId != sessionId {
+				t.Fatalf("unexpected security event %+v", event)
+			}
+		})
+	}
+}
+
+func TestRefreshTokenClientBinding(t *testing.T) {
+	loadTestSecrets(t)
+	if err := helper.LoadSigningKeys(config.JwtConf{}); err != nil {
+		t.Fatal(err)
+	}
+
+	const sessionId = "session-1"
+	owner := &models.User{Id: 7, Email: "user@mail.com"}
+
+	tests := []struct {
+		name          string
+		sessionClient string
+		sessionScope  string
+		clientId      string
+		oauth         bool
+		wantErr       string
+	}{
+		{name: "first-party session", clientId: ""},
+		{name: "oauth session refreshed by its client", sessionClient: "web-portal", sessionScope: "openid email", clientId: "web-portal", oauth: true},
+		{name: "oauth session refreshed by another client", sessionClient: "web-portal", sessionScope: "openid email", clientId: "other-app", oauth: true, wantErr: errorMessage.InvalidToken},
+		{name: "oauth session refreshed first-party", sessionClient: "web-portal", sessionScope: "openid email", wantErr: errorMessage.InvalidToken},
+		{name: "first-party session refreshed by a client", clientId: "web-portal", oauth: true, wantErr: errorMessage.InvalidToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			refreshToken, err := helper.GenerateRefreshToken(owner, sessionId)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			hashed, err := helper.HashRefreshToken(refreshToken)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			uc := &userUseCase{
+				RepoUser: &fakeUserRepo{user: owner},
+				RepoRefreshToken: &fakeRefreshTokenRepo{marked: true, token: &models.UserRefreshToken{
+					Id:               1,
+					UserId:           owner.Id,
+					SessionId:        sessionId,
+					RefreshTokenHash: hashed,
+					ExpiresAt:        sql.NullTime{Time: time.Now().Add(time.Hour), Valid: true},
+					IsActive:         true,
+				}},
+				RepoSession: &fakeSessionRepo{session: &models.UserSession{
+					Id:       sessionId,
+					UserId:   owner.Id,
+					ClientId: tt.sessionClient,
+					Scope:    tt.sessionScope,
+				}},
+			}
+
+			var resp *user.RefreshTokenResp
+			var scope string
+			if tt.oauth {
+				resp, scope, err = uc.RefreshOAuthToken(refreshToken, tt.clientId, "Mozilla/5.0")
+			} else {
+				resp, err = uc.RefreshToken(refreshToken, "Mozilla/5.0")
+			}
+
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if scope != tt.sessionScope {
+				t.Fatalf("scope = %q, want %q", scope, tt.sessionScope)
+			}
+
+			// token first-party ditolak oleh VerifyOAuthToken dan sebaliknya
+			verify := helper.VerifyToken
+			if tt.oauth {
+				verify = helper.VerifyOAuthToken
+			}
+
+			claims, err := verify(resp.AccessToken)
+			if err != nil {
+				t.Fatalf("access token rejected: %v", err)
+			}
+			if claims.ClientID != tt.sessionClient || claims.Scope != tt.sessionScope {
+				t.Fatalf("claims client_id=%q scope=%q, want %q %q", claims.ClientID, claims.Scope, tt.sessionClient, tt.sessionScope)
+			}
+		})
+	}
+}
+
+func TestForgotPassword(t *testing.T) {
+	owner := &models.User{Id: 7, Email: "user@mail.com"}
+
+	tests := []struct {
+		name         string
+		email        string
+		getErr       error
+		wantMessages int
+	}{
+		{name: "registered email", email: owner.Email, wantMessages: 1},
+		{name: "unknown email", email: "other@mail.com"},
+		{name: "database unavailable", email: owner.Email, getErr: errors.New("pq: connection refused")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			publisher := &fakePublisher{}
+			uc := &userUseCase{
+				NatsPublisher: publisher,
+				Redis:         newFakeRedis(),
+				RepoUser:      &fakeUserRepo{user: owner, getErr: tt.getErr},
+			}
+
+			// respons selalu sama agar email yang terdaftar tidak bisa ditebak
+			if err := uc.ForgotPassword(tt.email); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(publisher.messages) != tt.wantMessages {
+				t.Fatalf("published %d messages, want %d", len(publisher.messages), tt.wantMessages)
+			}
+		})
+	}
+}
+
+func TestResendVerification(t *testing.T) {
+	tests := []struct {
+		name         string
+		email        string
+		verified     bool
+		getErr       error
+		wantMessages int
+	}{
+		{name: "unverified email", email: "user@mail.com", wantMessages: 1},
+		{name: "already verified", email: "user@mail.com", verified: true},
+		{name: "unknown email", email: "other@mail.com"},
+		{name: "database unavailable", email: "user@mail.com", getErr: errors.New("pq: connection refused")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			publisher := &fakePublisher{}
+			uc := &userUseCase{
+				NatsPublisher: publisher,
+				Redis:         newFakeRedis(),
+				RepoUser: &fakeUserRepo{
+					user:   &models.User{Id: 7, Email: "user@mail.com", Verified: tt.verified},
+					getErr: tt.getErr,
+				},
+			}
+
+			if err := uc.ResendVerification(tt.email); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(publisher.messages) != tt.wantMessages {
+				t.Fatalf("published %d messages, want %d", len(publisher.messages), tt.wantMessages)
+			}
+		})
+	}
+}
@@ -2,7 +2,10 @@ package config
 
 import (
 	"os"
+	"runtime"
 	"strconv"
+	"strings"
+	"time"
 )
 
 type AppConf struct {
@@ -44,12 +47,161 @@ type RedisConf struct {
 	Port string
 }
 
+type JwtKeyConf struct {
+	Kid    string
+	Status string
+	Path   string
+}
+
+type JwtConf struct {
+	Issuer string
+	Keys   []JwtKeyConf
+}
+
+// SecretConf is one version of a symmetric secret. Source is either
+// "file:<path>" for a mounted secret or "env:<NAME>" for an environment variable.
+type SecretConf struct {
+	Kid    string
+	Status string
+	Source string
+}
+
+// SecretsConf holds the symmetric secrets. AllowEphemeral lets a process generate random
+// secrets when a list is empty; those secrets differ between the api and the worker and are
+// lost on restart, so it is meant for a single local process only.
+type SecretsConf struct {
+	EncryptKeys      []SecretConf
+	RefreshTokenKeys []SecretConf
+	AllowEphemeral   bool
+}
+
+// PageConf configures the result pages shown for browser-facing links.
+// RedirectAllowlist holds the hosts a "redirect" query parameter may point to.
+type PageConf struct {
+	TemplateDir       string
+	AppName           string
+	PrimaryColor      string
+	LogoUrl           string
+	SupportEmail      string
+	RedirectUrl       string
+	RedirectAllowlist []string
+}
+
+// EmailVerificationConf decides what an unverified account may do.
+// Policy is "off", "login" (login is refused) or "endpoints" (only Endpoints are refused).
+type EmailVerificationConf struct {
+	Policy    string
+	Endpoints []string
+}
+
+// WebAuthnConf is the relying party used for passkeys. Passkeys are disabled
+// when RPID is empty; Origins lists every origin allowed to run the ceremonies.
+type WebAuthnConf struct {
+	RPID    string
+	RPName  string
+	Origins []string
+}
+
+// MagicLinkConf enables passwordless login by email link for this deployment.
+type MagicLinkConf struct {
+	Enabled bool
+}
+
+// SmsConf selects the SMSSender. Provider "log" writes messages to LogPath (or the
+// application log when empty) and is meant for development only. Login enables
+// passwordless SMS login, Mfa makes a verified phone a second factor.
+type SmsConf struct {
+	Provider string
+	LogPath  string
+	Login    bool
+	Mfa      bool
+}
+
+// LockoutConf controls lockout after failed password logins. After DelayAfter failures
+// each attempt must wait an exponentially growing delay; at Threshold failures the account
+// is locked for Duration, doubling on every repeated lockout up to MaxDuration. An IP is
+// locked for Duration after IpThreshold failures. Threshold 0 disables account lockout.
+type LockoutConf struct {
+	Threshold   int
+	DelayAfter  int
+	Duration    time.Duration
+	MaxDuration time.Duration
+	IpThreshold int
+}
+
+// RateLimitPolicy limits one named route to Limit requests per sliding Window. Key is what
+// requests are counted by: "ip", "account" (user of the access token) or "client" (OAuth client_id).
+type RateLimitPolicy struct {
+	Limit  int
+	Window time.Duration
+	Key    string
+}
+
+// RateLimitConf holds the policies per route name. FailOpen lets requests through while
+// Redis is unavailable; otherwise they are rejected with 503.
+type RateLimitConf struct {
+	FailOpen bool
+	Policies map[string]RateLimitPolicy
+}
+
+// defaultRateLimitPolicies is used when RATE_LIMIT_POLICIES is unset.
+const defaultRateLimitPolicies = "register:ip:5:1h,login:ip:20:1m,refresh-token:ip:30:1m,update-password:account:5:15m,oauth-token:client:60:1m"
+
+// PasswordPolicyConf is the policy for every newly set password. CharacterClasses lists
+// the required classes (upper, lower, digit, symbol). MinScore is the minimum strength
+// score from 0 to 4; History is how many previous passwords may not be reused.
+// MaxBytes is the input limit of the password hasher, 0 when it has none.
+type PasswordPolicyConf struct {
+	MinLength        int
+	MaxLength        int
+	MaxBytes         int
+	CharacterClasses []string
+	MinScore         int
+	History          int
+}
+
+// PasswordHashConf selects the hasher for new passwords: "argon2id" (default) or "bcrypt".
+// Argon2Memory is in KiB. Stored hashes made with another algorithm or other parameters
+// keep working and are upgraded on the next successful login. Hashing runs on Workers
+// goroutines; at most QueueSize requests wait for a worker, the rest are rejected.
+type PasswordHashConf struct {
+	Algorithm         string
+	Argon2Memory      int
+	Argon2Time        int
+	Argon2Parallelism int
+	BcryptCost        int
+	Workers           int
+	QueueSize         int
+}
+
+// BreachedPasswordConf points to the offline breached password corpus. Source is either a
+// filter file built with "build-breach-filter" or a directory of HIBP range files
+// (ABCDE.txt with SUFFIX:COUNT lines). MinCount only applies to the directory; the filter
+// has its threshold baked in at build time. An empty Source disables the check.
+type BreachedPasswordConf struct {
+	Source   string
+	MinCount int
+}
+
 type Config struct {
-	App   AppConf
-	Http  HttpConf
-	Log   LogConf
-	SqlDb SqlDbConf
-	Redis RedisConf
+	App     AppConf
+	Http    HttpConf
+	Log     LogConf
+	SqlDb   SqlDbConf
+	Redis   RedisConf
+	Jwt     JwtConf
+	Secrets SecretsConf
+	Page    PageConf
+
+	EmailVerification EmailVerificationConf
+	WebAuthn          WebAuthnConf
+	MagicLink         MagicLinkConf
+	Sms               SmsConf
+	Lockout           LockoutConf
+	RateLimit         RateLimitConf
+	PasswordPolicy    PasswordPolicyConf
+	PasswordHash      PasswordHashConf
+	BreachedPassword  BreachedPasswordConf
 }
 
 func Make() Config {
@@ -93,6 +245,115 @@ func Make() Config {
 		http.Timeout = httpTimeout
 	}
 
+	jwt := JwtConf{
+		Issuer: os.Getenv("JWT_ISSUER"),
+		Keys:   parseJwtKeys(os.Getenv("JWT_SIGNING_KEYS")),
+	}
+
+	secrets := SecretsConf{
+		EncryptKeys:      parseSecrets(os.Getenv("ENCRYPT_KEYS")),
+		RefreshTokenKeys: parseSecrets(os.Getenv("JWT_REFRESH_KEYS")),
+		AllowEphemeral:   os.Getenv("ALLOW_EPHEMERAL_SECRETS") == "true",
+	}
+
+	page := PageConf{
+		TemplateDir:       os.Getenv("PAGE_TEMPLATE_DIR"),
+		AppName:           os.Getenv("PAGE_APP_NAME"),
+		PrimaryColor:      os.Getenv("PAGE_PRIMARY_COLOR"),
+		LogoUrl:           os.Getenv("PAGE_LOGO_URL"),
+		SupportEmail:      os.Getenv("PAGE_SUPPORT_EMAIL"),
+		RedirectUrl:       os.Getenv("PAGE_REDIRECT_URL"),
+		RedirectAllowlist: splitList(os.Getenv("PAGE_REDIRECT_ALLOWLIST")),
+	}
+	if page.AppName == "" {
+		page.AppName = app.Name
+	}
+
+	emailVerification := EmailVerificationConf{
+		Policy:    strings.ToLower(os.Getenv("EMAIL_VERIFICATION_POLICY")),
+		Endpoints: splitList(os.Getenv("EMAIL_VERIFICATION_ENDPOINTS")),
+	}
+	if emailVerification.Policy == "" {
+		emailVerification.Policy = "off"
+	}
+
+	webAuthn := WebAuthnConf{
+		RPID:    os.Getenv("WEBAUTHN_RP_ID"),
+		RPName:  os.Getenv("WEBAUTHN_RP_NAME"),
+		Origins: splitList(os.Getenv("WEBAUTHN_ORIGINS")),
+	}
+	if webAuthn.RPName == "" {
+		webAuthn.RPName = app.Name
+	}
+
+	magicLink := MagicLinkConf{}
+	magicLink.Enabled, _ = strconv.ParseBool(os.Getenv("MAGIC_LINK_ENABLED"))
+
+	sms := SmsConf{
+		Provider: strings.ToLower(os.Getenv("SMS_PROVIDER")),
+		LogPath:  os.Getenv("SMS_LOG_PATH"),
+	}
+	if sms.Provider == "" {
+		sms.Provider = "log"
+	}
+	sms.Login, _ = strconv.ParseBool(os.Getenv("SMS_OTP_LOGIN"))
+	sms.Mfa, _ = strconv.ParseBool(os.Getenv("SMS_OTP_MFA"))
+
+	lockout := LockoutConf{
+		Threshold:   envInt("LOCKOUT_THRESHOLD", 10),
+		DelayAfter:  envInt("LOCKOUT_DELAY_AFTER", 3),
+		Duration:    envDuration("LOCKOUT_DURATION", 15*time.Minute),
+		MaxDuration: envDuration("LOCKOUT_MAX_DURATION", 24*time.Hour),
+		IpThreshold: envInt("LOCKOUT_IP_THRESHOLD", 100),
+	}
+
+	rateLimitPolicies := os.Getenv("RATE_LIMIT_POLICIES")
+	if rateLimitPolicies == "" {
+		rateLimitPolicies = defaultRateLimitPolicies
+	}
+
+	rateLimit := RateLimitConf{
+		FailOpen: strings.ToLower(os.Getenv("RATE_LIMIT_FAIL_MODE")) != "closed",
+		Policies: parseRateLimitPolicies(rateLimitPolicies),
+	}
+
+	passwordHash := PasswordHashConf{
+		Algorithm:         strings.ToLower(os.Getenv("PASSWORD_HASH_ALGORITHM")),
+		Argon2Memory:      envInt("PASSWORD_ARGON2_MEMORY", 64*1024),
+		Argon2Time:        envInt("PASSWORD_ARGON2_TIME", 3),
+		Argon2Parallelism: envInt("PASSWORD_ARGON2_PARALLELISM", 2),
+		BcryptCost:        envInt("PASSWORD_BCRYPT_COST", 10),
+		// half of the cores by default so other routes keep a share of the CPU
+		Workers:   envInt("PASSWORD_HASH_WORKERS", (runtime.NumCPU()+1)/2),
+		QueueSize: envInt("PASSWORD_HASH_QUEUE", 64),
+	}
+	if passwordHash.Algorithm == "" {
+		passwordHash.Algorithm = "argon2id"
+	}
+
+	passwordPolicy := PasswordPolicyConf{
+		MinLength:        envInt("PASSWORD_MIN_LENGTH", 8),
+		MaxLength:        envInt("PASSWORD_MAX_LENGTH", 128),
+		CharacterClasses: splitList(strings.ToLower(os.Getenv("PASSWORD_CHARACTER_CLASSES"))),
+		MinScore:         envInt("PASSWORD_MIN_SCORE", 2),
+		History:          envInt("PASSWORD_HISTORY", 5),
+	}
+	if os.Getenv("PASSWORD_CHARACTER_CLASSES") == "" {
+		passwordPolicy.CharacterClasses = []string{"upper", "lower", "digit", "symbol"}
+	}
+	// bcrypt only accepts 72 bytes of input
+	if passwordHash.Algorithm == "bcrypt" {
+		passwordPolicy.MaxBytes = 72
+		if passwordPolicy.MaxLength > 72 {
+			passwordPolicy.MaxLength = 72
+		}
+	}
+
+	breachedPassword := BreachedPasswordConf{
+		Source:   os.Getenv("BREACHED_PASSWORD_SOURCE"),
+		MinCount: envInt("BREACHED_PASSWORD_MIN_COUNT", 1),
+	}
+
 	config := Config{
 		App:  app,
 		Http: http,
@@ -103,8 +364,127 @@ func Make() Config {
 			Master: master,
 			Slave:  slave,
 		},
-		Redis: redis,
+		Redis:   redis,
+		Jwt:     jwt,
+		Secrets: secrets,
+		Page:    page,
+
+		EmailVerification: emailVerification,
+		WebAuthn:          webAuthn,
+		MagicLink:         magicLink,
+		Sms:               sms,
+		Lockout:           lockout,
+		RateLimit:         rateLimit,
+		PasswordPolicy:    passwordPolicy,
+		PasswordHash:      passwordHash,
+		BreachedPassword:  breachedPassword,
 	}
 
 	return config
 }
+
+// envInt reads an integer environment variable, falling back when it is unset or invalid.
+func envInt(name string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(name))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+// envDuration reads a duration environment variable such as "15m", falling back when it is unset or invalid.
+func envDuration(name string, fallback time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(name))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
+}
+
+// parseJwtKeys reads a comma separated list of kid:status:path entries,
+// e.g. "2024-10:active:/keys/2024-10.pem,2024-04:verify:/keys/2024-04.pem".
+func parseJwtKeys(value string) []JwtKeyConf {
+	var keys []JwtKeyConf
+	for _, entry := range splitKeyEntries(value) {
+		keys = append(keys, JwtKeyConf{Kid: entry[0], Status: entry[1], Path: entry[2]})
+	}
+	return keys
+}
+
+// parseSecrets reads a comma separated list of kid:status:source entries,
+// e.g. "2024-10:active:file:/run/secrets/encrypt-2024-10,2024-04:verify:env:ENCRYPT_KEY_2024_04".
+func parseSecrets(value string) []SecretConf {
+	var secrets []SecretConf
+	for _, entry := range splitKeyEntries(value) {
+		secrets = append(secrets, SecretConf{Kid: entry[0], Status: entry[1], Source: entry[2]})
+	}
+	return secrets
+}
+
+// parseRateLimitPolicies reads a comma separated list of name:key:limit:window entries,
+// e.g. "login:ip:20:1m,update-password:account:5:15m". Entries with an invalid key, limit or
+// window are skipped. A limit of 0 disables the policy.
+func parseRateLimitPolicies(value string) map[string]RateLimitPolicy {
+	policies := make(map[string]RateLimitPolicy)
+	for _, entry := range splitList(value) {
+		parts := strings.Split(entry, ":")
+		if len(parts) != 4 {
+			continue
+		}
+
+		key := strings.ToLower(strings.TrimSpace(parts[1]))
+		if key != "ip" && key != "account" && key != "client" {
+			continue
+		}
+
+		limit, err := strconv.Atoi(strings.TrimSpace(parts[2]))
+		if err != nil || limit < 0 {
+			continue
+		}
+
+		window, err := time.ParseDuration(strings.TrimSpace(parts[3]))
+		if err != nil || window <= 0 {
+			continue
+		}
+
+		policies[strings.TrimSpace(parts[0])] = RateLimitPolicy{Limit: limit, Window: window, Key: key}
+	}
+	return policies
+}
+
+// splitKeyEntries splits kid:status:rest entries. Malformed entries are kept with
+// an empty status so that key loading rejects them instead of silently dropping a key.
+func splitKeyEntries(value string) [][3]string {
+	var entries [][3]string
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.SplitN(entry, ":", 3)
+		if len(parts) != 3 {
+			entries = append(entries, [3]string{entry, "", ""})
+			continue
+		}
+
+		entries = append(entries, [3]string{
+			strings.TrimSpace(parts[0]),
+			strings.ToLower(strings.TrimSpace(parts[1])),
+			strings.TrimSpace(parts[2]),
+		})
+	}
+	return entries
+}
+
+// splitList reads a comma separated list, ignoring empty entries.
+func splitList(value string) []string {
+	var list []string
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry != "" {
+			list = append(list, entry)
+		}
+	}
+	return list
+}
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseRateLimitPolicies(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  map[string]RateLimitPolicy
+	}{
+		{
+			name:  "valid entries",
+			value: "login:ip:20:1m, update-password:ACCOUNT:5:15m,oauth-token:client:60:1m",
+			want: map[string]RateLimitPolicy{
+				"login":           {Limit: 20, Window: time.Minute, Key: "ip"},
+				"update-password": {Limit: 5, Window: 15 * time.Minute, Key: "account"},
+				"oauth-token":     {Limit: 60, Window: time.Minute, Key: "client"},
+			},
+		},
+		{
+			name:  "zero limit kept to disable the route",
+			value: "login:ip:0:1m",
+			want:  map[string]RateLimitPolicy{"login": {Limit: 0, Window: time.Minute, Key: "ip"}},
+		},
+		{name: "too few parts", value: "login:ip:20", want: map[string]RateLimitPolicy{}},
+		{name: "too many parts", value: "login:ip:20:1m:extra", want: map[string]RateLimitPolicy{}},
+		{name: "unknown key", value: "login:user:20:1m", want: map[string]RateLimitPolicy{}},
+		{name: "non numeric limit", value: "login:ip:many:1m", want: map[string]RateLimitPolicy{}},
+		{name: "negative limit", value: "login:ip:-1:1m", want: map[string]RateLimitPolicy{}},
+		{name: "invalid window", value: "login:ip:20:soon", want: map[string]RateLimitPolicy{}},
+		{name: "zero window", value: "login:ip:20:0s", want: map[string]RateLimitPolicy{}},
+		{name: "negative window", value: "login:ip:20:-1m", want: map[string]RateLimitPolicy{}},
+		{
+			name:  "malformed entry does not drop valid ones",
+			value: "login:ip:20:1m,register:ip:five:1h,refresh-token:ip:30:1m",
+			want: map[string]RateLimitPolicy{
+				"login":         {Limit: 20, Window: time.Minute, Key: "ip"},
+				"refresh-token": {Limit: 30, Window: time.Minute, Key: "ip"},
+			},
+		},
+		{name: "empty", value: "", want: map[string]RateLimitPolicy{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseRateLimitPolicies(tt.value); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("parseRateLimitPolicies(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefaultRateLimitPolicies(t *testing.T) {
+	policies := parseRateLimitPolicies(defaultRateLimitPolicies)
+	for _, name := range []string{"register", "login", "refresh-token", "update-password", "oauth-token"} {
+		if policy, ok := policies[name]; !ok || policy.Limit <= 0 {
+			t.Fatalf("default policy %q missing or disabled: %+v", name, policy)
+		}
+	}
+}
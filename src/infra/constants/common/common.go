@@ -12,32 +12,144 @@ const (
 
 	AttachmentSizeLimit int64 = 10 * 1024 * 1024 // 10 MB
 
-	EncryptKey = "a9B2cD3eF4gH5iJ6kL7mN8oP9qR0sT13"
+	// Purpose untuk helper.Encrypt/Decrypt, ciphertext hanya valid untuk purpose yang sama
+	PurposeRevokeLink  = "revoke-link"
+	PurposeVerifyEmail = "verify-email"
+	PurposeTotpSecret  = "totp-secret"
+	PurposeMagicLink   = "magic-link"
 
-	JwtKey        = "secret_key"
-	JwtRefreshKey = "refresh_secret_key"
+	// Kebijakan verifikasi email (EMAIL_VERIFICATION_POLICY)
+	VerificationPolicyOff       = "off"
+	VerificationPolicyLogin     = "login"
+	VerificationPolicyEndpoints = "endpoints"
 
-	AccessTokenExp  = 120 * time.Minute
-	RefreshTokenExp = 7 * 24 * time.Hour
-	UserDetailExp   = 24 * time.Hour
-	RateLimit       = 5 * time.Minute
-	RevokeTokenExp  = 30 * time.Minute
+	// Status key JWT dan secret
+	KeyActive  = "active"
+	KeyVerify  = "verify"
+	KeyRetired = "retired"
+
+	AccessTokenExp         = 120 * time.Minute
+	RefreshTokenExp        = 7 * 24 * time.Hour
+	UserDetailExp          = 24 * time.Hour
+	RateLimit              = 5 * time.Minute
+	RevokeTokenExp         = 30 * time.Minute
+	ResetPasswordExp       = 15 * time.Minute
+	VerifyEmailExp         = 24 * time.Hour
+	VerifyEmailResendLimit = 1 * time.Minute
+	MfaChallengeExp        = 5 * time.Minute
+	TotpUsedExp            = 2 * time.Minute
+	WebAuthnChallengeExp   = 5 * time.Minute
+	MagicLinkExp           = 10 * time.Minute
+	SmsOtpExp              = 5 * time.Minute
+	SmsOtpAttemptWindow    = 1 * time.Hour
+	LoginFailureWindow     = 24 * time.Hour
+	LoginFailureIpWindow   = 1 * time.Hour
+	LoginMaxDelay          = 30 * time.Second
+	JwksCacheMaxAge        = 5 * time.Minute
+	PasswordHashRetryAfter = 1 * time.Second
+
+	AuthorizationCodeExp = 1 * time.Minute
+	IDTokenExp           = 60 * time.Minute
+	ClientTokenExp       = 30 * time.Minute
+	IntrospectionExp     = 30 * time.Second
 
 	NatsAuthSubject = `AuthSubject`
 	NatsAuthQueue   = `AuthQueue`
 
-	EventLogin          = "Login"
-	EventRegister       = "Register"
-	EventUpdatePassword = "UpdatePassword"
+	EventLogin             = "Login"
+	EventRegister          = "Register"
+	EventUpdatePassword    = "UpdatePassword"
+	EventRefreshTokenReuse = "RefreshTokenReuse"
+	EventForgotPassword    = "ForgotPassword"
+	EventVerifyEmail       = "VerifyEmail"
+	EventRecoveryCodeUsed  = "RecoveryCodeUsed"
+	EventMagicLink         = "MagicLink"
+	EventAccountLocked     = "AccountLocked"
+
+	// Metode login yang dicatat di user_login_history
+	AuthMethodPassword     = "password"
+	AuthMethodTotp         = "totp"
+	AuthMethodRecoveryCode = "recovery_code"
+	AuthMethodPasskey      = "passkey"
+	AuthMethodMagicLink    = "magic_link"
+	AuthMethodSms          = "sms"
+
+	// Metode langkah kedua login (mfa_method pada LoginResp)
+	MfaMethodTotp = "totp"
+	MfaMethodSms  = "sms"
+
+	// Purpose SMS OTP, OTP hanya valid untuk purpose yang sama
+	SmsOtpPurposePhone = "phone"
+	SmsOtpPurposeLogin = "login"
+
+	RecoveryCodeCount = 10
 
 	// Redis Key
-	LoginKey        = "login_attempt"
-	AccessTokenKey  = "access_token"
-	RefreshTokenKey = "refresh_token"
-	UserIdKey       = "user_id"
-	RevokeTokenKey  = "revoke_token"
+	LoginFailKey         = "login_fail"
+	LoginFailIpKey       = "login_fail_ip"
+	LoginDelayKey        = "login_delay"
+	LoginLockKey         = "login_lock"
+	LoginLockIpKey       = "login_lock_ip"
+	LoginLockCountKey    = "login_lock_count"
+	AccessDenylistKey    = "access_denylist"
+	UserIdKey            = "user_id"
+	RevokeTokenKey       = "revoke_token"
+	OAuthCodeKey         = "oauth_code"
+	IntrospectKey        = "introspect"
+	ForgotPasswordKey    = "forgot_password"
+	ResetPasswordKey     = "reset_password"
+	ResetPasswordUserKey = "reset_password_user"
+	VerifyEmailKey       = "verify_email"
+	MfaChallengeKey      = "mfa_challenge"
+	MfaAttemptKey        = "mfa_attempt"
+	TotpUsedKey          = "totp_used"
+	WebAuthnChallengeKey = "webauthn_challenge"
+	MagicLinkKey         = "magic_link"
+	MagicLinkUserKey     = "magic_link_user"
+	MagicLinkEmailKey    = "magic_link_email"
+	MagicLinkIpKey       = "magic_link_ip"
+	SmsOtpKey            = "sms_otp"
+	SmsOtpAttemptKey     = "sms_otp_attempt"
+	SmsOtpSendKey        = "sms_otp_send"
+	SmsLoginIpKey        = "sms_login_ip"
+	RateLimitKey         = "rate_limit"
+
+	// OAuth2 / OpenID Connect
+	GrantTypeAuthorizationCode = "authorization_code"
+	GrantTypeRefreshToken      = "refresh_token"
+	GrantTypeClientCredentials = "client_credentials"
+	ResponseTypeCode           = "code"
+	CodeChallengeS256          = "S256"
+	ScopeOpenId                = "openid"
+	ScopeProfile               = "profile"
+	ScopeEmail                 = "email"
+	TokenTypeBearer            = "Bearer"
+	SubjectTypeUser            = "user"
+	SubjectTypeClient          = "client"
+	TokenTypeHintAccess        = "access_token"
+	TokenTypeHintRefresh       = "refresh_token"
+
+	// OAuth2 error code (RFC 6749 5.2)
+	OAuthErrInvalidRequest          = "invalid_request"
+	OAuthErrInvalidClient           = "invalid_client"
+	OAuthErrInvalidGrant            = "invalid_grant"
+	OAuthErrUnauthorizedClient      = "unauthorized_client"
+	OAuthErrUnsupportedGrantType    = "unsupported_grant_type"
+	OAuthErrUnsupportedResponseType = "unsupported_response_type"
+	OAuthErrInvalidScope            = "invalid_scope"
+	OAuthErrInvalidToken            = "invalid_token"
+	OAuthErrServerError             = "server_error"
+	OAuthErrTemporarilyUnavailable  = "temporarily_unavailable"
+
+	// WebAuthn ceremony
+	WebAuthnCeremonyRegister = "webauthn.create"
+	WebAuthnCeremonyLogin    = "webauthn.get"
 
 	// Logout Reason
-	Token_Revoked = "Token Revoked"
-	User_Logout   = "User Logout"
+	Token_Revoked       = "Token Revoked"
+	User_Logout         = "User Logout"
+	Refresh_Token_Reuse = "Refresh Token Reuse"
+	Password_Changed    = "Password Changed"
+	Session_Revoked     = "Session Revoked"
+	Password_Reset      = "Password Reset"
 )
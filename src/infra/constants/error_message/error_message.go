@@ -5,6 +5,7 @@ const (
 	InvalidPassword          = "invalid password"
 	InvalidToken             = "invalid token"
 	ExpiredToken             = "token has expired"
+	RevokedToken             = "token has been revoked"
 	MissingToken             = "Token is missing or not found"
 	UserNotFound             = "user not found"
 	FailedUpdateData         = "failed to update data"
@@ -18,4 +19,49 @@ const (
 	BadRequest               = "bad request"
 	UserRefreshTokenNotFound = "user refresh token not found"
 	MissingUserAgent         = "missing user agent"
+	NoActiveSigningKey       = "no active signing key configured"
+	UnsupportedSigningKey    = "unsupported signing key type"
+	InvalidSigningKeyStatus  = "invalid signing key status"
+	NoActiveSecret           = "no active secret configured"
+	InvalidSecret            = "invalid secret"
+	InvalidEncryptedData     = "invalid or tampered data"
+	RevokeLinkUsed           = "link has already been used"
+	PasswordResetRequired    = "password reset is required before logging in"
+	InvalidResetToken        = "reset password token is invalid or has expired"
+	EmailNotVerified         = "email address has not been verified"
+	OAuthClientNotFound      = "oauth client not found"
+	InvalidClient            = "client authentication failed"
+	InvalidRedirectUri       = "redirect_uri is not registered for this client"
+	InvalidScope             = "requested scope is not allowed"
+	InvalidGrant             = "authorization grant is invalid, expired or already used"
+	InvalidCodeVerifier      = "code_verifier does not match code_challenge"
+	UnsupportedGrantType     = "grant_type is not supported"
+	UnsupportedResponseType  = "response_type is not supported"
+	UnauthorizedClient       = "client is not allowed to use this grant_type"
+	InvalidCredentials       = "invalid email or password"
+	RefreshTokenReused       = "refresh token reuse detected, session has been revoked"
+	SessionNotFound          = "session not found or already ended"
+	TotpNotFound             = "totp has not been enrolled"
+	TotpAlreadyEnabled       = "totp is already enabled"
+	InvalidTotpCode          = "invalid authentication code"
+	InvalidMfaToken          = "mfa token is invalid or has expired"
+	MfaRequired              = "authentication code is required"
+	InvalidRecoveryCode      = "invalid recovery code"
+	WebAuthnDisabled         = "passkey is not configured"
+	InvalidWebAuthnChallenge = "passkey challenge is invalid or has expired"
+	InvalidWebAuthnResponse  = "passkey response could not be verified"
+	PasskeyNotFound          = "passkey not found"
+	PasskeyAlreadyRegistered = "passkey is already registered"
+	MagicLinkDisabled        = "magic link login is not enabled"
+	InvalidMagicLink         = "magic link is invalid, expired or already used"
+	MagicLinkDeviceMismatch  = "magic link must be opened on the device that requested it"
+	SmsLoginDisabled         = "sms login is not enabled"
+	InvalidSmsOtp            = "sms code is invalid or has expired"
+	PhoneNotFound            = "no verified phone number"
+	AccountLocked            = "account is temporarily locked due to too many failed login attempts"
+	Forbidden                = "you do not have permission to perform this action"
+	RateLimitUnavailable     = "rate limiter is unavailable, please try again later"
+	PasswordBreached         = "password has appeared in a data breach, choose a different password"
+	PasswordHashBusy         = "server is busy, please try again later"
+	PasswordReused           = "password was used recently, choose a different password"
 )
@@ -0,0 +1,231 @@
+package helper
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go-auth-service/src/infra/config"
+)
+
+// breachFilterMagic adalah header file filter: magic | k (uint32) | m bit (uint64) | bitset
+const breachFilterMagic = "GABF1"
+
+// BreachFilter adalah bloom filter berisi hash SHA-1 password yang bocor. Index bit diturunkan
+// dari hash SHA-1 itu sendiri (double hashing), sehingga lookup tidak perlu hash tambahan.
+type BreachFilter struct {
+	k    uint32
+	m    uint64
+	bits []byte
+}
+
+// NewBreachFilter membuat filter untuk n hash dengan tingkat false positive fpRate
+func NewBreachFilter(n uint64, fpRate float64) *BreachFilter {
+	if n == 0 {
+		n = 1
+	}
+
+	m := uint64(math.Ceil(-float64(n) * math.Log(fpRate) / (math.Ln2 * math.Ln2)))
+	k := uint32(math.Round(float64(m) / float64(n) * math.Ln2))
+	if k < 1 {
+		k = 1
+	}
+
+	return &BreachFilter{k: k, m: m, bits: make([]byte, (m+7)/8)}
+}
+
+func (f *BreachFilter) Add(sum [sha1.Size]byte) {
+	h1, h2 := breachFilterHashes(sum)
+	for i := uint64(0); i < uint64(f.k); i++ {
+		bit := (h1 + i*h2) % f.m
+		f.bits[bit/8] |= 1 << (bit % 8)
+	}
+}
+
+func (f *BreachFilter) Contains(sum [sha1.Size]byte) bool {
+	h1, h2 := breachFilterHashes(sum)
+	for i := uint64(0); i < uint64(f.k); i++ {
+		bit := (h1 + i*h2) % f.m
+		if f.bits[bit/8]&(1<<(bit%8)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (f *BreachFilter) WriteTo(w io.Writer) (int64, error) {
+	header := make([]byte, len(breachFilterMagic)+12)
+	copy(header, breachFilterMagic)
+	binary.BigEndian.PutUint32(header[len(breachFilterMagic):], f.k)
+	binary.BigEndian.PutUint64(header[len(breachFilterMagic)+4:], f.m)
+
+	n, err := w.Write(header)
+	if err != nil {
+		return int64(n), err
+	}
+
+	written, err := w.Write(f.bits)
+	return int64(n + written), err
+}
+
+// ReadBreachFilter membaca file hasil BreachFilter.WriteTo
+func ReadBreachFilter(r io.Reader) (*BreachFilter, error) {
+	header := make([]byte, len(breachFilterMagic)+12)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+
+	if string(header[:len(breachFilterMagic)]) != breachFilterMagic {
+		return nil, errors.New("not a breached password filter file")
+	}
+
+	f := &BreachFilter{
+		k: binary.BigEndian.Uint32(header[len(breachFilterMagic):]),
+		m: binary.BigEndian.Uint64(header[len(breachFilterMagic)+4:]),
+	}
+	if f.k == 0 || f.m == 0 {
+		return nil, errors.New("invalid breached password filter header")
+	}
+
+	f.bits = make([]byte, (f.m+7)/8)
+	if _, err := io.ReadFull(r, f.bits); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func breachFilterHashes(sum [sha1.Size]byte) (uint64, uint64) {
+	h1 := binary.BigEndian.Uint64(sum[0:8])
+	h2 := binary.BigEndian.Uint64(sum[8:16]) | 1
+	return h1, h2
+}
+
+// ParseBreachLine membaca satu baris dataset HIBP "HASH:COUNT". Untuk file range HIBP
+// (nama file adalah 5 karakter awal hash, isi baris hanya SUFFIX:COUNT) prefix diisi nama file.
+func ParseBreachLine(line, prefix string) (sum [sha1.Size]byte, count int, ok bool) {
+	line = strings.TrimSpace(line)
+	hash, countStr, found := strings.Cut(line, ":")
+	if !found {
+		return sum, 0, false
+	}
+
+	decoded, err := hex.DecodeString(prefix + hash)
+	if err != nil || len(decoded) != sha1.Size {
+		return sum, 0, false
+	}
+
+	count, err = strconv.Atoi(strings.TrimSpace(countStr))
+	if err != nil {
+		return sum, 0, false
+	}
+
+	copy(sum[:], decoded)
+	return sum, count, true
+}
+
+type breachedPasswords struct {
+	mu       sync.RWMutex
+	filter   *BreachFilter
+	dir      string
+	minCount int
+}
+
+var breachedSet = &breachedPasswords{}
+
+// LoadBreachedPasswords memuat dataset password bocor saat startup. Source berupa file filter
+// dimuat ke memori, sedangkan direktori range HIBP dibaca per prefix saat lookup.
+func LoadBreachedPasswords(conf config.BreachedPasswordConf) error {
+	if conf.Source == "" {
+		log.Println("BREACHED_PASSWORD_SOURCE is empty, breached password check is disabled")
+		return nil
+	}
+
+	info, err := os.Stat(conf.Source)
+	if err != nil {
+		return err
+	}
+
+	breachedSet.mu.Lock()
+	defer breachedSet.mu.Unlock()
+
+	if info.IsDir() {
+		breachedSet.filter = nil
+		breachedSet.dir = conf.Source
+		breachedSet.minCount = conf.MinCount
+		return nil
+	}
+
+	file, err := os.Open(conf.Source)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	filter, err := ReadBreachFilter(bufio.NewReader(file))
+	if err != nil {
+		return fmt.Errorf("BREACHED_PASSWORD_SOURCE: %v", err)
+	}
+
+	breachedSet.filter = filter
+	breachedSet.dir = ""
+	return nil
+}
+
+// IsBreachedPassword memeriksa password terhadap dataset password bocor tanpa memanggil API luar.
+// Selalu false jika dataset tidak dikonfigurasi.
+func IsBreachedPassword(password string) (bool, error) {
+	breachedSet.mu.RLock()
+	defer breachedSet.mu.RUnlock()
+
+	sum := sha1.Sum([]byte(password))
+
+	if breachedSet.filter != nil {
+		return breachedSet.filter.Contains(sum), nil
+	}
+
+	if breachedSet.dir != "" {
+		return breachedSet.containsInRange(sum)
+	}
+
+	return false, nil
+}
+
+// containsInRange mencari hash di file range HIBP milik 5 karakter awal hash
+func (b *breachedPasswords) containsInRange(sum [sha1.Size]byte) (bool, error) {
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix := hash[:5]
+
+	file, err := os.Open(filepath.Join(b.dir, prefix+".txt"))
+	if errors.Is(err, os.ErrNotExist) {
+		file, err = os.Open(filepath.Join(b.dir, prefix))
+	}
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lineSum, count, ok := ParseBreachLine(scanner.Text(), prefix)
+		if ok && lineSum == sum {
+			return count >= b.minCount, nil
+		}
+	}
+
+	return false, scanner.Err()
+}
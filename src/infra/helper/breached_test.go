@@ -0,0 +1,211 @@
+package helper
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go-auth-service/src/infra/config"
+)
+
+// resetBreachedPasswords mengembalikan dataset global setelah test selesai
+func resetBreachedPasswords(t *testing.T) {
+	t.Cleanup(func() {
+		breachedSet.mu.Lock()
+		defer breachedSet.mu.Unlock()
+		breachedSet.filter = nil
+		breachedSet.dir = ""
+		breachedSet.minCount = 0
+	})
+}
+
+func TestBreachFilter(t *testing.T) {
+	const n = 10000
+
+	filter := NewBreachFilter(n, 0.001)
+	for i := 0; i < n; i++ {
+		filter.Add(sha1.Sum([]byte(fmt.Sprintf("breached-%d", i))))
+	}
+
+	var buf bytes.Buffer
+	if _, err := filter.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := ReadBreachFilter(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < n; i++ {
+		if !loaded.Contains(sha1.Sum([]byte(fmt.Sprintf("breached-%d", i)))) {
+			t.Fatalf("breached-%d missing from filter", i)
+		}
+	}
+
+	// false positive harus mendekati fpRate, diberi toleransi 5x agar test tidak flaky
+	falsePositives := 0
+	for i := 0; i < n; i++ {
+		if loaded.Contains(sha1.Sum([]byte(fmt.Sprintf("clean-%d", i)))) {
+			falsePositives++
+		}
+	}
+	if falsePositives > n*5/1000 {
+		t.Fatalf("%d false positives out of %d", falsePositives, n)
+	}
+}
+
+func TestReadBreachFilterInvalid(t *testing.T) {
+	var valid bytes.Buffer
+	if _, err := NewBreachFilter(10, 0.01).WriteTo(&valid); err != nil {
+		t.Fatal(err)
+	}
+
+	zeroK := append([]byte{}, valid.Bytes()...)
+	copy(zeroK[len(breachFilterMagic):], []byte{0, 0, 0, 0})
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "empty", data: nil},
+		{name: "wrong magic", data: append([]byte("NOPE1"), valid.Bytes()[len(breachFilterMagic):]...)},
+		{name: "zero hash functions", data: zeroK},
+		{name: "truncated bitset", data: valid.Bytes()[:valid.Len()-1]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ReadBreachFilter(bytes.NewReader(tt.data)); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
+
+func TestParseBreachLine(t *testing.T) {
+	full := sha1.Sum([]byte("password"))
+	hash := strings.ToUpper(hex.EncodeToString(full[:]))
+
+	tests := []struct {
+		name      string
+		line      string
+		prefix    string
+		wantCount int
+		wantOk    bool
+	}{
+		{name: "full hash", line: hash + ":3861493", wantCount: 3861493, wantOk: true},
+		{name: "range file suffix", line: hash[5:] + ":12\r\n", prefix: hash[:5], wantCount: 12, wantOk: true},
+		{name: "lowercase hash", line: strings.ToLower(hash) + ":1", wantCount: 1, wantOk: true},
+		{name: "missing count", line: hash, wantOk: false},
+		{name: "invalid count", line: hash + ":many", wantOk: false},
+		{name: "short hash", line: hash[:39] + ":1", wantOk: false},
+		{name: "not hex", line: strings.Repeat("z", 40) + ":1", wantOk: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sum, count, ok := ParseBreachLine(tt.line, tt.prefix)
+			if ok != tt.wantOk {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOk)
+			}
+			if ok && (sum != full || count != tt.wantCount) {
+				t.Fatalf("sum = %x count = %d, want %x and %d", sum, count, full, tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestIsBreachedPasswordFilter(t *testing.T) {
+	resetBreachedPasswords(t)
+
+	filter := NewBreachFilter(1, 0.0001)
+	filter.Add(sha1.Sum([]byte("P@ssw0rd")))
+
+	path := filepath.Join(t.TempDir(), "breached.bin")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = filter.WriteTo(file); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	if err = LoadBreachedPasswords(config.BreachedPasswordConf{Source: path}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		password string
+		want     bool
+	}{
+		{password: "P@ssw0rd", want: true},
+		{password: "p@ssw0rd", want: false},
+		{password: "kT9#vQ2!mZ", want: false},
+	}
+
+	for _, tt := range tests {
+		got, err := IsBreachedPassword(tt.password)
+		if err != nil || got != tt.want {
+			t.Errorf("IsBreachedPassword(%q) = %v, %v, want %v", tt.password, got, err, tt.want)
+		}
+	}
+}
+
+func TestIsBreachedPasswordRangeDir(t *testing.T) {
+	resetBreachedPasswords(t)
+
+	dir := t.TempDir()
+	writeRange := func(password string, count int, fileName func(prefix string) string) {
+		sum := sha1.Sum([]byte(password))
+		hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+		content := fmt.Sprintf("0000000000000000000000000000000000A:1\n%s:%d\n", hash[5:], count)
+		if err := os.WriteFile(filepath.Join(dir, fileName(hash[:5])), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeRange("password", 3861493, func(prefix string) string { return prefix + ".txt" })
+	writeRange("rarely-used", 2, func(prefix string) string { return prefix })
+
+	if err := LoadBreachedPasswords(config.BreachedPasswordConf{Source: dir, MinCount: 10}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		password string
+		want     bool
+	}{
+		{name: "count above minimum", password: "password", want: true},
+		{name: "count below minimum", password: "rarely-used", want: false},
+		{name: "no range file", password: "kT9#vQ2!mZ", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := IsBreachedPassword(tt.password)
+			if err != nil || got != tt.want {
+				t.Fatalf("IsBreachedPassword(%q) = %v, %v, want %v", tt.password, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsBreachedPasswordDisabled(t *testing.T) {
+	resetBreachedPasswords(t)
+
+	if err := LoadBreachedPasswords(config.BreachedPasswordConf{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, err := IsBreachedPassword("password"); err != nil || got {
+		t.Fatalf("IsBreachedPassword = %v, %v, want false without a dataset", got, err)
+	}
+}
@@ -0,0 +1,127 @@
+package helper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"go-auth-service/src/infra/constants/common"
+	redis "go-auth-service/src/infra/persistence/redis/service"
+)
+
+var tokenDenylist redis.ServRedisInterface
+
+// InitTokenDenylist mengaktifkan pengecekan denylist access token pada VerifyAccessToken
+func InitTokenDenylist(redisService redis.ServRedisInterface) {
+	tokenDenylist = redisService
+}
+
+func denylistJtiKey(jti string) string {
+	return fmt.Sprintf("%s:jti:%s", common.AccessDenylistKey, jti)
+}
+
+func denylistSessionKey(sessionId string) string {
+	return fmt.Sprintf("%s:sid:%s", common.AccessDenylistKey, sessionId)
+}
+
+func denylistUserKey(userId int64) string {
+	return fmt.Sprintf("%s:user:%d", common.AccessDenylistKey, userId)
+}
+
+// RevokeAccessToken memasukkan jti dan sesi token ke denylist selama sisa umur token
+func RevokeAccessToken(claims *TokenClaims) error {
+	if tokenDenylist == nil {
+		return errors.New("token denylist is not initialized")
+	}
+
+	ttl := time.Until(time.Unix(claims.ExpiresAt, 0))
+	if ttl <= 0 {
+		return nil
+	}
+
+	if claims.Id != "" {
+		if err := tokenDenylist.SetData(context.Background(), denylistJtiKey(claims.Id), 1, ttl); err != nil {
+			return err
+		}
+	}
+
+	if claims.SessionID != "" {
+		return RevokeSession(claims.SessionID)
+	}
+
+	return nil
+}
+
+// RevokeSession menolak seluruh access token milik satu sesi, termasuk yang
+// diterbitkan ulang melalui refresh token
+func RevokeSession(sessionId string) error {
+	if tokenDenylist == nil {
+		return errors.New("token denylist is not initialized")
+	}
+
+	return tokenDenylist.SetData(context.Background(), denylistSessionKey(sessionId), 1, common.AccessTokenExp)
+}
+
+// RevokeUserTokens menolak seluruh access token user yang diterbitkan sampai saat ini,
+// dipakai ketika password diganti atau token dicabut. Waktu disimpan dalam milidetik agar
+// token baru yang diterbitkan pada detik yang sama tetap berlaku.
+func RevokeUserTokens(userId int64) error {
+	if tokenDenylist == nil {
+		return errors.New("token denylist is not initialized")
+	}
+
+	return tokenDenylist.SetData(context.Background(), denylistUserKey(userId), time.Now().UnixMilli(), common.AccessTokenExp)
+}
+
+// issuedBeforeRevocation membandingkan waktu terbit token dengan nilai RevokeUserTokens dalam milidetik.
+// Token tanpa iat_ms memakai iat yang dibulatkan ke awal detik.
+func issuedBeforeRevocation(claims *TokenClaims, revokedAt int64) bool {
+	issuedAtMs := claims.IssuedAtMs
+	if issuedAtMs == 0 {
+		issuedAtMs = claims.IssuedAt * 1000
+	}
+
+	return issuedAtMs <= revokedAt
+}
+
+// isAccessTokenRevoked memeriksa jti, sesi dan batas waktu user dalam satu round trip.
+// Jika Redis tidak dapat diakses token dianggap dicabut (fail closed).
+func isAccessTokenRevoked(claims *TokenClaims) (bool, error) {
+	if tokenDenylist == nil {
+		return false, nil
+	}
+
+	keys := []string{denylistJtiKey(claims.Id)}
+	if claims.SessionID != "" {
+		keys = append(keys, denylistSessionKey(claims.SessionID))
+	}
+	if claims.SubjectType == common.SubjectTypeUser {
+		keys = append(keys, denylistUserKey(claims.UserID))
+	}
+
+	values, err := tokenDenylist.GetMultiData(context.Background(), keys...)
+	if err != nil {
+		log.Println("Failed to check access token denylist:", err)
+		return true, err
+	}
+
+	for i, value := range values {
+		if value == "" {
+			continue
+		}
+
+		if keys[i] != denylistUserKey(claims.UserID) {
+			return true, nil
+		}
+
+		revokedAt, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || issuedBeforeRevocation(claims, revokedAt) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
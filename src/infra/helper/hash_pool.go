@@ -0,0 +1,159 @@
+package helper
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	errorMessage "go-auth-service/src/infra/constants/error_message"
+)
+
+// ErrPasswordHashBusy dikembalikan HashPassword dan VerifyPassword saat antrean hashing penuh
+var ErrPasswordHashBusy = errors.New(errorMessage.PasswordHashBusy)
+
+// hashQueueBuckets adalah batas atas (ms) bucket histogram waktu tunggu antrean
+var hashQueueBuckets = []float64{1, 2, 5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000}
+
+// PasswordHashMetrics adalah ringkasan worker pool hashing password sejak service berjalan
+type PasswordHashMetrics struct {
+	Workers        int     `json:"workers"`
+	QueueSize      int     `json:"queue_size"`
+	Queued         int     `json:"queued"`
+	Running        int64   `json:"running"`
+	Completed      uint64  `json:"completed"`
+	Rejected       uint64  `json:"rejected"`
+	QueueTimeAvgMs float64 `json:"queue_time_avg_ms"`
+	QueueTimeP99Ms float64 `json:"queue_time_p99_ms"`
+	QueueTimeMaxMs float64 `json:"queue_time_max_ms"`
+}
+
+type hashJob struct {
+	run      func()
+	enqueued time.Time
+	done     chan struct{}
+}
+
+// hashWorkerPool menjalankan hashing dan verifikasi password di sejumlah worker tetap, sehingga
+// lonjakan login tidak memakai seluruh CPU. Job yang tidak muat di antrean langsung ditolak.
+type hashWorkerPool struct {
+	jobs    chan *hashJob
+	workers int
+
+	mu             sync.Mutex
+	running        int64
+	completed      uint64
+	rejected       uint64
+	queueTimeTotal time.Duration
+	queueTimeMax   time.Duration
+	queueTimeHist  []uint64
+}
+
+func newHashWorkerPool(workers, queueSize int) *hashWorkerPool {
+	p := &hashWorkerPool{
+		jobs:          make(chan *hashJob, queueSize),
+		workers:       workers,
+		queueTimeHist: make([]uint64, len(hashQueueBuckets)+1),
+	}
+
+	for i := 0; i < workers; i++ {
+		go p.work()
+	}
+
+	return p
+}
+
+func (p *hashWorkerPool) work() {
+	for job := range p.jobs {
+		p.started(time.Since(job.enqueued))
+		job.run()
+		p.finished()
+		close(job.done)
+	}
+}
+
+// do menjalankan fn di worker pool dan menunggu sampai selesai
+func (p *hashWorkerPool) do(fn func()) error {
+	job := &hashJob{run: fn, enqueued: time.Now(), done: make(chan struct{})}
+
+	select {
+	case p.jobs <- job:
+	default:
+		p.mu.Lock()
+		p.rejected++
+		p.mu.Unlock()
+		return ErrPasswordHashBusy
+	}
+
+	<-job.done
+	return nil
+}
+
+func (p *hashWorkerPool) started(wait time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.running++
+	p.queueTimeTotal += wait
+	if wait > p.queueTimeMax {
+		p.queueTimeMax = wait
+	}
+
+	ms := float64(wait) / float64(time.Millisecond)
+	bucket := len(hashQueueBuckets)
+	for i, upper := range hashQueueBuckets {
+		if ms <= upper {
+			bucket = i
+			break
+		}
+	}
+	p.queueTimeHist[bucket]++
+}
+
+func (p *hashWorkerPool) finished() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.running--
+	p.completed++
+}
+
+func (p *hashWorkerPool) metrics() PasswordHashMetrics {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	metrics := PasswordHashMetrics{
+		Workers:        p.workers,
+		QueueSize:      cap(p.jobs),
+		Queued:         len(p.jobs),
+		Running:        p.running,
+		Completed:      p.completed,
+		Rejected:       p.rejected,
+		QueueTimeMaxMs: float64(p.queueTimeMax) / float64(time.Millisecond),
+	}
+
+	var total uint64
+	for _, count := range p.queueTimeHist {
+		total += count
+	}
+	if total == 0 {
+		return metrics
+	}
+
+	metrics.QueueTimeAvgMs = float64(p.queueTimeTotal) / float64(total) / float64(time.Millisecond)
+
+	// p99 dibulatkan ke batas atas bucket, bucket terakhir memakai waktu tunggu terlama
+	var cumulative uint64
+	for i, count := range p.queueTimeHist {
+		cumulative += count
+		if float64(cumulative) >= 0.99*float64(total) {
+			if i < len(hashQueueBuckets) && hashQueueBuckets[i] < metrics.QueueTimeMaxMs {
+				metrics.QueueTimeP99Ms = hashQueueBuckets[i]
+			} else {
+				metrics.QueueTimeP99Ms = metrics.QueueTimeMaxMs
+			}
+			break
+		}
+	}
+
+	return metrics
+}
@@ -0,0 +1,129 @@
+package helper
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// blockPool mengisi satu-satunya worker dan seluruh antrean pool dengan job yang menunggu
+// release ditutup. Mengembalikan fungsi untuk melepas job dan menunggu semuanya selesai.
+func blockPool(t *testing.T, pool *hashWorkerPool) (release func()) {
+	t.Helper()
+
+	unblock := make(chan struct{})
+	started := make(chan struct{})
+	results := make(chan error, 1+cap(pool.jobs))
+
+	// tanpa antrean, job hanya diterima saat worker sudah menunggu sehingga perlu dicoba ulang
+	go func() {
+		for {
+			err := pool.do(func() {
+				close(started)
+				<-unblock
+			})
+			if !errors.Is(err, ErrPasswordHashBusy) {
+				results <- err
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}()
+
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		t.Fatal("worker did not start the first job")
+	}
+
+	for i := 0; i < cap(pool.jobs); i++ {
+		go func() { results <- pool.do(func() {}) }()
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for len(pool.jobs) < cap(pool.jobs) {
+		if time.Now().After(deadline) {
+			t.Fatal("queue was not filled")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	return func() {
+		close(unblock)
+		for i := 0; i < 1+cap(pool.jobs); i++ {
+			if err := <-results; err != nil {
+				t.Errorf("queued job failed: %v", err)
+			}
+		}
+	}
+}
+
+func TestHashWorkerPoolRejectsWhenQueueFull(t *testing.T) {
+	pool := newHashWorkerPool(1, 2)
+	release := blockPool(t, pool)
+
+	ran := false
+	if err := pool.do(func() { ran = true }); !errors.Is(err, ErrPasswordHashBusy) {
+		t.Fatalf("err = %v, want ErrPasswordHashBusy", err)
+	}
+	if ran {
+		t.Fatal("rejected job was executed")
+	}
+
+	metrics := pool.metrics()
+	if metrics.Workers != 1 || metrics.QueueSize != 2 || metrics.Queued != 2 || metrics.Running != 1 || metrics.Rejected != 1 {
+		t.Fatalf("unexpected metrics while full: %+v", metrics)
+	}
+
+	release()
+
+	if err := pool.do(func() { ran = true }); err != nil || !ran {
+		t.Fatalf("job after release: ran = %v, err = %v", ran, err)
+	}
+
+	metrics = pool.metrics()
+	if metrics.Completed != 4 || metrics.Running != 0 || metrics.Queued != 0 || metrics.Rejected != 1 {
+		t.Fatalf("unexpected metrics after release: %+v", metrics)
+	}
+	if metrics.QueueTimeMaxMs <= 0 || metrics.QueueTimeP99Ms <= 0 || metrics.QueueTimeP99Ms > metrics.QueueTimeMaxMs {
+		t.Fatalf("unexpected queue time metrics: %+v", metrics)
+	}
+}
+
+func TestHashWorkerPoolWithoutQueue(t *testing.T) {
+	pool := newHashWorkerPool(1, 0)
+	release := blockPool(t, pool)
+	defer release()
+
+	if err := pool.do(func() {}); !errors.Is(err, ErrPasswordHashBusy) {
+		t.Fatalf("err = %v, want ErrPasswordHashBusy", err)
+	}
+}
+
+// HashPassword dan VerifyPassword mengembalikan ErrPasswordHashBusy, bukan password salah
+func TestPasswordHashBusy(t *testing.T) {
+	restorePasswordHasher(t)
+
+	hash, err := HashPassword("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pool := newHashWorkerPool(1, 1)
+	passwordHasher.mu.Lock()
+	passwordHasher.pool = pool
+	passwordHasher.mu.Unlock()
+
+	release := blockPool(t, pool)
+	defer release()
+
+	if _, err = HashPassword("correct horse"); !errors.Is(err, ErrPasswordHashBusy) {
+		t.Fatalf("HashPassword err = %v, want ErrPasswordHashBusy", err)
+	}
+	if err = VerifyPassword(hash, "correct horse"); !errors.Is(err, ErrPasswordHashBusy) {
+		t.Fatalf("VerifyPassword err = %v, want ErrPasswordHashBusy", err)
+	}
+	if metrics := GetPasswordHashMetrics(); metrics.Rejected != 2 {
+		t.Fatalf("rejected = %d, want 2", metrics.Rejected)
+	}
+}
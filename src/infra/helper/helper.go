@@ -1,13 +1,14 @@
 package helper
 
 import (
-	"bytes"
+	"context"
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/rand"
 	"crypto/sha256"
 	"database/sql"
 	"encoding/base64"
+	"encoding/binary"
 	"encoding/hex"
 	"errors"
 	"fmt"
@@ -15,80 +16,209 @@ import (
 	"go-auth-service/src/infra/constants/common"
 	errorMessage "go-auth-service/src/infra/constants/error_message"
 	"go-auth-service/src/infra/models"
-	"golang.org/x/crypto/bcrypt"
 	"io"
 	"mime/multipart"
 	"net/http"
 	"net/smtp"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 )
 
-var jwtKey = []byte(common.JwtKey)
-var jwtRefreshKey = []byte(common.JwtRefreshKey)
-var key = []byte(common.EncryptKey)
-
-func HashPassword(password string) (string, error) {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	if err != nil {
-		return "", err
-	}
-	return string(hashedPassword), nil
-}
-
-func VerifyPassword(hashedPassword, inputPassword string) error {
-	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(inputPassword))
+// TokenClaims menyimpan klaim JWT untuk akses token. SubjectType membedakan token
+// milik user (sub = user id) dan token milik client/service (sub = client_id).
+// IssuedAtMs adalah iat dalam milidetik, dipakai untuk membandingkan dengan waktu
+// RevokeUserTokens karena iat hanya beresolusi detik.
+type TokenClaims struct {
+	UserID      int64  `json:"user_id,omitempty"`
+	Email       string `json:"email,omitempty"`
+	Scope       string `json:"scope,omitempty"`
+	SubjectType string `json:"sub_type"`
+	ClientID    string `json:"client_id,omitempty"`
+	SessionID   string `json:"sid,omitempty"`
+	Verified    bool   `json:"verified,omitempty"`
+	IssuedAtMs  int64  `json:"iat_ms,omitempty"`
+	jwt.StandardClaims
 }
 
-// TokenClaims menyimpan klaim JWT untuk akses token
-type TokenClaims struct {
-	UserID int64  `json:"user_id"`
-	Email  string `json:"email"`
+// IDTokenClaims menyimpan klaim ID token OpenID Connect
+type IDTokenClaims struct {
+	Nonce         string `json:"nonce,omitempty"`
+	AuthTime      int64  `json:"auth_time,omitempty"`
+	Email         string `json:"email,omitempty"`
+	EmailVerified *bool  `json:"email_verified,omitempty"`
 	jwt.StandardClaims
 }
 
 // RefreshTokenClaims menyimpan klaim JWT untuk refresh token
 type RefreshTokenClaims struct {
-	UserID int64  `json:"user_id"`
-	Email  string `json:"email"`
+	UserID    int64  `json:"user_id"`
+	Email     string `json:"email"`
+	SessionID string `json:"sid,omitempty"`
 	jwt.StandardClaims
 }
 
-// GenerateToken membuat token JWT
-func GenerateToken(data *models.User) (string, error) {
-	expirationTime := time.Now().Add(common.AccessTokenExp)
+// GenerateToken membuat token JWT yang ditandatangani dengan signing key active
+func GenerateToken(data *models.User, sessionId string) (string, error) {
+	return GenerateScopedToken(data, sessionId, "", "")
+}
+
+// GenerateScopedToken membuat token JWT untuk client OAuth dengan audience dan scope tertentu
+func GenerateScopedToken(data *models.User, sessionId, audience, scope string) (string, error) {
+	jti, err := RandomToken(16)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
 	claims := &TokenClaims{
-		UserID: data.Id,
-		Email:  data.Email,
+		UserID:      data.Id,
+		Email:       data.Email,
+		Scope:       scope,
+		SubjectType: common.SubjectTypeUser,
+		ClientID:    audience,
+		SessionID:   sessionId,
+		Verified:    data.Verified,
+		IssuedAtMs:  now.UnixMilli(),
 		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: expirationTime.Unix(),
+			Id:        jti,
+			Issuer:    TokenIssuer(),
+			Subject:   strconv.FormatInt(data.Id, 10),
+			Audience:  audience,
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(common.AccessTokenExp).Unix(),
 		},
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtKey)
+	return signToken(claims)
+}
+
+// GenerateClientToken membuat access token untuk client credentials grant (service-to-service)
+func GenerateClientToken(clientId, scope string) (string, error) {
+	jti, err := RandomToken(16)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	claims := &TokenClaims{
+		Scope:       scope,
+		SubjectType: common.SubjectTypeClient,
+		ClientID:    clientId,
+		StandardClaims: jwt.StandardClaims{
+			Id:        jti,
+			Issuer:    TokenIssuer(),
+			Subject:   clientId,
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(common.ClientTokenExp).Unix(),
+		},
+	}
+	return signToken(claims)
+}
+
+// GenerateIDToken membuat ID token OpenID Connect untuk client (audience) tertentu
+func GenerateIDToken(userId int64, audience string, claims *IDTokenClaims) (string, error) {
+	now := time.Now()
+	claims.Issuer = TokenIssuer()
+	claims.Subject = strconv.FormatInt(userId, 10)
+	claims.Audience = audience
+	claims.IssuedAt = now.Unix()
+	claims.ExpiresAt = now.Add(common.IDTokenExp).Unix()
+	return signToken(claims)
 }
 
-// GenerateRefreshToken membuat refresh token JWT
-func GenerateRefreshToken(data *models.User) (string, error) {
+// GenerateRefreshToken membuat refresh token JWT untuk sesi tertentu
+func GenerateRefreshToken(data *models.User, sessionId string) (string, error) {
+	jti, err := RandomToken(16)
+	if err != nil {
+		return "", err
+	}
+
 	expirationTime := time.Now().Add(common.RefreshTokenExp)
 	claims := &RefreshTokenClaims{
-		UserID: data.Id,
-		Email:  data.Email,
+		UserID:    data.Id,
+		Email:     data.Email,
+		SessionID: sessionId,
 		StandardClaims: jwt.StandardClaims{
+			Id:        jti,
 			ExpiresAt: expirationTime.Unix(),
 		},
 	}
+
+	refreshKey, err := refreshTokenKeys.current()
+	if err != nil {
+		return "", err
+	}
+
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtRefreshKey)
+	token.Header["kid"] = refreshKey.Kid
+	return token.SignedString(refreshKey.Value)
 }
 
-// VerifyToken memverifikasi token JWT
+// VerifyToken memverifikasi access token first-party milik user. Token client credentials dan
+// token yang diterbitkan untuk client OAuth (memiliki audience) ditolak, agar endpoint user tidak
+// dapat diakses dengan identitas service maupun dengan token milik aplikasi pihak ketiga.
 func VerifyToken(tokenString string) (*TokenClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &TokenClaims{}, func(token *jwt.Token) (interface{}, error) {
-		return jwtKey, nil
-	})
+	claims, err := VerifyAccessToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.SubjectType != common.SubjectTypeUser || claims.ClientID != "" || claims.Audience != "" {
+		return nil, fmt.Errorf(errorMessage.InvalidToken)
+	}
+
+	return claims, nil
+}
+
+// VerifyOAuthToken memverifikasi access token user yang diterbitkan untuk client OAuth.
+// Token ini hanya berlaku untuk /oauth/userinfo, dengan klaim sesuai scope-nya.
+func VerifyOAuthToken(tokenString string) (*TokenClaims, error) {
+	claims, err := VerifyAccessToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.SubjectType != common.SubjectTypeUser || claims.ClientID == "" || claims.Audience != claims.ClientID {
+		return nil, fmt.Errorf(errorMessage.InvalidToken)
+	}
+
+	return claims, nil
+}
+
+type requestClaimsKey struct{}
+
+type requestClaims struct {
+	claims *TokenClaims
+	err    error
+}
+
+// WithRequestClaims memverifikasi access token pada header Authorization satu kali dan menyimpan
+// hasilnya di context request, sehingga middleware dan handler berikutnya tidak mengulang
+// verifikasi dan pengecekan denylist
+func WithRequestClaims(r *http.Request) *http.Request {
+	if _, ok := r.Context().Value(requestClaimsKey{}).(*requestClaims); ok {
+		return r
+	}
+
+	claims, err := VerifyToken(r.Header.Get("Authorization"))
+	return r.WithContext(context.WithValue(r.Context(), requestClaimsKey{}, &requestClaims{claims: claims, err: err}))
+}
+
+// RequestClaims mengembalikan hasil WithRequestClaims, atau memverifikasi token jika belum ada
+func RequestClaims(r *http.Request) (*TokenClaims, error) {
+	if cached, ok := r.Context().Value(requestClaimsKey{}).(*requestClaims); ok {
+		return cached.claims, cached.err
+	}
+
+	return VerifyToken(r.Header.Get("Authorization"))
+}
+
+// VerifyAccessToken memverifikasi access token berdasarkan kid pada header,
+// baik milik user maupun milik client, lalu memeriksa denylist di Redis
+func VerifyAccessToken(tokenString string) (*TokenClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &TokenClaims{}, verificationKey)
 
 	if err != nil {
 		var ve *jwt.ValidationError
@@ -105,13 +235,28 @@ func VerifyToken(tokenString string) (*TokenClaims, error) {
 		return nil, fmt.Errorf(errorMessage.InvalidToken)
 	}
 
+	revoked, err := isAccessTokenRevoked(claims)
+	if err != nil || revoked {
+		return nil, fmt.Errorf(errorMessage.RevokedToken)
+	}
+
 	return claims, nil
 }
 
-// VerifyRefreshToken memverifikasi refresh token JWT
+// VerifyRefreshToken memverifikasi refresh token JWT dengan versi secret sesuai header kid
 func VerifyRefreshToken(tokenString string) (*RefreshTokenClaims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &RefreshTokenClaims{}, func(token *jwt.Token) (interface{}, error) {
-		return jwtRefreshKey, nil
+		if token.Method != jwt.SigningMethodHS256 {
+			return nil, errors.New(errorMessage.InvalidToken)
+		}
+
+		kid, _ := token.Header["kid"].(string)
+		refreshKey, err := refreshTokenKeys.lookup(kid)
+		if err != nil {
+			return nil, err
+		}
+
+		return refreshKey.Value, nil
 	})
 
 	if err != nil {
@@ -234,70 +379,107 @@ func SendMail(to, subject, body string) error {
 	return nil
 }
 
-func pad(src []byte) []byte {
-	padding := aes.BlockSize - len(src)%aes.BlockSize
-	padtext := bytes.Repeat([]byte{byte(padding)}, padding)
-	return append(src, padtext...)
-}
+// envelopeVersion adalah versi format hasil Encrypt:
+// version(1) | len(kid)(1) | kid | expiry unix(8) | nonce(12) | ciphertext+tag
+const envelopeVersion byte = 1
 
-func unpad(src []byte) ([]byte, error) {
-	if len(src) == 0 {
-		return nil, errors.New("input to unpad is empty")
-	}
-	padding := src[len(src)-1]
-	if int(padding) > aes.BlockSize || padding == 0 {
-		return nil, errors.New("invalid padding")
+// Encrypt mengenkripsi text dengan AES-256-GCM memakai versi active dari ENCRYPT_KEYS.
+// Purpose dan header (versi, kid, expiry) menjadi associated data sehingga ciphertext
+// untuk satu keperluan ditolak di keperluan lain, dan kedaluwarsa setelah ttl.
+// ttl <= 0 dipakai untuk data yang disimpan (misalnya secret TOTP) dan tidak pernah kedaluwarsa.
+func Encrypt(purpose, text string, ttl time.Duration) (string, error) {
+	encryptKey, err := encryptKeys.current()
+	if err != nil {
+		return "", err
 	}
-	return src[:len(src)-int(padding)], nil
-}
 
-func Encrypt(text string) (string, error) {
-	block, err := aes.NewCipher(key)
+	aead, err := newAEAD(encryptKey.Value)
 	if err != nil {
 		return "", err
 	}
 
-	paddedText := pad([]byte(text))
-	ciphertext := make([]byte, aes.BlockSize+len(paddedText))
-	iv := ciphertext[:aes.BlockSize]
-	if _, err := io.ReadFull(rand.Reader, iv); err != nil {
+	header := make([]byte, 0, 2+len(encryptKey.Kid)+8)
+	header = append(header, envelopeVersion, byte(len(encryptKey.Kid)))
+	header = append(header, encryptKey.Kid...)
+	var expiry int64
+	if ttl > 0 {
+		expiry = time.Now().Add(ttl).Unix()
+	}
+	header = binary.BigEndian.AppendUint64(header, uint64(expiry))
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
 		return "", err
 	}
 
-	mode := cipher.NewCBCEncrypter(block, iv)
-	mode.CryptBlocks(ciphertext[aes.BlockSize:], paddedText)
+	envelope := append(header, nonce...)
+	envelope = aead.Seal(envelope, nonce, []byte(text), envelopeAdditionalData(header, purpose))
 
-	encoded := base64.RawURLEncoding.EncodeToString(ciphertext)
-	return encoded, nil
+	return base64.RawURLEncoding.EncodeToString(envelope), nil
 }
 
-func Decrypt(encodedText string) (string, error) {
-	ciphertext, err := base64.RawURLEncoding.DecodeString(encodedText)
+// Decrypt membuka hasil Encrypt untuk purpose yang sama. Seluruh kegagalan format,
+// kid, purpose maupun autentikasi menghasilkan error yang sama.
+func Decrypt(purpose, encodedText string) (string, error) {
+	invalid := errors.New(errorMessage.InvalidEncryptedData)
+
+	envelope, err := base64.RawURLEncoding.DecodeString(encodedText)
+	if err != nil || len(envelope) < 2 || envelope[0] != envelopeVersion {
+		return "", invalid
+	}
+
+	headerLen := 2 + int(envelope[1]) + 8
+	if len(envelope) < headerLen {
+		return "", invalid
+	}
+
+	header := envelope[:headerLen]
+	kid := string(header[2 : headerLen-8])
+	expiry := int64(binary.BigEndian.Uint64(header[headerLen-8:]))
+
+	encryptKey, err := encryptKeys.lookup(kid)
 	if err != nil {
-		return "", err
+		return "", invalid
 	}
 
-	if len(ciphertext) < aes.BlockSize {
-		return "", errors.New("ciphertext too short")
+	aead, err := newAEAD(encryptKey.Value)
+	if err != nil {
+		return "", invalid
 	}
 
-	block, err := aes.NewCipher(key)
+	if len(envelope) < headerLen+aead.NonceSize() {
+		return "", invalid
+	}
+
+	nonce := envelope[headerLen : headerLen+aead.NonceSize()]
+	ciphertext := envelope[headerLen+aead.NonceSize():]
+
+	plaintext, err := aead.Open(nil, nonce, ciphertext, envelopeAdditionalData(header, purpose))
 	if err != nil {
-		return "", err
+		return "", invalid
 	}
 
-	iv := ciphertext[:aes.BlockSize]
-	ciphertext = ciphertext[aes.BlockSize:]
-	mode := cipher.NewCBCDecrypter(block, iv)
+	// expiry ikut diautentikasi, jadi hanya dicek setelah ciphertext terbukti asli
+	if expiry != 0 && time.Now().Unix() > expiry {
+		return "", errors.New(errorMessage.ExpiredToken)
+	}
 
-	mode.CryptBlocks(ciphertext, ciphertext)
+	return string(plaintext), nil
+}
 
-	unpaddedText, err := unpad(ciphertext)
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	return string(unpaddedText), nil
+	return cipher.NewGCM(block)
+}
+
+func envelopeAdditionalData(header []byte, purpose string) []byte {
+	data := make([]byte, 0, len(header)+len(purpose))
+	data = append(data, header...)
+	return append(data, purpose...)
 }
 
 func GetRealIP(r *http.Request) string {
@@ -320,6 +502,41 @@ func GetRealIP(r *http.Request) string {
 	return ip
 }
 
+// RandomToken membuat string acak base64url dari n byte
+func RandomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := io.ReadFull(rand.Reader, b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// RandomDigits membuat string acak berisi n digit, dipakai untuk OTP
+func RandomDigits(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := io.ReadFull(rand.Reader, b); err != nil {
+		return "", err
+	}
+
+	digits := make([]byte, n)
+	for i := range b {
+		// 250 habis dibagi 10, nilai di atasnya diulang agar distribusi tetap rata
+		for b[i] >= 250 {
+			if _, err := io.ReadFull(rand.Reader, b[i:i+1]); err != nil {
+				return "", err
+			}
+		}
+		digits[i] = '0' + b[i]%10
+	}
+	return string(digits), nil
+}
+
+// HashToken membuat hash sha256 (hex) untuk token yang disimpan di Redis atau database
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
 func HashRefreshToken(refreshToken string) (string, error) {
 	hash := sha256.New()
 	_, err := hash.Write([]byte(refreshToken))
@@ -333,38 +550,36 @@ func HashRefreshToken(refreshToken string) (string, error) {
 	return hashString, nil
 }
 
-func NormalizeUserAgent(userAgent string) string {
-	// Tentukan OS
-	device := "unknown"
-	if strings.Contains(userAgent, "Windows") {
+// DeviceLabel membuat label perangkat yang mudah dibaca dari User-Agent,
+// misalnya "Chrome on Windows". Label hanya untuk ditampilkan, bukan identitas sesi.
+func DeviceLabel(userAgent string) string {
+	device := "Unknown OS"
+	switch {
+	case strings.Contains(userAgent, "Android"):
+		device = "Android"
+	case strings.Contains(userAgent, "iPhone") || strings.Contains(userAgent, "iPad"):
+		device = "iOS"
+	case strings.Contains(userAgent, "Windows"):
 		device = "Windows"
-	} else if strings.Contains(userAgent, "Macintosh") {
+	case strings.Contains(userAgent, "Macintosh"):
 		device = "MacOS"
-	} else if strings.Contains(userAgent, "Linux") {
+	case strings.Contains(userAgent, "Linux"):
 		device = "Linux"
-	} else if strings.Contains(userAgent, "Android") {
-		device = "Android"
-	} else if strings.Contains(userAgent, "iPhone") || strings.Contains(userAgent, "iPad") {
-		device = "iOS"
 	}
 
-	// Tentukan Browser
-	browser := "unknown"
-	if strings.Contains(userAgent, "Chrome") {
-		browser = "Chrome"
-	} else if strings.Contains(userAgent, "Firefox") {
-		browser = "Firefox"
-	} else if strings.Contains(userAgent, "Safari") && !strings.Contains(userAgent, "Chrome") {
-		browser = "Safari"
-	} else if strings.Contains(userAgent, "Edge") {
+	browser := "Unknown Browser"
+	switch {
+	case strings.Contains(userAgent, "Edg"):
 		browser = "Edge"
-	} else if strings.Contains(userAgent, "Opera") || strings.Contains(userAgent, "OPR") {
+	case strings.Contains(userAgent, "OPR") || strings.Contains(userAgent, "Opera"):
 		browser = "Opera"
+	case strings.Contains(userAgent, "Firefox"):
+		browser = "Firefox"
+	case strings.Contains(userAgent, "Chrome"):
+		browser = "Chrome"
+	case strings.Contains(userAgent, "Safari"):
+		browser = "Safari"
 	}
 
-	// Gabungkan dan format key
-	deviceKey := strings.ToLower(device + "_" + browser)
-
-	// Encode agar aman di Redis
-	return deviceKey
+	return browser + " on " + device
 }
@@ -0,0 +1,260 @@
+package helper
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"strings"
+	"testing"
+	"time"
+
+	"go-auth-service/src/infra/config"
+	"go-auth-service/src/infra/constants/common"
+	errorMessage "go-auth-service/src/infra/constants/error_message"
+	"go-auth-service/src/infra/models"
+)
+
+// purpose tambahan untuk test, selain purpose yang dipakai aplikasi
+const (
+	testPurposeStored = "test-stored"
+	testPurposeOther  = "test-other"
+	testPurposeLink   = "test-link"
+)
+
+// loadTestEncryptKeys memuat ENCRYPT_KEYS dari env dengan status per kid
+func loadTestEncryptKeys(t *testing.T, statuses map[string]string) {
+	t.Helper()
+
+	confs := make([]config.SecretConf, 0, len(statuses))
+	for kid, status := range statuses {
+		confs = append(confs, config.SecretConf{Kid: kid, Status: status, Source: "env:TEST_ENCRYPT_KEY_" + kid})
+	}
+
+	if err := encryptKeys.load(confs, false); err != nil {
+		t.Fatalf("load encrypt keys: %v", err)
+	}
+}
+
+func setTestEncryptKey(t *testing.T, kid string) {
+	t.Helper()
+
+	value := make([]byte, 32)
+	if _, err := rand.Read(value); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TEST_ENCRYPT_KEY_"+kid, "base64:"+base64.StdEncoding.EncodeToString(value))
+}
+
+// sealTestEnvelope membuat envelope dengan expiry tertentu, termasuk yang sudah lewat
+func sealTestEnvelope(t *testing.T, kid, purpose, text string, expiry int64) string {
+	t.Helper()
+
+	key, err := encryptKeys.lookup(kid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	aead, err := newAEAD(key.Value)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	header := append([]byte{envelopeVersion, byte(len(kid))}, kid...)
+	header = binary.BigEndian.AppendUint64(header, uint64(expiry))
+	nonce := make([]byte, aead.NonceSize())
+	envelope := append(append([]byte(nil), header...), nonce...)
+	envelope = aead.Seal(envelope, nonce, []byte(text), envelopeAdditionalData(header, purpose))
+
+	return base64.RawURLEncoding.EncodeToString(envelope)
+}
+
+// tamperEnvelope membalik satu bit pada byte ke-i envelope
+func tamperEnvelope(t *testing.T, encoded string, i int) string {
+	t.Helper()
+
+	envelope, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if i < 0 {
+		i += len(envelope)
+	}
+	envelope[i] ^= 0x01
+
+	return base64.RawURLEncoding.EncodeToString(envelope)
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	setTestEncryptKey(t, "k1")
+	setTestEncryptKey(t, "k2")
+	loadTestEncryptKeys(t, map[string]string{"k1": common.KeyActive, "k2": common.KeyVerify})
+
+	const plaintext = `{"session_id":"abc"}`
+
+	valid, err := Encrypt(common.PurposeRevokeLink, plaintext, time.Hour)
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+
+	// offset header: version(1) | len(kid)(1) | kid(2) | expiry(8) | nonce(12)
+	const (
+		kidOffset    = 2
+		expiryOffset = 4
+		nonceOffset  = 12
+	)
+
+	past := time.Now().Add(-time.Minute).Unix()
+	future := time.Now().Add(time.Hour).Unix()
+
+	tests := []struct {
+		name      string
+		purpose   string
+		encrypted string
+		want      string
+		wantErr   string
+	}{
+		{name: "valid", purpose: common.PurposeRevokeLink, encrypted: valid, want: plaintext},
+		{name: "verify key", purpose: common.PurposeRevokeLink, encrypted: sealTestEnvelope(t, "k2", common.PurposeRevokeLink, plaintext, future), want: plaintext},
+		{name: "no expiry", purpose: testPurposeStored, encrypted: sealTestEnvelope(t, "k1", testPurposeStored, plaintext, 0), want: plaintext},
+		{name: "wrong purpose", purpose: testPurposeOther, encrypted: valid, wantErr: errorMessage.InvalidEncryptedData},
+		{name: "empty purpose", purpose: "", encrypted: valid, wantErr: errorMessage.InvalidEncryptedData},
+		{name: "expired", purpose: common.PurposeRevokeLink, encrypted: sealTestEnvelope(t, "k1", common.PurposeRevokeLink, plaintext, past), wantErr: errorMessage.ExpiredToken},
+		{name: "expired with wrong purpose", purpose: testPurposeLink, encrypted: sealTestEnvelope(t, "k1", common.PurposeRevokeLink, plaintext, past), wantErr: errorMessage.InvalidEncryptedData},
+		{name: "tampered version", purpose: common.PurposeRevokeLink, encrypted: tamperEnvelope(t, valid, 0), wantErr: errorMessage.InvalidEncryptedData},
+		{name: "tampered kid", purpose: common.PurposeRevokeLink, encrypted: tamperEnvelope(t, valid, kidOffset+1), wantErr: errorMessage.InvalidEncryptedData},
+		{name: "tampered expiry", purpose: common.PurposeRevokeLink, encrypted: tamperEnvelope(t, valid, expiryOffset+7), wantErr: errorMessage.InvalidEncryptedData},
+		{name: "extended expiry of expired data", purpose: common.PurposeRevokeLink, encrypted: tamperEnvelope(t, sealTestEnvelope(t, "k1", common.PurposeRevokeLink, plaintext, past), expiryOffset+4), wantErr: errorMessage.InvalidEncryptedData},
+		{name: "tampered nonce", purpose: common.PurposeRevokeLink, encrypted: tamperEnvelope(t, valid, nonceOffset), wantErr: errorMessage.InvalidEncryptedData},
+		{name: "tampered ciphertext", purpose: common.PurposeRevokeLink, encrypted: tamperEnvelope(t, valid, nonceOffset+12+1), wantErr: errorMessage.InvalidEncryptedData},
+		{name: "tampered tag", purpose: common.PurposeRevokeLink, encrypted: tamperEnvelope(t, valid, -1), wantErr: errorMessage.InvalidEncryptedData},
+		{name: "not base64url", purpose: common.PurposeRevokeLink, encrypted: valid + "*", wantErr: errorMessage.InvalidEncryptedData},
+		{name: "empty", purpose: common.PurposeRevokeLink, encrypted: "", wantErr: errorMessage.InvalidEncryptedData},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Decrypt(tt.purpose, tt.encrypted)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("plaintext = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// Setiap potongan envelope harus ditolak tanpa panic
+func TestDecryptTruncated(t *testing.T) {
+	setTestEncryptKey(t, "k1")
+	loadTestEncryptKeys(t, map[string]string{"k1": common.KeyActive})
+
+	encrypted, err := Encrypt(testPurposeLink, "payload", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	envelope, _ := base64.RawURLEncoding.DecodeString(encrypted)
+	for i := 0; i < len(envelope); i++ {
+		if _, err = Decrypt(testPurposeLink, base64.RawURLEncoding.EncodeToString(envelope[:i])); err == nil {
+			t.Fatalf("envelope truncated to %d bytes was accepted", i)
+		}
+	}
+}
+
+// Ciphertext dari kid yang sudah retired tidak bisa dibuka lagi
+func TestDecryptRetiredKey(t *testing.T) {
+	setTestEncryptKey(t, "k1")
+	setTestEncryptKey(t, "k2")
+	loadTestEncryptKeys(t, map[string]string{"k1": common.KeyActive})
+
+	encrypted, err := Encrypt(testPurposeOther, "payload", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loadTestEncryptKeys(t, map[string]string{"k1": common.KeyVerify, "k2": common.KeyActive})
+	if _, err = Decrypt(testPurposeOther, encrypted); err != nil {
+		t.Fatalf("verify key rejected: %v", err)
+	}
+
+	loadTestEncryptKeys(t, map[string]string{"k1": common.KeyRetired, "k2": common.KeyActive})
+	if _, err = Decrypt(testPurposeOther, encrypted); err == nil || err.Error() != errorMessage.InvalidEncryptedData {
+		t.Fatalf("err = %v, want %q", err, errorMessage.InvalidEncryptedData)
+	}
+}
+
+func TestSecretRingEmpty(t *testing.T) {
+	ring := &secretRing{name: "ENCRYPT_KEYS", validate: exactLength(32), keys: map[string]*secret{}}
+
+	if err := ring.load(nil, false); err == nil || !strings.Contains(err.Error(), "ENCRYPT_KEYS is empty") {
+		t.Fatalf("err = %v, want refusal of empty secrets", err)
+	}
+	if ring.active != nil {
+		t.Fatal("active secret set after a refused load")
+	}
+
+	if err := ring.load(nil, true); err != nil {
+		t.Fatalf("ephemeral load: %v", err)
+	}
+	if ring.active == nil || len(ring.active.Value) != 32 {
+		t.Fatal("no ephemeral secret generated")
+	}
+}
+
+// Token first-party, token client OAuth dan token client credentials hanya diterima oleh verifier-nya masing-masing
+func TestVerifyTokenAudience(t *testing.T) {
+	if err := LoadSigningKeys(config.JwtConf{Issuer: "https://auth.example.com"}); err != nil {
+		t.Fatal(err)
+	}
+
+	users := &models.User{Id: 7, Email: "user@mail.com"}
+
+	firstParty, err := GenerateToken(users, "session-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	oauthToken, err := GenerateScopedToken(users, "session-1", "web-portal", "openid email")
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientToken, err := GenerateClientToken("billing-service", "users.read")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name           string
+		token          string
+		wantFirstParty bool
+		wantOAuth      bool
+	}{
+		{name: "first-party token", token: firstParty, wantFirstParty: true},
+		{name: "oauth client token", token: oauthToken, wantOAuth: true},
+		{name: "client credentials token", token: clientToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := VerifyToken(tt.token)
+			if (err == nil) != tt.wantFirstParty {
+				t.Fatalf("VerifyToken err = %v, want accepted = %v", err, tt.wantFirstParty)
+			}
+
+			claims, err := VerifyOAuthToken(tt.token)
+			if (err == nil) != tt.wantOAuth {
+				t.Fatalf("VerifyOAuthToken err = %v, want accepted = %v", err, tt.wantOAuth)
+			}
+			if tt.wantOAuth && (claims.ClientID != "web-portal" || claims.Scope != "openid email") {
+				t.Fatalf("unexpected oauth claims %+v", claims)
+			}
+		})
+	}
+}
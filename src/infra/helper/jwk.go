@@ -0,0 +1,311 @@
+package helper
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/golang-jwt/jwt"
+	"go-auth-service/src/infra/config"
+	"go-auth-service/src/infra/constants/common"
+	errorMessage "go-auth-service/src/infra/constants/error_message"
+)
+
+// signingKey menyimpan satu key JWT beserta statusnya
+type signingKey struct {
+	Kid     string
+	Status  string
+	Method  jwt.SigningMethod
+	Private crypto.PrivateKey
+	Public  crypto.PublicKey
+}
+
+type keySet struct {
+	mu     sync.RWMutex
+	issuer string
+	active *signingKey
+	keys   map[string]*signingKey
+}
+
+var signingKeys = &keySet{keys: map[string]*signingKey{}}
+
+// JWK adalah representasi public key sesuai RFC 7517
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// LoadSigningKeys memuat key JWT dari konfigurasi. Tepat satu key harus berstatus active,
+// key verify hanya dipakai untuk verifikasi dan key retired selalu ditolak.
+// Jika tidak ada key yang dikonfigurasi, key sementara dibuat untuk development.
+func LoadSigningKeys(conf config.JwtConf) error {
+	set := map[string]*signingKey{}
+	var active *signingKey
+
+	if len(conf.Keys) == 0 {
+		key, err := generateEphemeralKey()
+		if err != nil {
+			return err
+		}
+		log.Printf("JWT_SIGNING_KEYS is empty, using ephemeral signing key %s", key.Kid)
+		set[key.Kid] = key
+		active = key
+	}
+
+	for _, keyConf := range conf.Keys {
+		if keyConf.Status != common.KeyActive && keyConf.Status != common.KeyVerify && keyConf.Status != common.KeyRetired {
+			return fmt.Errorf("%s: %s", errorMessage.InvalidSigningKeyStatus, keyConf.Kid)
+		}
+
+		if _, ok := set[keyConf.Kid]; ok {
+			return fmt.Errorf("duplicate signing key id: %s", keyConf.Kid)
+		}
+
+		key := &signingKey{Kid: keyConf.Kid, Status: keyConf.Status}
+		if keyConf.Status != common.KeyRetired {
+			raw, err := os.ReadFile(keyConf.Path)
+			if err != nil {
+				return err
+			}
+
+			if err = key.parsePEM(raw); err != nil {
+				return fmt.Errorf("signing key %s: %v", keyConf.Kid, err)
+			}
+		}
+
+		if keyConf.Status == common.KeyActive {
+			if active != nil {
+				return fmt.Errorf("more than one active signing key: %s, %s", active.Kid, key.Kid)
+			}
+			if key.Private == nil {
+				return fmt.Errorf("active signing key %s has no private key", key.Kid)
+			}
+			active = key
+		}
+
+		set[key.Kid] = key
+	}
+
+	if active == nil {
+		return errors.New(errorMessage.NoActiveSigningKey)
+	}
+
+	signingKeys.mu.Lock()
+	defer signingKeys.mu.Unlock()
+
+	signingKeys.issuer = conf.Issuer
+	signingKeys.active = active
+	signingKeys.keys = set
+
+	return nil
+}
+
+// parsePEM membaca private key (PKCS#1, PKCS#8, SEC 1) atau public key (PKIX).
+// Key verify boleh hanya berisi public key.
+func (k *signingKey) parsePEM(raw []byte) error {
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return errors.New("invalid PEM data")
+	}
+
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		parsed, err = x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return fmt.Errorf("%s: %s", errorMessage.UnsupportedSigningKey, block.Type)
+	}
+	if err != nil {
+		return err
+	}
+
+	switch key := parsed.(type) {
+	case *rsa.PrivateKey:
+		k.Private, k.Public = key, &key.PublicKey
+	case *ecdsa.PrivateKey:
+		k.Private, k.Public = key, &key.PublicKey
+	case ed25519.PrivateKey:
+		k.Private, k.Public = key, key.Public()
+	case *rsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey:
+		k.Public = key
+	default:
+		return errors.New(errorMessage.UnsupportedSigningKey)
+	}
+
+	k.Method, err = signingMethodFor(k.Public)
+	return err
+}
+
+func signingMethodFor(public crypto.PublicKey) (jwt.SigningMethod, error) {
+	switch key := public.(type) {
+	case *rsa.PublicKey:
+		return jwt.SigningMethodRS256, nil
+	case *ecdsa.PublicKey:
+		switch key.Curve {
+		case elliptic.P256():
+			return jwt.SigningMethodES256, nil
+		case elliptic.P384():
+			return jwt.SigningMethodES384, nil
+		case elliptic.P521():
+			return jwt.SigningMethodES512, nil
+		}
+	case ed25519.PublicKey:
+		return jwt.SigningMethodEdDSA, nil
+	}
+	return nil, errors.New(errorMessage.UnsupportedSigningKey)
+}
+
+func generateEphemeralKey() (*signingKey, error) {
+	private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	kid := make([]byte, 8)
+	if _, err = rand.Read(kid); err != nil {
+		return nil, err
+	}
+
+	return &signingKey{
+		Kid:     "ephemeral-" + hex.EncodeToString(kid),
+		Status:  common.KeyActive,
+		Method:  jwt.SigningMethodES256,
+		Private: private,
+		Public:  &private.PublicKey,
+	}, nil
+}
+
+// signToken menandatangani claims dengan key active dan menambahkan header kid
+func signToken(claims jwt.Claims) (string, error) {
+	signingKeys.mu.RLock()
+	active := signingKeys.active
+	signingKeys.mu.RUnlock()
+
+	if active == nil {
+		return "", errors.New(errorMessage.NoActiveSigningKey)
+	}
+
+	token := jwt.NewWithClaims(active.Method, claims)
+	token.Header["kid"] = active.Kid
+	return token.SignedString(active.Private)
+}
+
+// verificationKey memilih public key berdasarkan header kid. Token tanpa kid,
+// dengan kid yang tidak dikenal atau retired, atau dengan alg yang tidak sesuai ditolak.
+func verificationKey(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		return nil, errors.New(errorMessage.InvalidToken)
+	}
+
+	signingKeys.mu.RLock()
+	key, ok := signingKeys.keys[kid]
+	signingKeys.mu.RUnlock()
+
+	if !ok || key.Status == common.KeyRetired {
+		return nil, errors.New(errorMessage.InvalidToken)
+	}
+
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, errors.New(errorMessage.InvalidToken)
+	}
+
+	return key.Public, nil
+}
+
+// TokenIssuer mengembalikan issuer (iss) yang dipakai pada access token
+func TokenIssuer() string {
+	signingKeys.mu.RLock()
+	defer signingKeys.mu.RUnlock()
+	return signingKeys.issuer
+}
+
+// SigningAlgorithms mengembalikan daftar alg dari key yang masih dipercaya
+func SigningAlgorithms() []string {
+	signingKeys.mu.RLock()
+	defer signingKeys.mu.RUnlock()
+
+	seen := map[string]bool{}
+	var algs []string
+	for _, key := range signingKeys.keys {
+		if key.Status == common.KeyRetired || seen[key.Method.Alg()] {
+			continue
+		}
+		seen[key.Method.Alg()] = true
+		algs = append(algs, key.Method.Alg())
+	}
+	sort.Strings(algs)
+
+	return algs
+}
+
+// JWKS mengembalikan public key active dan verify untuk endpoint /.well-known/jwks.json
+func JWKS() JWKSet {
+	signingKeys.mu.RLock()
+	defer signingKeys.mu.RUnlock()
+
+	set := JWKSet{Keys: []JWK{}}
+	for _, key := range signingKeys.keys {
+		if key.Status == common.KeyRetired {
+			continue
+		}
+
+		jwk := JWK{Kid: key.Kid, Use: "sig", Alg: key.Method.Alg()}
+		switch public := key.Public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case *ecdsa.PublicKey:
+			size := (public.Curve.Params().BitSize + 7) / 8
+			jwk.Kty = "EC"
+			jwk.Crv = public.Curve.Params().Name
+			jwk.X = base64.RawURLEncoding.EncodeToString(public.X.FillBytes(make([]byte, size)))
+			jwk.Y = base64.RawURLEncoding.EncodeToString(public.Y.FillBytes(make([]byte, size)))
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		}
+
+		set.Keys = append(set.Keys, jwk)
+	}
+
+	sort.Slice(set.Keys, func(i, j int) bool {
+		return set.Keys[i].Kid < set.Keys[j].Kid
+	})
+
+	return set
+}
@@ -0,0 +1,284 @@
+package helper
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"go-auth-service/src/infra/config"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var errPasswordMismatch = errors.New("password does not match")
+
+// PasswordHasher membuat dan memverifikasi hash password dalam format PHC
+// ($argon2id$v=19$m=...,t=...,p=...$salt$hash) atau format bcrypt ($2a$cost$...).
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	// Match bernilai true jika hash dibuat oleh algoritma ini
+	Match(hash string) bool
+	Verify(hash, password string) error
+	// NeedsRehash bernilai true jika hash dibuat dengan parameter yang berbeda dari konfigurasi
+	NeedsRehash(hash string) bool
+}
+
+type Argon2idHasher struct {
+	Memory      uint32
+	Time        uint32
+	Parallelism uint8
+	SaltLength  int
+	KeyLength   uint32
+}
+
+type argon2idParams struct {
+	memory      uint32
+	time        uint32
+	parallelism uint8
+	salt        []byte
+	key         []byte
+}
+
+func (h *Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.Time, h.Memory, h.Parallelism, h.KeyLength)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, h.Memory, h.Time, h.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+func (h *Argon2idHasher) Match(hash string) bool {
+	return strings.HasPrefix(hash, "$argon2id$")
+}
+
+func (h *Argon2idHasher) Verify(hash, password string) error {
+	params, err := parseArgon2id(hash)
+	if err != nil {
+		return err
+	}
+
+	key := argon2.IDKey([]byte(password), params.salt, params.time, params.memory, params.parallelism, uint32(len(params.key)))
+	if subtle.ConstantTimeCompare(key, params.key) != 1 {
+		return errPasswordMismatch
+	}
+
+	return nil
+}
+
+func (h *Argon2idHasher) NeedsRehash(hash string) bool {
+	params, err := parseArgon2id(hash)
+	if err != nil {
+		return true
+	}
+
+	return params.memory != h.Memory || params.time != h.Time || params.parallelism != h.Parallelism ||
+		len(params.salt) != h.SaltLength || uint32(len(params.key)) != h.KeyLength
+}
+
+func parseArgon2id(hash string) (*argon2idParams, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return nil, errors.New("invalid argon2id hash")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, errors.New("unsupported argon2id version")
+	}
+
+	params := &argon2idParams{}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memory, &params.time, &params.parallelism); err != nil {
+		return nil, errors.New("invalid argon2id parameters")
+	}
+
+	var err error
+	if params.salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return nil, errors.New("invalid argon2id salt")
+	}
+	if params.key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil || len(params.key) == 0 {
+		return nil, errors.New("invalid argon2id hash")
+	}
+
+	return params, nil
+}
+
+// BcryptHasher dipertahankan untuk hash lama. bcrypt hanya menerima input 72 byte.
+type BcryptHasher struct {
+	Cost int
+}
+
+func (h *BcryptHasher) Hash(password string) (string, error) {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), h.Cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hashedPassword), nil
+}
+
+func (h *BcryptHasher) Match(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$")
+}
+
+func (h *BcryptHasher) Verify(hash, password string) error {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+}
+
+func (h *BcryptHasher) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost != h.Cost
+}
+
+type passwordHashers struct {
+	mu      sync.RWMutex
+	current PasswordHasher
+	all     []PasswordHasher
+	// pool nil berarti hashing berjalan langsung di goroutine pemanggil (sebelum LoadPasswordHasher)
+	pool *hashWorkerPool
+}
+
+var passwordHasher = &passwordHashers{
+	current: &BcryptHasher{Cost: bcrypt.DefaultCost},
+	all:     []PasswordHasher{&BcryptHasher{Cost: bcrypt.DefaultCost}},
+}
+
+// LoadPasswordHasher memilih hasher untuk password baru sesuai PASSWORD_HASH_ALGORITHM dan
+// menjalankan worker pool hashing. Hash dengan algoritma lain tetap bisa diverifikasi.
+// Dipanggil sekali saat startup.
+func LoadPasswordHasher(conf config.PasswordHashConf) error {
+	argon2id := &Argon2idHasher{
+		Memory:      uint32(conf.Argon2Memory),
+		Time:        uint32(conf.Argon2Time),
+		Parallelism: uint8(conf.Argon2Parallelism),
+		SaltLength:  16,
+		KeyLength:   32,
+	}
+	bcryptHasher := &BcryptHasher{Cost: conf.BcryptCost}
+
+	var current PasswordHasher
+	switch conf.Algorithm {
+	case "argon2id":
+		if conf.Argon2Memory < 8*conf.Argon2Parallelism || conf.Argon2Time < 1 || conf.Argon2Parallelism < 1 || conf.Argon2Parallelism > 255 {
+			return errors.New("invalid PASSWORD_ARGON2_* parameters")
+		}
+		current = argon2id
+	case "bcrypt":
+		if conf.BcryptCost < bcrypt.MinCost || conf.BcryptCost > bcrypt.MaxCost {
+			return fmt.Errorf("PASSWORD_BCRYPT_COST must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+		}
+		current = bcryptHasher
+	default:
+		return fmt.Errorf("unknown PASSWORD_HASH_ALGORITHM: %s", conf.Algorithm)
+	}
+
+	if conf.Workers < 1 || conf.QueueSize < 0 {
+		return errors.New("PASSWORD_HASH_WORKERS must be at least 1 and PASSWORD_HASH_QUEUE may not be negative")
+	}
+
+	passwordHasher.mu.Lock()
+	defer passwordHasher.mu.Unlock()
+
+	passwordHasher.current = current
+	passwordHasher.all = []PasswordHasher{argon2id, bcryptHasher}
+	if passwordHasher.pool == nil {
+		passwordHasher.pool = newHashWorkerPool(conf.Workers, conf.QueueSize)
+	}
+	return nil
+}
+
+// HashPassword membuat hash password dengan hasher yang sedang dipakai. Mengembalikan
+// ErrPasswordHashBusy jika antrean worker pool penuh.
+func HashPassword(password string) (hash string, err error) {
+	passwordHasher.mu.RLock()
+	current, pool := passwordHasher.current, passwordHasher.pool
+	passwordHasher.mu.RUnlock()
+
+	if pool == nil {
+		return current.Hash(password)
+	}
+
+	if poolErr := pool.do(func() { hash, err = current.Hash(password) }); poolErr != nil {
+		return "", poolErr
+	}
+	return hash, err
+}
+
+// VerifyPassword memverifikasi password terhadap hash dengan algoritma apa pun yang didukung.
+// Mengembalikan ErrPasswordHashBusy jika antrean worker pool penuh, pemanggil harus
+// membedakannya dari password yang salah.
+func VerifyPassword(hashedPassword, inputPassword string) (err error) {
+	passwordHasher.mu.RLock()
+	all, pool := passwordHasher.all, passwordHasher.pool
+	passwordHasher.mu.RUnlock()
+
+	for _, hasher := range all {
+		if !hasher.Match(hashedPassword) {
+			continue
+		}
+
+		if pool == nil {
+			return hasher.Verify(hashedPassword, inputPassword)
+		}
+
+		if poolErr := pool.do(func() { err = hasher.Verify(hashedPassword, inputPassword) }); poolErr != nil {
+			return poolErr
+		}
+		return err
+	}
+
+	return errors.New("unsupported password hash format")
+}
+
+var dummyPassword struct {
+	once sync.Once
+	hash string
+}
+
+// VerifyDummyPassword menjalankan verifikasi terhadap hash palsu untuk email yang tidak terdaftar,
+// sehingga waktu respons login sama dengan password yang salah. Hasilnya selalu error; hanya
+// ErrPasswordHashBusy yang perlu dibedakan pemanggil.
+func VerifyDummyPassword(password string) error {
+	dummyPassword.once.Do(func() {
+		passwordHasher.mu.RLock()
+		current := passwordHasher.current
+		passwordHasher.mu.RUnlock()
+
+		dummyPassword.hash, _ = current.Hash("dummy-password")
+	})
+
+	err := VerifyPassword(dummyPassword.hash, password)
+	if errors.Is(err, ErrPasswordHashBusy) {
+		return err
+	}
+
+	return errPasswordMismatch
+}
+
+// PasswordNeedsRehash bernilai true jika hash tidak dibuat dengan algoritma dan parameter
+// yang sedang dipakai, dipanggil setelah VerifyPassword berhasil
+func PasswordNeedsRehash(hashedPassword string) bool {
+	passwordHasher.mu.RLock()
+	defer passwordHasher.mu.RUnlock()
+
+	current := passwordHasher.current
+	return !current.Match(hashedPassword) || current.NeedsRehash(hashedPassword)
+}
+
+// GetPasswordHashMetrics mengembalikan ringkasan worker pool hashing password
+func GetPasswordHashMetrics() PasswordHashMetrics {
+	passwordHasher.mu.RLock()
+	pool := passwordHasher.pool
+	passwordHasher.mu.RUnlock()
+
+	if pool == nil {
+		return PasswordHashMetrics{}
+	}
+	return pool.metrics()
+}
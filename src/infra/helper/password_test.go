@@ -0,0 +1,224 @@
+package helper
+
+import (
+	"strings"
+	"testing"
+
+	"go-auth-service/src/infra/config"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// parameter kecil agar test cepat, tidak untuk produksi
+var testArgon2id = &Argon2idHasher{Memory: 64, Time: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+// restorePasswordHasher mengembalikan hasher global setelah test selesai
+func restorePasswordHasher(t *testing.T) {
+	passwordHasher.mu.RLock()
+	current, all, pool := passwordHasher.current, passwordHasher.all, passwordHasher.pool
+	passwordHasher.mu.RUnlock()
+
+	t.Cleanup(func() {
+		passwordHasher.mu.Lock()
+		defer passwordHasher.mu.Unlock()
+		passwordHasher.current, passwordHasher.all, passwordHasher.pool = current, all, pool
+	})
+}
+
+func TestArgon2idHasher(t *testing.T) {
+	hash, err := testArgon2id.Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=64,t=1,p=1$") || len(strings.Split(hash, "$")) != 6 {
+		t.Fatalf("unexpected PHC string %q", hash)
+	}
+	if !testArgon2id.Match(hash) {
+		t.Fatal("hasher does not match its own hash")
+	}
+
+	if err = testArgon2id.Verify(hash, "correct horse"); err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if err = testArgon2id.Verify(hash, "correct horse "); err == nil {
+		t.Fatal("wrong password verified")
+	}
+
+	other, _ := testArgon2id.Hash("correct horse")
+	if other == hash {
+		t.Fatal("two hashes of the same password share a salt")
+	}
+}
+
+func TestParseArgon2idMalformed(t *testing.T) {
+	valid, err := testArgon2id.Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(valid, "$")
+
+	tests := []struct {
+		name string
+		hash string
+	}{
+		{name: "empty", hash: ""},
+		{name: "bcrypt hash", hash: "$2a$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy"},
+		{name: "other algorithm", hash: strings.Replace(valid, "$argon2id$", "$argon2i$", 1)},
+		{name: "missing part", hash: strings.Join(parts[:5], "$")},
+		{name: "extra part", hash: valid + "$extra"},
+		{name: "old version", hash: strings.Replace(valid, "$v=19$", "$v=16$", 1)},
+		{name: "missing version", hash: strings.Replace(valid, "$v=19$", "$19$", 1)},
+		{name: "invalid parameters", hash: strings.Replace(valid, "m=64,t=1,p=1", "m=64,p=1", 1)},
+		{name: "invalid salt", hash: strings.Join([]string{"", parts[1], parts[2], parts[3], "!!!", parts[5]}, "$")},
+		{name: "invalid key", hash: strings.Join([]string{"", parts[1], parts[2], parts[3], parts[4], "!!!"}, "$")},
+		{name: "empty key", hash: strings.Join([]string{"", parts[1], parts[2], parts[3], parts[4], ""}, "$")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseArgon2id(tt.hash); err == nil {
+				t.Fatal("expected parse error")
+			}
+			if err := testArgon2id.Verify(tt.hash, "correct horse"); err == nil {
+				t.Fatal("malformed hash verified")
+			}
+			if !testArgon2id.NeedsRehash(tt.hash) {
+				t.Fatal("malformed hash must need a rehash")
+			}
+		})
+	}
+}
+
+func TestArgon2idNeedsRehash(t *testing.T) {
+	hash, err := testArgon2id.Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		hasher Argon2idHasher
+		want   bool
+	}{
+		{name: "same parameters", hasher: *testArgon2id, want: false},
+		{name: "memory changed", hasher: Argon2idHasher{Memory: 128, Time: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}, want: true},
+		{name: "time changed", hasher: Argon2idHasher{Memory: 64, Time: 2, Parallelism: 1, SaltLength: 16, KeyLength: 32}, want: true},
+		{name: "parallelism changed", hasher: Argon2idHasher{Memory: 64, Time: 1, Parallelism: 2, SaltLength: 16, KeyLength: 32}, want: true},
+		{name: "salt length changed", hasher: Argon2idHasher{Memory: 64, Time: 1, Parallelism: 1, SaltLength: 32, KeyLength: 32}, want: true},
+		{name: "key length changed", hasher: Argon2idHasher{Memory: 64, Time: 1, Parallelism: 1, SaltLength: 16, KeyLength: 64}, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.hasher.NeedsRehash(hash); got != tt.want {
+				t.Fatalf("NeedsRehash = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBcryptHasherNeedsRehash(t *testing.T) {
+	hasher := &BcryptHasher{Cost: bcrypt.MinCost}
+	hash, err := hasher.Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !hasher.Match(hash) || hasher.NeedsRehash(hash) {
+		t.Fatalf("fresh bcrypt hash %q must match without rehash", hash)
+	}
+	if !(&BcryptHasher{Cost: bcrypt.MinCost + 1}).NeedsRehash(hash) {
+		t.Fatal("cost change must need a rehash")
+	}
+	if !hasher.NeedsRehash("not a hash") {
+		t.Fatal("malformed hash must need a rehash")
+	}
+}
+
+// Hash lama dengan algoritma lain tetap bisa login dan ditandai untuk rehash
+func TestPasswordHasherMigration(t *testing.T) {
+	restorePasswordHasher(t)
+
+	bcryptConf := config.PasswordHashConf{Algorithm: "bcrypt", Argon2Memory: 64, Argon2Time: 1, Argon2Parallelism: 1, BcryptCost: bcrypt.MinCost, Workers: 1, QueueSize: 4}
+	argon2Conf := bcryptConf
+	argon2Conf.Algorithm = "argon2id"
+
+	if err := LoadPasswordHasher(bcryptConf); err != nil {
+		t.Fatal(err)
+	}
+	bcryptHash, err := HashPassword("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = LoadPasswordHasher(argon2Conf); err != nil {
+		t.Fatal(err)
+	}
+	argon2Hash, err := HashPassword("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(argon2Hash, "$argon2id$") {
+		t.Fatalf("new hash %q is not argon2id", argon2Hash)
+	}
+
+	tests := []struct {
+		name       string
+		conf       config.PasswordHashConf
+		hash       string
+		wantRehash bool
+	}{
+		{name: "bcrypt hash with argon2id configured", conf: argon2Conf, hash: bcryptHash, wantRehash: true},
+		{name: "argon2id hash with argon2id configured", conf: argon2Conf, hash: argon2Hash, wantRehash: false},
+		{name: "argon2id hash with bcrypt configured", conf: bcryptConf, hash: argon2Hash, wantRehash: true},
+		{name: "bcrypt hash with bcrypt configured", conf: bcryptConf, hash: bcryptHash, wantRehash: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := LoadPasswordHasher(tt.conf); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := VerifyPassword(tt.hash, "correct horse"); err != nil {
+				t.Fatalf("verify: %v", err)
+			}
+			if err := VerifyPassword(tt.hash, "wrong horse"); err == nil {
+				t.Fatal("wrong password verified")
+			}
+			if got := PasswordNeedsRehash(tt.hash); got != tt.wantRehash {
+				t.Fatalf("PasswordNeedsRehash = %v, want %v", got, tt.wantRehash)
+			}
+		})
+	}
+
+	if err = VerifyPassword("plaintext", "plaintext"); err == nil {
+		t.Fatal("unsupported hash format verified")
+	}
+}
+
+func TestLoadPasswordHasherInvalid(t *testing.T) {
+	restorePasswordHasher(t)
+
+	tests := []struct {
+		name string
+		conf config.PasswordHashConf
+	}{
+		{name: "unknown algorithm", conf: config.PasswordHashConf{Algorithm: "md5"}},
+		{name: "argon2id memory below 8 per lane", conf: config.PasswordHashConf{Algorithm: "argon2id", Argon2Memory: 8, Argon2Time: 1, Argon2Parallelism: 2}},
+		{name: "argon2id zero time", conf: config.PasswordHashConf{Algorithm: "argon2id", Argon2Memory: 64, Argon2Time: 0, Argon2Parallelism: 1}},
+		{name: "argon2id too many lanes", conf: config.PasswordHashConf{Algorithm: "argon2id", Argon2Memory: 1 << 16, Argon2Time: 1, Argon2Parallelism: 256}},
+		{name: "bcrypt cost too low", conf: config.PasswordHashConf{Algorithm: "bcrypt", BcryptCost: bcrypt.MinCost - 1}},
+		{name: "bcrypt cost too high", conf: config.PasswordHashConf{Algorithm: "bcrypt", BcryptCost: bcrypt.MaxCost + 1}},
+		{name: "no workers", conf: config.PasswordHashConf{Algorithm: "bcrypt", BcryptCost: bcrypt.MinCost, Workers: 0, QueueSize: 4}},
+		{name: "negative queue", conf: config.PasswordHashConf{Algorithm: "bcrypt", BcryptCost: bcrypt.MinCost, Workers: 1, QueueSize: -1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := LoadPasswordHasher(tt.conf); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
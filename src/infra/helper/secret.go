@@ -0,0 +1,214 @@
+package helper
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+
+	"go-auth-service/src/infra/config"
+	"go-auth-service/src/infra/constants/common"
+	errorMessage "go-auth-service/src/infra/constants/error_message"
+)
+
+// secret menyimpan satu versi secret simetris beserta statusnya
+type secret struct {
+	Kid    string
+	Status string
+	Value  []byte
+}
+
+// secretRing menyimpan seluruh versi sebuah secret. Versi active dipakai untuk
+// enkripsi/tanda tangan baru, versi verify hanya untuk dekripsi/verifikasi.
+type secretRing struct {
+	mu       sync.RWMutex
+	name     string
+	validate func(value []byte) error
+	active   *secret
+	keys     map[string]*secret
+}
+
+var (
+	encryptKeys = &secretRing{
+		name:     "ENCRYPT_KEYS",
+		validate: exactLength(32),
+		keys:     map[string]*secret{},
+	}
+	refreshTokenKeys = &secretRing{
+		name:     "JWT_REFRESH_KEYS",
+		validate: minLength(32),
+		keys:     map[string]*secret{},
+	}
+)
+
+// kid ikut tersimpan di ciphertext dan header token sehingga harus aman untuk URL
+var secretKidRegex = regexp.MustCompile(`^[A-Za-z0-9_-]{1,32}$`)
+
+// LoadSecrets memuat secret enkripsi dan secret refresh token dari konfigurasi. Secret yang
+// kosong ditolak, kecuali ALLOW_EPHEMERAL_SECRETS=true: secret sementara dibuat per proses,
+// sehingga data dari proses lain (worker) atau sebelum restart tidak bisa dibaca.
+func LoadSecrets(conf config.SecretsConf) error {
+	if err := encryptKeys.load(conf.EncryptKeys, conf.AllowEphemeral); err != nil {
+		return err
+	}
+
+	if err := refreshTokenKeys.load(conf.RefreshTokenKeys, conf.AllowEphemeral); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *secretRing) load(confs []config.SecretConf, allowEphemeral bool) error {
+	set := map[string]*secret{}
+	var active *secret
+
+	if len(confs) == 0 {
+		if !allowEphemeral {
+			return fmt.Errorf("%s is empty; configure it (shared by the api and the worker) or set ALLOW_EPHEMERAL_SECRETS=true for a single local process", r.name)
+		}
+
+		value := make([]byte, 32)
+		kid := make([]byte, 4)
+		if _, err := rand.Read(value); err != nil {
+			return err
+		}
+		if _, err := rand.Read(kid); err != nil {
+			return err
+		}
+
+		active = &secret{Kid: "ephemeral-" + hex.EncodeToString(kid), Status: common.KeyActive, Value: value}
+		log.Printf("%s is empty, using ephemeral secret %s", r.name, active.Kid)
+		set[active.Kid] = active
+	}
+
+	for _, conf := range confs {
+		if !secretKidRegex.MatchString(conf.Kid) {
+			return fmt.Errorf("%s: %s: invalid key id %q", r.name, errorMessage.InvalidSecret, conf.Kid)
+		}
+
+		if conf.Status != common.KeyActive && conf.Status != common.KeyVerify && conf.Status != common.KeyRetired {
+			return fmt.Errorf("%s: %s: %s", r.name, errorMessage.InvalidSigningKeyStatus, conf.Kid)
+		}
+
+		if _, ok := set[conf.Kid]; ok {
+			return fmt.Errorf("%s: duplicate key id: %s", r.name, conf.Kid)
+		}
+
+		key := &secret{Kid: conf.Kid, Status: conf.Status}
+		if conf.Status != common.KeyRetired {
+			value, err := readSecret(conf.Source)
+			if err != nil {
+				return fmt.Errorf("%s: %s: %v", r.name, conf.Kid, err)
+			}
+
+			if err = r.validate(value); err != nil {
+				return fmt.Errorf("%s: %s: %v", r.name, conf.Kid, err)
+			}
+			key.Value = value
+		}
+
+		if conf.Status == common.KeyActive {
+			if active != nil {
+				return fmt.Errorf("%s: more than one active key: %s, %s", r.name, active.Kid, key.Kid)
+			}
+			active = key
+		}
+
+		set[key.Kid] = key
+	}
+
+	if active == nil {
+		return fmt.Errorf("%s: %s", r.name, errorMessage.NoActiveSecret)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.active = active
+	r.keys = set
+
+	return nil
+}
+
+// readSecret membaca secret dari "file:<path>" atau "env:<NAME>".
+// Nilai dengan prefix "base64:" di-decode terlebih dahulu.
+func readSecret(source string) ([]byte, error) {
+	var value []byte
+	switch {
+	case strings.HasPrefix(source, "file:"):
+		raw, err := os.ReadFile(strings.TrimPrefix(source, "file:"))
+		if err != nil {
+			return nil, err
+		}
+		value = bytes.TrimRight(raw, "\r\n")
+	case strings.HasPrefix(source, "env:"):
+		value = []byte(os.Getenv(strings.TrimPrefix(source, "env:")))
+	default:
+		return nil, errors.New("secret source must start with file: or env:")
+	}
+
+	if bytes.HasPrefix(value, []byte("base64:")) {
+		decoded, err := base64.StdEncoding.DecodeString(string(bytes.TrimPrefix(value, []byte("base64:"))))
+		if err != nil {
+			return nil, err
+		}
+		value = decoded
+	}
+
+	if len(value) == 0 {
+		return nil, errors.New("secret is empty")
+	}
+
+	return value, nil
+}
+
+func exactLength(n int) func([]byte) error {
+	return func(value []byte) error {
+		if len(value) != n {
+			return fmt.Errorf("secret must be exactly %d bytes", n)
+		}
+		return nil
+	}
+}
+
+func minLength(n int) func([]byte) error {
+	return func(value []byte) error {
+		if len(value) < n {
+			return fmt.Errorf("secret must be at least %d bytes", n)
+		}
+		return nil
+	}
+}
+
+// current mengembalikan versi active untuk enkripsi/tanda tangan baru
+func (r *secretRing) current() (*secret, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.active == nil {
+		return nil, errors.New(errorMessage.NoActiveSecret)
+	}
+
+	return r.active, nil
+}
+
+// lookup mengembalikan versi berdasarkan kid. Kid yang tidak dikenal atau retired ditolak.
+func (r *secretRing) lookup(kid string) (*secret, error) {
+	r.mu.RLock()
+	key, ok := r.keys[kid]
+	r.mu.RUnlock()
+
+	if !ok || key.Status == common.KeyRetired {
+		return nil, errors.New(errorMessage.InvalidSecret)
+	}
+
+	return key, nil
+}
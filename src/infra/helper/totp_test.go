@@ -0,0 +1,101 @@
+package helper
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// secret "12345678901234567890" dari test vector RFC 6238 (SHA1) dalam base32
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTotpCodeRfc6238(t *testing.T) {
+	key, err := totpEncoding.DecodeString(rfc6238Secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 6 digit terakhir dari code 8 digit pada RFC 6238 lampiran B
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{unix: 59, want: "287082"},
+		{unix: 1111111109, want: "081804"},
+		{unix: 1111111111, want: "050471"},
+		{unix: 1234567890, want: "005924"},
+		{unix: 2000000000, want: "279037"},
+		{unix: 20000000000, want: "353130"},
+	}
+
+	for _, tt := range tests {
+		if got := totpCode(key, tt.unix/totpPeriod); got != tt.want {
+			t.Errorf("totpCode at %d = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestValidateTotp(t *testing.T) {
+	// code 287082 berlaku untuk counter 1 (detik 30-59)
+	const code = "287082"
+
+	tests := []struct {
+		name        string
+		secret      string
+		code        string
+		at          int64
+		wantCounter int64
+		wantOk      bool
+	}{
+		{name: "current period", secret: rfc6238Secret, code: code, at: 59, wantCounter: 1, wantOk: true},
+		{name: "start of period", secret: rfc6238Secret, code: code, at: 30, wantCounter: 1, wantOk: true},
+		{name: "one period late", secret: rfc6238Secret, code: code, at: 89, wantCounter: 1, wantOk: true},
+		{name: "one period early", secret: rfc6238Secret, code: code, at: 29, wantCounter: 1, wantOk: true},
+		{name: "two periods late", secret: rfc6238Secret, code: code, at: 90, wantOk: false},
+		{name: "two periods early", secret: rfc6238Secret, code: "081804", at: 1111111109 - 2*totpPeriod, wantOk: false},
+		{name: "lowercase secret", secret: strings.ToLower(rfc6238Secret), code: code, at: 59, wantCounter: 1, wantOk: true},
+		{name: "wrong code", secret: rfc6238Secret, code: "287083", at: 59, wantOk: false},
+		{name: "eight digit code", secret: rfc6238Secret, code: "94287082", at: 59, wantOk: false},
+		{name: "short code", secret: rfc6238Secret, code: "28708", at: 59, wantOk: false},
+		{name: "empty code", secret: rfc6238Secret, code: "", at: 59, wantOk: false},
+		{name: "invalid secret", secret: "not base32!", code: code, at: 59, wantOk: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counter, ok := ValidateTotp(tt.secret, tt.code, time.Unix(tt.at, 0))
+			if ok != tt.wantOk {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOk)
+			}
+			if ok && counter != tt.wantCounter {
+				t.Fatalf("counter = %d, want %d", counter, tt.wantCounter)
+			}
+		})
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, err := GenerateRecoveryCodes(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seen := map[string]bool{}
+	for _, code := range codes {
+		if len(code) != 11 || code[5] != '-' {
+			t.Fatalf("unexpected recovery code format %q", code)
+		}
+		if seen[code] {
+			t.Fatalf("duplicate recovery code %q", code)
+		}
+		seen[code] = true
+
+		// input user dengan huruf besar, spasi atau tanpa tanda hubung tetap cocok
+		variants := []string{strings.ToUpper(code), strings.Replace(code, "-", "", 1), strings.Replace(code, "-", " ", 1)}
+		for _, variant := range variants {
+			if HashRecoveryCode(variant) != HashRecoveryCode(code) {
+				t.Fatalf("recovery code %q does not match %q", variant, code)
+			}
+		}
+	}
+}
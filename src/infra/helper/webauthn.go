@@ -0,0 +1,389 @@
+package helper
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/url"
+	"strings"
+	"sync"
+
+	"go-auth-service/src/infra/config"
+	"go-auth-service/src/infra/constants/common"
+	errorMessage "go-auth-service/src/infra/constants/error_message"
+)
+
+// Flag authenticator data (WebAuthn 6.1)
+const (
+	webAuthnFlagUserPresent      = 0x01
+	webAuthnFlagUserVerified     = 0x04
+	webAuthnFlagAttestedCredData = 0x40
+)
+
+// Algoritma COSE yang didukung, urutan sesuai preferensi pubKeyCredParams
+const (
+	CoseAlgES256 int64 = -7
+	CoseAlgEdDSA int64 = -8
+	CoseAlgRS256 int64 = -257
+)
+
+var WebAuthnAlgorithms = []int64{CoseAlgES256, CoseAlgEdDSA, CoseAlgRS256}
+
+type relyingParty struct {
+	mu      sync.RWMutex
+	id      string
+	name    string
+	idHash  [32]byte
+	origins map[string]bool
+}
+
+var webAuthnParty = &relyingParty{}
+
+// WebAuthnCredential adalah credential hasil registrasi. PublicKey disimpan dalam format COSE_Key.
+type WebAuthnCredential struct {
+	CredentialId []byte
+	PublicKey    []byte
+	SignCount    uint32
+}
+
+type webAuthnClientData struct {
+	Type        string `json:"type"`
+	Challenge   string `json:"challenge"`
+	Origin      string `json:"origin"`
+	CrossOrigin bool   `json:"crossOrigin"`
+}
+
+type authenticatorData struct {
+	rpIdHash   []byte
+	flags      byte
+	signCount  uint32
+	credential *WebAuthnCredential
+}
+
+// LoadWebAuthn memuat relying party untuk passkey. Passkey dinonaktifkan jika RPID kosong.
+// Setiap origin harus sama dengan RPID atau subdomain-nya dan memakai https (kecuali localhost).
+func LoadWebAuthn(conf config.WebAuthnConf) error {
+	if conf.RPID == "" {
+		log.Println("WEBAUTHN_RP_ID is empty, passkeys are disabled")
+		return nil
+	}
+
+	if len(conf.Origins) == 0 {
+		return errors.New("WEBAUTHN_ORIGINS must be configured when WEBAUTHN_RP_ID is set")
+	}
+
+	origins := map[string]bool{}
+	for _, origin := range conf.Origins {
+		u, err := url.Parse(origin)
+		if err != nil || u.Host == "" || u.Path != "" {
+			return fmt.Errorf("WEBAUTHN_ORIGINS: invalid origin %q", origin)
+		}
+
+		host := u.Hostname()
+		if host != conf.RPID && !strings.HasSuffix(host, "."+conf.RPID) {
+			return fmt.Errorf("WEBAUTHN_ORIGINS: origin %q is not within rp id %q", origin, conf.RPID)
+		}
+
+		if u.Scheme != "https" && !(u.Scheme == "http" && host == "localhost") {
+			return fmt.Errorf("WEBAUTHN_ORIGINS: origin %q must use https", origin)
+		}
+
+		origins[u.Scheme+"://"+u.Host] = true
+	}
+
+	webAuthnParty.mu.Lock()
+	defer webAuthnParty.mu.Unlock()
+
+	webAuthnParty.id = conf.RPID
+	webAuthnParty.name = conf.RPName
+	webAuthnParty.idHash = sha256.Sum256([]byte(conf.RPID))
+	webAuthnParty.origins = origins
+
+	return nil
+}
+
+// WebAuthnRelyingParty mengembalikan id dan nama relying party untuk options ceremony
+func WebAuthnRelyingParty() (string, string, error) {
+	webAuthnParty.mu.RLock()
+	defer webAuthnParty.mu.RUnlock()
+
+	if webAuthnParty.id == "" {
+		return "", "", errors.New(errorMessage.WebAuthnDisabled)
+	}
+
+	return webAuthnParty.id, webAuthnParty.name, nil
+}
+
+// VerifyWebAuthnRegistration memverifikasi hasil navigator.credentials.create().
+// Attestation statement tidak diverifikasi (attestation "none"), sehingga yang dijamin
+// hanya challenge, origin, rp id, user presence dan user verification.
+func VerifyWebAuthnRegistration(clientDataJSON, attestationObject []byte, challenge string) (*WebAuthnCredential, error) {
+	invalid := errors.New(errorMessage.InvalidWebAuthnResponse)
+
+	if err := verifyClientData(clientDataJSON, common.WebAuthnCeremonyRegister, challenge); err != nil {
+		return nil, err
+	}
+
+	decoded, _, err := decodeCbor(attestationObject)
+	if err != nil {
+		return nil, invalid
+	}
+
+	attestation, ok := decoded.(map[interface{}]interface{})
+	if !ok {
+		return nil, invalid
+	}
+
+	rawAuthData, ok := attestation["authData"].([]byte)
+	if !ok {
+		return nil, invalid
+	}
+
+	authData, err := parseAuthenticatorData(rawAuthData)
+	if err != nil {
+		return nil, err
+	}
+
+	if authData.flags&webAuthnFlagAttestedCredData == 0 || authData.credential == nil {
+		return nil, invalid
+	}
+
+	if _, _, err = parseCoseKey(authData.credential.PublicKey); err != nil {
+		return nil, invalid
+	}
+
+	authData.credential.SignCount = authData.signCount
+
+	return authData.credential, nil
+}
+
+// VerifyWebAuthnAssertion memverifikasi hasil navigator.credentials.get() terhadap public key
+// yang tersimpan dan mengembalikan sign count baru. Sign count yang tidak naik dianggap
+// tanda authenticator di-clone sehingga ditolak.
+func VerifyWebAuthnAssertion(clientDataJSON, rawAuthData, signature, publicKey []byte, challenge string, signCount uint32) (uint32, error) {
+	invalid := errors.New(errorMessage.InvalidWebAuthnResponse)
+
+	if err := verifyClientData(clientDataJSON, common.WebAuthnCeremonyLogin, challenge); err != nil {
+		return 0, err
+	}
+
+	authData, err := parseAuthenticatorData(rawAuthData)
+	if err != nil {
+		return 0, err
+	}
+
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	signed := append(append([]byte(nil), rawAuthData...), clientDataHash[:]...)
+
+	if err = verifyCoseSignature(publicKey, signed, signature); err != nil {
+		return 0, invalid
+	}
+
+	if (authData.signCount != 0 || signCount != 0) && authData.signCount <= signCount {
+		return 0, invalid
+	}
+
+	return authData.signCount, nil
+}
+
+// WebAuthnChallenge membaca challenge dari clientDataJSON agar data ceremony bisa diambil dari Redis
+// sebelum response diverifikasi sepenuhnya
+func WebAuthnChallenge(clientDataJSON []byte) (string, error) {
+	var clientData webAuthnClientData
+	if err := json.Unmarshal(clientDataJSON, &clientData); err != nil || clientData.Challenge == "" {
+		return "", errors.New(errorMessage.InvalidWebAuthnChallenge)
+	}
+
+	return clientData.Challenge, nil
+}
+
+// WebAuthnDecode membaca data biner base64url dari client, dengan atau tanpa padding
+func WebAuthnDecode(value string) ([]byte, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+	if err != nil {
+		return nil, errors.New(errorMessage.InvalidWebAuthnResponse)
+	}
+
+	return decoded, nil
+}
+
+func verifyClientData(raw []byte, ceremony, challenge string) error {
+	invalid := errors.New(errorMessage.InvalidWebAuthnResponse)
+
+	var clientData webAuthnClientData
+	if err := json.Unmarshal(raw, &clientData); err != nil {
+		return invalid
+	}
+
+	if clientData.Type != ceremony || clientData.CrossOrigin {
+		return invalid
+	}
+
+	if subtle.ConstantTimeCompare([]byte(clientData.Challenge), []byte(challenge)) != 1 {
+		return invalid
+	}
+
+	webAuthnParty.mu.RLock()
+	allowed := webAuthnParty.origins[clientData.Origin]
+	webAuthnParty.mu.RUnlock()
+
+	if !allowed {
+		return invalid
+	}
+
+	return nil
+}
+
+// parseAuthenticatorData membaca authenticator data (WebAuthn 6.1) dan memastikan rp id,
+// user presence dan user verification. Passkey menggantikan password sehingga UV wajib.
+func parseAuthenticatorData(data []byte) (*authenticatorData, error) {
+	invalid := errors.New(errorMessage.InvalidWebAuthnResponse)
+
+	if len(data) < 37 {
+		return nil, invalid
+	}
+
+	result := &authenticatorData{
+		rpIdHash:  data[:32],
+		flags:     data[32],
+		signCount: binary.BigEndian.Uint32(data[33:37]),
+	}
+
+	webAuthnParty.mu.RLock()
+	idHash := webAuthnParty.idHash
+	webAuthnParty.mu.RUnlock()
+
+	if subtle.ConstantTimeCompare(result.rpIdHash, idHash[:]) != 1 {
+		return nil, invalid
+	}
+
+	if result.flags&webAuthnFlagUserPresent == 0 || result.flags&webAuthnFlagUserVerified == 0 {
+		return nil, invalid
+	}
+
+	if result.flags&webAuthnFlagAttestedCredData == 0 {
+		return result, nil
+	}
+
+	// attested credential data: aaguid(16) | len(2) | credential id | COSE_Key
+	rest := data[37:]
+	if len(rest) < 18 {
+		return nil, invalid
+	}
+
+	idLen := int(binary.BigEndian.Uint16(rest[16:18]))
+	rest = rest[18:]
+	if idLen == 0 || idLen > 1023 || len(rest) < idLen {
+		return nil, invalid
+	}
+
+	credentialId := rest[:idLen]
+	rest = rest[idLen:]
+
+	_, remaining, err := decodeCbor(rest)
+	if err != nil {
+		return nil, invalid
+	}
+
+	result.credential = &WebAuthnCredential{
+		CredentialId: append([]byte(nil), credentialId...),
+		PublicKey:    append([]byte(nil), rest[:len(rest)-len(remaining)]...),
+	}
+
+	return result, nil
+}
+
+// parseCoseKey membaca COSE_Key (RFC 9053) untuk algoritma ES256, EdDSA dan RS256
+func parseCoseKey(raw []byte) (crypto.PublicKey, int64, error) {
+	decoded, remaining, err := decodeCbor(raw)
+	if err != nil || len(remaining) != 0 {
+		return nil, 0, errCbor
+	}
+
+	key, ok := decoded.(map[interface{}]interface{})
+	if !ok {
+		return nil, 0, errCbor
+	}
+
+	kty, _ := key[int64(1)].(int64)
+	alg, _ := key[int64(3)].(int64)
+
+	switch {
+	case kty == 2 && alg == CoseAlgES256:
+		crv, _ := key[int64(-1)].(int64)
+		x, _ := key[int64(-2)].([]byte)
+		y, _ := key[int64(-3)].([]byte)
+		if crv != 1 || len(x) != 32 || len(y) != 32 {
+			return nil, 0, errCbor
+		}
+
+		// ecdh memvalidasi bahwa titik berada di kurva P-256
+		point := append(append([]byte{0x04}, x...), y...)
+		if _, err = ecdh.P256().NewPublicKey(point); err != nil {
+			return nil, 0, err
+		}
+
+		return &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}, alg, nil
+	case kty == 1 && alg == CoseAlgEdDSA:
+		crv, _ := key[int64(-1)].(int64)
+		x, _ := key[int64(-2)].([]byte)
+		if crv != 6 || len(x) != ed25519.PublicKeySize {
+			return nil, 0, errCbor
+		}
+
+		return ed25519.PublicKey(x), alg, nil
+	case kty == 3 && alg == CoseAlgRS256:
+		n, _ := key[int64(-1)].([]byte)
+		e, _ := key[int64(-2)].([]byte)
+		if len(n) < 256 || len(e) == 0 || len(e) > 4 {
+			return nil, 0, errCbor
+		}
+
+		exponent := new(big.Int).SetBytes(e)
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, alg, nil
+	}
+
+	return nil, 0, errCbor
+}
+
+func verifyCoseSignature(rawKey, data, signature []byte) error {
+	publicKey, alg, err := parseCoseKey(rawKey)
+	if err != nil {
+		return err
+	}
+
+	digest := sha256.Sum256(data)
+
+	switch alg {
+	case CoseAlgES256:
+		if !ecdsa.VerifyASN1(publicKey.(*ecdsa.PublicKey), digest[:], signature) {
+			return errors.New("invalid signature")
+		}
+		return nil
+	case CoseAlgEdDSA:
+		if !ed25519.Verify(publicKey.(ed25519.PublicKey), data, signature) {
+			return errors.New("invalid signature")
+		}
+		return nil
+	case CoseAlgRS256:
+		return rsa.VerifyPKCS1v15(publicKey.(*rsa.PublicKey), crypto.SHA256, digest[:], signature)
+	}
+
+	return errors.New("unsupported algorithm")
+}
@@ -0,0 +1,435 @@
+package helper
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+
+	"go-auth-service/src/infra/config"
+	"go-auth-service/src/infra/constants/common"
+	errorMessage "go-auth-service/src/infra/constants/error_message"
+)
+
+const (
+	testRPID      = "example.com"
+	testOrigin    = "https://example.com"
+	testChallenge = "Y2hhbGxlbmdl"
+)
+
+func loadTestWebAuthn(t *testing.T) {
+	t.Helper()
+
+	err := LoadWebAuthn(config.WebAuthnConf{RPID: testRPID, RPName: "Example", Origins: []string{testOrigin}})
+	if err != nil {
+		t.Fatalf("LoadWebAuthn: %v", err)
+	}
+}
+
+// cborHead menulis header item CBOR dengan argumen n
+func cborHead(major byte, n uint64) []byte {
+	switch {
+	case n < 24:
+		return []byte{major<<5 | byte(n)}
+	case n <= 0xff:
+		return []byte{major<<5 | 24, byte(n)}
+	case n <= 0xffff:
+		return binary.BigEndian.AppendUint16([]byte{major<<5 | 25}, uint16(n))
+	}
+	return binary.BigEndian.AppendUint32([]byte{major<<5 | 26}, uint32(n))
+}
+
+func cborInt(n int64) []byte {
+	if n < 0 {
+		return cborHead(1, uint64(-1-n))
+	}
+	return cborHead(0, uint64(n))
+}
+
+func cborBytes(b []byte) []byte {
+	return append(cborHead(2, uint64(len(b))), b...)
+}
+
+func cborText(s string) []byte {
+	return append(cborHead(3, uint64(len(s))), s...)
+}
+
+func es256CoseKey(key *ecdsa.PublicKey) []byte {
+	x := make([]byte, 32)
+	y := make([]byte, 32)
+	key.X.FillBytes(x)
+	key.Y.FillBytes(y)
+
+	out := cborHead(5, 5)
+	out = append(out, cborInt(1)...)
+	out = append(out, cborInt(2)...)
+	out = append(out, cborInt(3)...)
+	out = append(out, cborInt(CoseAlgES256)...)
+	out = append(out, cborInt(-1)...)
+	out = append(out, cborInt(1)...)
+	out = append(out, cborInt(-2)...)
+	out = append(out, cborBytes(x)...)
+	out = append(out, cborInt(-3)...)
+	out = append(out, cborBytes(y)...)
+	return out
+}
+
+func testAuthData(rpId string, flags byte, signCount uint32, credentialId, coseKey []byte) []byte {
+	idHash := sha256.Sum256([]byte(rpId))
+	data := append([]byte(nil), idHash[:]...)
+	data = append(data, flags)
+	data = binary.BigEndian.AppendUint32(data, signCount)
+
+	if flags&webAuthnFlagAttestedCredData != 0 {
+		data = append(data, make([]byte, 16)...)
+		data = binary.BigEndian.AppendUint16(data, uint16(len(credentialId)))
+		data = append(data, credentialId...)
+		data = append(data, coseKey...)
+	}
+
+	return data
+}
+
+func testClientData(t *testing.T, ceremony, challenge, origin string, crossOrigin bool) []byte {
+	t.Helper()
+
+	raw, err := json.Marshal(webAuthnClientData{Type: ceremony, Challenge: challenge, Origin: origin, CrossOrigin: crossOrigin})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func signAssertion(t *testing.T, key *ecdsa.PrivateKey, authData, clientData []byte) []byte {
+	t.Helper()
+
+	clientDataHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(append([]byte(nil), authData...), clientDataHash[:]...))
+
+	signature, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signature
+}
+
+func TestVerifyWebAuthnAssertion(t *testing.T) {
+	loadTestWebAuthn(t)
+
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	otherKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	publicKey := es256CoseKey(&key.PublicKey)
+
+	const flags = webAuthnFlagUserPresent | webAuthnFlagUserVerified
+
+	tests := []struct {
+		name       string
+		clientData []byte
+		authData   []byte
+		signer     *ecdsa.PrivateKey
+		tamper     func(clientData, authData, signature []byte) ([]byte, []byte, []byte)
+		stored     uint32
+		wantCount  uint32
+		wantErr    string
+	}{
+		{
+			name:       "valid",
+			clientData: testClientData(t, common.WebAuthnCeremonyLogin, testChallenge, testOrigin, false),
+			authData:   testAuthData(testRPID, flags, 6, nil, nil),
+			signer:     key,
+			stored:     5,
+			wantCount:  6,
+		},
+		{
+			name:       "authenticator without counter",
+			clientData: testClientData(t, common.WebAuthnCeremonyLogin, testChallenge, testOrigin, false),
+			authData:   testAuthData(testRPID, flags, 0, nil, nil),
+			signer:     key,
+			stored:     0,
+			wantCount:  0,
+		},
+		{
+			name:       "signed by another key",
+			clientData: testClientData(t, common.WebAuthnCeremonyLogin, testChallenge, testOrigin, false),
+			authData:   testAuthData(testRPID, flags, 6, nil, nil),
+			signer:     otherKey,
+			stored:     5,
+			wantErr:    errorMessage.InvalidWebAuthnResponse,
+		},
+		{
+			name:       "corrupted signature",
+			clientData: testClientData(t, common.WebAuthnCeremonyLogin, testChallenge, testOrigin, false),
+			authData:   testAuthData(testRPID, flags, 6, nil, nil),
+			signer:     key,
+			tamper: func(clientData, authData, signature []byte) ([]byte, []byte, []byte) {
+				signature[len(signature)-1] ^= 0xff
+				return clientData, authData, signature
+			},
+			stored:  5,
+			wantErr: errorMessage.InvalidWebAuthnResponse,
+		},
+		{
+			name:       "auth data changed after signing",
+			clientData: testClientData(t, common.WebAuthnCeremonyLogin, testChallenge, testOrigin, false),
+			authData:   testAuthData(testRPID, flags, 6, nil, nil),
+			signer:     key,
+			tamper: func(clientData, authData, signature []byte) ([]byte, []byte, []byte) {
+				return clientData, testAuthData(testRPID, flags, 7, nil, nil), signature
+			},
+			stored:  5,
+			wantErr: errorMessage.InvalidWebAuthnResponse,
+		},
+		{
+			name:       "sign count not increased",
+			clientData: testClientData(t, common.WebAuthnCeremonyLogin, testChallenge, testOrigin, false),
+			authData:   testAuthData(testRPID, flags, 5, nil, nil),
+			signer:     key,
+			stored:     5,
+			wantErr:    errorMessage.InvalidWebAuthnResponse,
+		},
+		{
+			name:       "sign count regression",
+			clientData: testClientData(t, common.WebAuthnCeremonyLogin, testChallenge, testOrigin, false),
+			authData:   testAuthData(testRPID, flags, 3, nil, nil),
+			signer:     key,
+			stored:     5,
+			wantErr:    errorMessage.InvalidWebAuthnResponse,
+		},
+		{
+			name:       "sign count reset to zero",
+			clientData: testClientData(t, common.WebAuthnCeremonyLogin, testChallenge, testOrigin, false),
+			authData:   testAuthData(testRPID, flags, 0, nil, nil),
+			signer:     key,
+			stored:     5,
+			wantErr:    errorMessage.InvalidWebAuthnResponse,
+		},
+		{
+			name:       "wrong rp id hash",
+			clientData: testClientData(t, common.WebAuthnCeremonyLogin, testChallenge, testOrigin, false),
+			authData:   testAuthData("evil.com", flags, 6, nil, nil),
+			signer:     key,
+			stored:     5,
+			wantErr:    errorMessage.InvalidWebAuthnResponse,
+		},
+		{
+			name:       "wrong origin",
+			clientData: testClientData(t, common.WebAuthnCeremonyLogin, testChallenge, "https://evil.com", false),
+			authData:   testAuthData(testRPID, flags, 6, nil, nil),
+			signer:     key,
+			stored:     5,
+			wantErr:    errorMessage.InvalidWebAuthnResponse,
+		},
+		{
+			name:       "wrong challenge",
+			clientData: testClientData(t, common.WebAuthnCeremonyLogin, "b3RoZXI", testOrigin, false),
+			authData:   testAuthData(testRPID, flags, 6, nil, nil),
+			signer:     key,
+			stored:     5,
+			wantErr:    errorMessage.InvalidWebAuthnResponse,
+		},
+		{
+			name:       "registration client data",
+			clientData: testClientData(t, common.WebAuthnCeremonyRegister, testChallenge, testOrigin, false),
+			authData:   testAuthData(testRPID, flags, 6, nil, nil),
+			signer:     key,
+			stored:     5,
+			wantErr:    errorMessage.InvalidWebAuthnResponse,
+		},
+		{
+			name:       "cross origin",
+			clientData: testClientData(t, common.WebAuthnCeremonyLogin, testChallenge, testOrigin, true),
+			authData:   testAuthData(testRPID, flags, 6, nil, nil),
+			signer:     key,
+			stored:     5,
+			wantErr:    errorMessage.InvalidWebAuthnResponse,
+		},
+		{
+			name:       "user not verified",
+			clientData: testClientData(t, common.WebAuthnCeremonyLogin, testChallenge, testOrigin, false),
+			authData:   testAuthData(testRPID, webAuthnFlagUserPresent, 6, nil, nil),
+			signer:     key,
+			stored:     5,
+			wantErr:    errorMessage.InvalidWebAuthnResponse,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientData, authData := tt.clientData, tt.authData
+			signature := signAssertion(t, tt.signer, authData, clientData)
+			if tt.tamper != nil {
+				clientData, authData, signature = tt.tamper(clientData, authData, signature)
+			}
+
+			count, err := VerifyWebAuthnAssertion(clientData, authData, signature, publicKey, testChallenge, tt.stored)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if count != tt.wantCount {
+				t.Fatalf("sign count = %d, want %d", count, tt.wantCount)
+			}
+		})
+	}
+}
+
+func testAttestationObject(authData []byte) []byte {
+	out := cborHead(5, 3)
+	out = append(out, cborText("fmt")...)
+	out = append(out, cborText("none")...)
+	out = append(out, cborText("attStmt")...)
+	out = append(out, cborHead(5, 0)...)
+	out = append(out, cborText("authData")...)
+	out = append(out, cborBytes(authData)...)
+	return out
+}
+
+func TestVerifyWebAuthnRegistration(t *testing.T) {
+	loadTestWebAuthn(t)
+
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	coseKey := es256CoseKey(&key.PublicKey)
+	credentialId := []byte("credential-1")
+
+	const flags = webAuthnFlagUserPresent | webAuthnFlagUserVerified | webAuthnFlagAttestedCredData
+
+	tests := []struct {
+		name        string
+		clientData  []byte
+		attestation []byte
+		wantErr     bool
+	}{
+		{
+			name:        "valid",
+			clientData:  testClientData(t, common.WebAuthnCeremonyRegister, testChallenge, testOrigin, false),
+			attestation: testAttestationObject(testAuthData(testRPID, flags, 0, credentialId, coseKey)),
+		},
+		{
+			name:        "wrong challenge",
+			clientData:  testClientData(t, common.WebAuthnCeremonyRegister, "b3RoZXI", testOrigin, false),
+			attestation: testAttestationObject(testAuthData(testRPID, flags, 0, credentialId, coseKey)),
+			wantErr:     true,
+		},
+		{
+			name:        "wrong origin",
+			clientData:  testClientData(t, common.WebAuthnCeremonyRegister, testChallenge, "https://example.org", false),
+			attestation: testAttestationObject(testAuthData(testRPID, flags, 0, credentialId, coseKey)),
+			wantErr:     true,
+		},
+		{
+			name:        "wrong rp id hash",
+			clientData:  testClientData(t, common.WebAuthnCeremonyRegister, testChallenge, testOrigin, false),
+			attestation: testAttestationObject(testAuthData("example.org", flags, 0, credentialId, coseKey)),
+			wantErr:     true,
+		},
+		{
+			name:        "login client data",
+			clientData:  testClientData(t, common.WebAuthnCeremonyLogin, testChallenge, testOrigin, false),
+			attestation: testAttestationObject(testAuthData(testRPID, flags, 0, credentialId, coseKey)),
+			wantErr:     true,
+		},
+		{
+			name:        "without attested credential",
+			clientData:  testClientData(t, common.WebAuthnCeremonyRegister, testChallenge, testOrigin, false),
+			attestation: testAttestationObject(testAuthData(testRPID, flags&^webAuthnFlagAttestedCredData, 0, nil, nil)),
+			wantErr:     true,
+		},
+		{
+			name:        "unsupported key",
+			clientData:  testClientData(t, common.WebAuthnCeremonyRegister, testChallenge, testOrigin, false),
+			attestation: testAttestationObject(testAuthData(testRPID, flags, 0, credentialId, append(cborHead(5, 1), append(cborInt(1), cborInt(2)...)...))),
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			credential, err := VerifyWebAuthnRegistration(tt.clientData, tt.attestation, testChallenge)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(credential.CredentialId) != string(credentialId) || string(credential.PublicKey) != string(coseKey) {
+				t.Fatal("credential does not match the attested credential data")
+			}
+		})
+	}
+}
+
+// Setiap potongan attestation object dan authenticator data harus ditolak tanpa panic
+func TestVerifyWebAuthnRegistrationTruncated(t *testing.T) {
+	loadTestWebAuthn(t)
+
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	flags := byte(webAuthnFlagUserPresent | webAuthnFlagUserVerified | webAuthnFlagAttestedCredData)
+	authData := testAuthData(testRPID, flags, 0, []byte("credential-1"), es256CoseKey(&key.PublicKey))
+	attestation := testAttestationObject(authData)
+	clientData := testClientData(t, common.WebAuthnCeremonyRegister, testChallenge, testOrigin, false)
+
+	for i := 0; i < len(attestation); i++ {
+		if _, err := VerifyWebAuthnRegistration(clientData, attestation[:i], testChallenge); err == nil {
+			t.Fatalf("attestation truncated to %d bytes was accepted", i)
+		}
+	}
+
+	for i := 0; i < len(authData); i++ {
+		if _, err := VerifyWebAuthnRegistration(clientData, testAttestationObject(authData[:i]), testChallenge); err == nil {
+			t.Fatalf("auth data truncated to %d bytes was accepted", i)
+		}
+	}
+}
+
+func TestDecodeCborMalformed(t *testing.T) {
+	// array bersarang melewati cborMaxDepth, diakhiri integer agar selain kedalaman input valid
+	nested := make([]byte, cborMaxDepth+2)
+	for i := range nested {
+		nested[i] = 0x81
+	}
+	nested = append(nested, 0x01)
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "empty", data: nil},
+		{name: "missing argument", data: []byte{0x18}},
+		{name: "short uint16 argument", data: []byte{0x19, 0x01}},
+		{name: "short uint64 argument", data: []byte{0x1b, 0, 0, 0, 0}},
+		{name: "integer overflow", data: []byte{0x1b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}},
+		{name: "negative integer overflow", data: []byte{0x3b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}},
+		{name: "byte string longer than input", data: []byte{0x45, 0x01, 0x02}},
+		{name: "huge byte string length", data: []byte{0x5b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}},
+		{name: "huge array length", data: []byte{0x9b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}},
+		{name: "huge map length", data: []byte{0xbb, 0x00, 0x00, 0x00, 0x00, 0xff, 0xff, 0xff, 0xff}},
+		{name: "array missing items", data: []byte{0x83, 0x01, 0x02}},
+		{name: "map missing value", data: []byte{0xa1, 0x01}},
+		{name: "map with array key", data: []byte{0xa1, 0x80, 0x01}},
+		{name: "indefinite byte string", data: []byte{0x5f, 0x41, 0x00, 0xff}},
+		{name: "tag", data: []byte{0xc0, 0x01}},
+		{name: "float", data: []byte{0xf9, 0x3c, 0x00}},
+		{name: "too deep", data: nested},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := decodeCbor(tt.data); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"database/sql"
+)
+
+// OAuthClient menyimpan aplikasi yang terdaftar sebagai client OAuth2/OIDC.
+// RedirectUris, GrantTypes dan Scopes dipisahkan spasi.
+type OAuthClient struct {
+	Id               int64          `db:"id"`
+	ClientId         string         `db:"client_id"`
+	ClientSecretHash sql.NullString `db:"client_secret_hash"`
+	Name             string         `db:"name"`
+	RedirectUris     string         `db:"redirect_uris"`
+	GrantTypes       string         `db:"grant_types"`
+	Scopes           string         `db:"scopes"`
+	IsActive         bool           `db:"is_active"`
+	CreatedAt        sql.NullTime   `db:"created_at"`
+	UpdatedAt        sql.NullTime   `db:"updated_at"`
+	DeletedAt        sql.NullTime   `db:"deleted_at"`
+}
@@ -5,10 +5,14 @@ import (
 )
 
 type User struct {
-	Id        int64        `db:"id"`
-	Email     string       `db:"email"`
-	Password  string       `db:"password"`
-	CreatedAt sql.NullTime `db:"created_at"`
-	UpdatedAt sql.NullTime `db:"updated_at"`
-	DeletedAt sql.NullTime `db:"deleted_at"`
+	Id                    int64          `db:"id"`
+	Email                 string         `db:"email"`
+	Password              string         `db:"password"`
+	PasswordResetRequired bool           `db:"password_reset_required"`
+	Verified              bool           `db:"verified"`
+	Phone                 sql.NullString `db:"phone"`
+	UserTypeId            int64          `db:"user_type_id"`
+	CreatedAt             sql.NullTime   `db:"created_at"`
+	UpdatedAt             sql.NullTime   `db:"updated_at"`
+	DeletedAt             sql.NullTime   `db:"deleted_at"`
 }
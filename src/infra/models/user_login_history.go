@@ -7,9 +7,11 @@ import (
 type UserLoginHistory struct {
 	Id           int64          `db:"id"`
 	UserId       int64          `db:"user_id"`
+	SessionId    sql.NullString `db:"session_id"`
 	LoginTime    sql.NullTime   `db:"login_time"`
 	IpAddress    sql.NullString `db:"ip_address"`
 	UserAgent    sql.NullString `db:"user_agent"`
 	LogoutTime   sql.NullTime   `db:"logout_time"`
 	LogoutReason sql.NullString `db:"logout_reason"`
+	AuthMethod   string         `db:"auth_method"`
 }
@@ -0,0 +1,11 @@
+package models
+
+import "database/sql"
+
+type UserRecoveryCode struct {
+	Id        int64        `db:"id"`
+	UserId    int64        `db:"user_id"`
+	CodeHash  string       `db:"code_hash"`
+	UsedAt    sql.NullTime `db:"used_at"`
+	CreatedAt sql.NullTime `db:"created_at"`
+}
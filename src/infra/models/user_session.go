@@ -0,0 +1,18 @@
+package models
+
+import "database/sql"
+
+type UserSession struct {
+	Id           string         `db:"id"`
+	UserId       int64          `db:"user_id"`
+	DeviceLabel  string         `db:"device_label"`
+	IpAddress    string         `db:"ip_address"`
+	UserAgent    string         `db:"user_agent"`
+	ClientId     string         `db:"client_id"`
+	Scope        string         `db:"scope"`
+	CreatedAt    sql.NullTime   `db:"created_at"`
+	LastSeenAt   sql.NullTime   `db:"last_seen_at"`
+	ExpiresAt    sql.NullTime   `db:"expires_at"`
+	RevokedAt    sql.NullTime   `db:"revoked_at"`
+	RevokeReason sql.NullString `db:"revoke_reason"`
+}
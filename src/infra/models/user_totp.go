@@ -0,0 +1,14 @@
+package models
+
+import "database/sql"
+
+// UserTotp menyimpan secret TOTP dalam bentuk terenkripsi. ConfirmedAt kosong berarti
+// enrollment belum dikonfirmasi dan belum dipakai saat login.
+type UserTotp struct {
+	Id          int64        `db:"id"`
+	UserId      int64        `db:"user_id"`
+	Secret      string       `db:"secret"`
+	ConfirmedAt sql.NullTime `db:"confirmed_at"`
+	CreatedAt   sql.NullTime `db:"created_at"`
+	UpdatedAt   sql.NullTime `db:"updated_at"`
+}
@@ -0,0 +1,17 @@
+package models
+
+import "database/sql"
+
+// UserWebauthnCredential adalah passkey milik user. CredentialId disimpan dalam base64url,
+// PublicKey dalam format COSE_Key dan Transports dipisahkan koma.
+type UserWebauthnCredential struct {
+	Id           int64        `db:"id"`
+	UserId       int64        `db:"user_id"`
+	CredentialId string       `db:"credential_id"`
+	PublicKey    []byte       `db:"public_key"`
+	SignCount    int64        `db:"sign_count"`
+	Transports   string       `db:"transports"`
+	Nickname     string       `db:"nickname"`
+	CreatedAt    sql.NullTime `db:"created_at"`
+	LastUsedAt   sql.NullTime `db:"last_used_at"`
+}
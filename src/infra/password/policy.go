@@ -0,0 +1,128 @@
+package password
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"go-auth-service/src/infra/config"
+)
+
+// PasswordPolicy memeriksa password baru. userInputs berisi data akun (email, nama) yang
+// tidak boleh menjadi bagian dari password. History adalah jumlah password terakhir yang
+// tidak boleh dipakai ulang, pengecekannya dilakukan oleh pemanggil karena butuh data user.
+type PasswordPolicy interface {
+	Validate(password string, userInputs ...string) error
+	History() int
+}
+
+// PolicyError dikembalikan untuk password yang tidak memenuhi policy. Pesannya aman
+// ditampilkan ke user.
+type PolicyError struct {
+	Message string
+}
+
+func (e *PolicyError) Error() string {
+	return e.Message
+}
+
+type policy struct {
+	conf config.PasswordPolicyConf
+}
+
+func NewPolicy(conf config.PasswordPolicyConf) PasswordPolicy {
+	return &policy{conf: conf}
+}
+
+func (p *policy) History() int {
+	return p.conf.History
+}
+
+func (p *policy) Validate(password string, userInputs ...string) error {
+	length := utf8.RuneCountInString(password)
+	tooLong := length > p.conf.MaxLength || (p.conf.MaxBytes > 0 && len(password) > p.conf.MaxBytes)
+	if length < p.conf.MinLength || tooLong {
+		return &PolicyError{Message: fmt.Sprintf("password must be between %d and %d characters", p.conf.MinLength, p.conf.MaxLength)}
+	}
+
+	for _, class := range p.conf.CharacterClasses {
+		if err := checkCharacterClass(password, class); err != nil {
+			return err
+		}
+	}
+
+	lower := strings.ToLower(password)
+	for _, input := range accountTokens(userInputs) {
+		if strings.Contains(lower, input) {
+			return &PolicyError{Message: "password must not contain your email or name"}
+		}
+	}
+
+	if Score(password, userInputs...) < p.conf.MinScore {
+		return &PolicyError{Message: "password is too easy to guess, use a longer password or passphrase"}
+	}
+
+	return nil
+}
+
+func checkCharacterClass(password, class string) error {
+	var match func(r rune) bool
+	var message string
+
+	switch class {
+	case "upper":
+		match, message = unicode.IsUpper, "password must contain at least one uppercase letter"
+	case "lower":
+		match, message = unicode.IsLower, "password must contain at least one lowercase letter"
+	case "digit":
+		match, message = unicode.IsDigit, "password must contain at least one number"
+	case "symbol":
+		match, message = isSymbol, "password must contain at least one special character (e.g., @, #, $, %, etc.)"
+	default:
+		return nil
+	}
+
+	if strings.IndexFunc(password, match) < 0 {
+		return &PolicyError{Message: message}
+	}
+
+	return nil
+}
+
+func isSymbol(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+// accountTokens memecah email dan nama menjadi bagian yang dicek terhadap password,
+// misalnya "john.doe@mail.com" menjadi "john.doe@mail.com", "john.doe", "john" dan "doe".
+// Bagian yang lebih pendek dari 3 karakter diabaikan.
+func accountTokens(userInputs []string) []string {
+	var tokens []string
+	add := func(token string) {
+		if utf8.RuneCountInString(token) >= 3 {
+			tokens = append(tokens, token)
+		}
+	}
+
+	for _, input := range userInputs {
+		input = strings.ToLower(strings.TrimSpace(input))
+		add(input)
+
+		local := input
+		if at := strings.LastIndex(input, "@"); at >= 0 {
+			local = input[:at]
+			add(local)
+		}
+
+		for _, part := range strings.FieldsFunc(local, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		}) {
+			if part != local {
+				add(part)
+			}
+		}
+	}
+
+	return tokens
+}
@@ -0,0 +1,78 @@
+package password
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"go-auth-service/src/infra/config"
+)
+
+func TestPolicyValidate(t *testing.T) {
+	conf := config.PasswordPolicyConf{
+		MinLength:        8,
+		MaxLength:        72,
+		CharacterClasses: []string{"upper", "lower", "digit", "symbol"},
+		MinScore:         2,
+		History:          5,
+		MaxBytes:         72,
+	}
+	argon2Conf := conf
+	argon2Conf.MaxBytes = 0
+	userInputs := []string{"john.doe@mail.com", "John", "Doe"}
+
+	tests := []struct {
+		name     string
+		conf     config.PasswordPolicyConf
+		password string
+		wantErr  string
+	}{
+		{name: "valid", conf: conf, password: "kT9#vQ2!mZ"},
+		{name: "too short", conf: conf, password: "kT9#vQ2", wantErr: "password must be between 8 and 72 characters"},
+		{name: "longer than bcrypt accepts", conf: conf, password: "kT9#vQ2!" + strings.Repeat("é", 40), wantErr: "password must be between 8 and 72 characters"},
+		{name: "no byte limit for argon2id", conf: argon2Conf, password: "kT9#vQ2!" + strings.Repeat("é", 40)},
+		{name: "missing uppercase", conf: conf, password: "kt9#vq2!mz", wantErr: "password must contain at least one uppercase letter"},
+		{name: "missing lowercase", conf: conf, password: "KT9#VQ2!MZ", wantErr: "password must contain at least one lowercase letter"},
+		{name: "missing digit", conf: conf, password: "kTx#vQy!mZ", wantErr: "password must contain at least one number"},
+		{name: "missing symbol", conf: conf, password: "kT9xvQ2ymZ", wantErr: "password must contain at least one special character (e.g., @, #, $, %, etc.)"},
+		{name: "contains email local part", conf: conf, password: "X1!john.doe", wantErr: "password must not contain your email or name"},
+		{name: "contains name", conf: conf, password: "Kt9#DOE!mz", wantErr: "password must not contain your email or name"},
+		{name: "too easy to guess", conf: conf, password: "P@ssw0rd1", wantErr: "password is too easy to guess, use a longer password or passphrase"},
+		{
+			name:     "no character classes required",
+			conf:     config.PasswordPolicyConf{MinLength: 12, MaxLength: 72, MinScore: 3},
+			password: "correct horse battery staple",
+		},
+		{
+			name:     "unknown character class ignored",
+			conf:     config.PasswordPolicyConf{MinLength: 8, MaxLength: 72, CharacterClasses: []string{"emoji"}},
+			password: "kT9#vQ2!mZ",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewPolicy(tt.conf).Validate(tt.password, userInputs...)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			var policyErr *PolicyError
+			if !errors.As(err, &policyErr) || policyErr.Message != tt.wantErr {
+				t.Fatalf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAccountTokens(t *testing.T) {
+	got := accountTokens([]string{" John.Doe@Mail.com ", "Al", "Doe"})
+	want := []string{"john.doe@mail.com", "john.doe", "john", "doe", "doe"}
+
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("accountTokens = %v, want %v", got, want)
+	}
+}
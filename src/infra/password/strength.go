@@ -0,0 +1,141 @@
+package password
+
+import (
+	"math"
+	"strings"
+)
+
+// commonWords adalah password dan kata yang paling sering dipakai. Bagian password yang cocok
+// (setelah leetspeak dikembalikan, misalnya "p@ssw0rd") dihitung sebagai satu tebakan dari daftar ini.
+var commonWords = []string{
+	"password", "passw0rd", "qwerty", "letmein", "welcome", "admin", "administrator", "login",
+	"iloveyou", "monkey", "dragon", "master", "sunshine", "princess", "football", "baseball",
+	"superman", "batman", "trustno1", "shadow", "michael", "jennifer", "jordan", "hunter",
+	"freedom", "whatever", "starwars", "computer", "internet", "secret", "summer", "winter",
+	"spring", "autumn", "hello", "charlie", "cheese", "pokemon", "soccer", "killer", "pepper",
+	"ginger", "flower", "mustang", "access", "changeme", "default", "guest", "root", "test",
+	"user", "abc", "love", "god", "sayang", "rahasia", "indonesia", "jakarta", "bismillah",
+	"garuda", "cinta", "asdf", "zxcv", "qazwsx", "azerty", "111111", "123123", "654321",
+	"666666", "696969", "987654", "112233", "121212", "000000", "2020", "2021", "2022",
+	"2023", "2024", "2025", "2026",
+}
+
+// keyboardRows dipakai untuk mengenali urutan tombol seperti "qwerty" atau "asdf"
+var keyboardRows = []string{"1234567890", "qwertyuiop", "asdfghjkl", "zxcvbnm"}
+
+var leetspeak = strings.NewReplacer("0", "o", "1", "i", "3", "e", "4", "a", "5", "s", "7", "t", "@", "a", "$", "s", "!", "i")
+
+// Score memperkirakan kekuatan password dengan skala 0-4 seperti zxcvbn, berdasarkan perkiraan
+// jumlah tebakan: kata umum dan email/nama user dihitung sebagai satu tebakan dari daftar kata,
+// karakter berulang atau berurutan (aaa, abc, 123, qwerty) dihitung sebagai satu karakter, dan
+// karakter lain masing-masing 10 tebakan (kardinalitas bruteforce zxcvbn).
+func Score(password string, userInputs ...string) int {
+	if password == "" {
+		return 0
+	}
+
+	runes := []rune(password)
+	lower := []rune(strings.ToLower(password))
+	plain := []rune(leetspeak.Replace(strings.ToLower(password)))
+	if len(plain) != len(lower) {
+		plain = lower
+	}
+
+	dictionary := append(append([]string{}, commonWords...), accountTokens(userInputs)...)
+	matched := make([]bool, len(runes))
+
+	// log10 dari perkiraan jumlah tebakan
+	guesses := 0.0
+
+	// kata umum dan data akun, kata terpanjang dicocokkan lebih dulu
+	for _, word := range sortByLength(dictionary) {
+		target := []rune(word)
+		for i := 0; i+len(target) <= len(plain); i++ {
+			if !matchAt(plain, lower, matched, i, target) {
+				continue
+			}
+
+			for j := i; j < i+len(target); j++ {
+				matched[j] = true
+			}
+
+			guesses += math.Log10(float64(len(dictionary)))
+			if runes[i] != lower[i] {
+				guesses += math.Log10(2)
+			}
+			if string(plain[i:i+len(target)]) != string(lower[i:i+len(target)]) {
+				guesses += math.Log10(2)
+			}
+		}
+	}
+
+	for i := 0; i < len(runes); {
+		if matched[i] {
+			i++
+			continue
+		}
+
+		end := i + 1
+		for end < len(runes) && !matched[end] && (lower[end] == lower[end-1] || isSequence(lower[end-1], lower[end])) {
+			end++
+		}
+
+		guesses += 1 + math.Log10(float64(end-i))
+		i = end
+	}
+
+	switch {
+	case guesses < 3:
+		return 0
+	case guesses < 6:
+		return 1
+	case guesses < 8:
+		return 2
+	case guesses < 10:
+		return 3
+	}
+
+	return 4
+}
+
+func matchAt(plain, lower []rune, matched []bool, i int, target []rune) bool {
+	for j, r := range target {
+		if matched[i+j] || (plain[i+j] != r && lower[i+j] != r) {
+			return false
+		}
+	}
+	return true
+}
+
+func sortByLength(words []string) []string {
+	sorted := append([]string{}, words...)
+	for i := 1; i < len(sorted); i++ {
+		for j := i; j > 0 && len(sorted[j]) > len(sorted[j-1]); j-- {
+			sorted[j], sorted[j-1] = sorted[j-1], sorted[j]
+		}
+	}
+	return sorted
+}
+
+// isSequence bernilai true jika b adalah tombol/karakter tepat sebelum atau sesudah a
+func isSequence(a, b rune) bool {
+	if sameRange(a, b, 'a', 'z') || sameRange(a, b, '0', '9') {
+		if b-a == 1 || a-b == 1 {
+			return true
+		}
+	}
+
+	for _, row := range keyboardRows {
+		i := strings.IndexRune(row, a)
+		j := strings.IndexRune(row, b)
+		if i >= 0 && j >= 0 && (i-j == 1 || j-i == 1) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func sameRange(a, b, from, to rune) bool {
+	return a >= from && a <= to && b >= from && b <= to
+}
@@ -0,0 +1,56 @@
+package password
+
+import "testing"
+
+func TestScore(t *testing.T) {
+	tests := []struct {
+		name       string
+		password   string
+		userInputs []string
+		want       int
+	}{
+		{name: "empty", password: "", want: 0},
+		{name: "common word", password: "password", want: 0},
+		{name: "common word with leetspeak and case", password: "P@ssw0rd", want: 0},
+		{name: "repeated character", password: "aaaaaaaaaaaa", want: 0},
+		{name: "digit sequence", password: "12345678", want: 0},
+		{name: "letter sequence", password: "abcdefgh", want: 1},
+		{name: "keyboard row", password: "qwertyuiop", want: 1},
+		{name: "common word with digits", password: "sayang123", want: 1},
+		{name: "short random", password: "Xy7$kP2q", want: 2},
+		{name: "long random", password: "kT9#vQ2!mZ", want: 4},
+		{name: "passphrase", password: "correct horse battery staple", want: 4},
+		{name: "name without account data", password: "john.doe2024", want: 4},
+		{name: "name with account data", password: "john.doe2024", userInputs: []string{"john.doe@mail.com", "John", "Doe"}, want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Score(tt.password, tt.userInputs...); got != tt.want {
+				t.Fatalf("Score(%q) = %d, want %d", tt.password, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsSequence(t *testing.T) {
+	tests := []struct {
+		a, b rune
+		want bool
+	}{
+		{a: 'a', b: 'b', want: true},
+		{a: 'b', b: 'a', want: true},
+		{a: '4', b: '5', want: true},
+		{a: 'q', b: 'w', want: true},
+		{a: 'l', b: 'k', want: true},
+		{a: 'a', b: 'c', want: false},
+		{a: '9', b: 'a', want: false},
+		{a: 'p', b: 'a', want: false},
+	}
+
+	for _, tt := range tests {
+		if got := isSequence(tt.a, tt.b); got != tt.want {
+			t.Errorf("isSequence(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
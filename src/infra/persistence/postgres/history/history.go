@@ -10,24 +10,24 @@ import (
 )
 
 type HistoryRepository interface {
-	Create(userId int64, ipAddress, userAgent string) error
-	UpdateLogoutByUserIdAndUserAgent(userId int64, logoutReason, userAgent string) error
+	Create(userId int64, sessionId, ipAddress, userAgent, authMethod string) error
+	UpdateLogoutBySessionId(sessionId, logoutReason string) error
 	GetByUserId(useId int64) ([]*models.UserLoginHistory, error)
 	UpdateLogoutByUserId(userId int64, logoutReason string) error
 }
 
 const (
-	Create                           = `INSERT INTO user_login_history (user_id, login_time, ip_address, user_agent) VALUES ($1, now(), $2, $3)`
-	UpdateLogoutByUserIdAndUserAgent = `UPDATE user_login_history SET logout_time = NOW(), logout_reason = $1 WHERE user_id = $2 AND user_agent = $3 AND logout_time IS NULL`
-	GetByUserId                      = `SELECT * FROM user_login_history WHERE user_id = $1 AND logout_time IS NULL ORDER BY login_time`
-	UpdateLogoutByUserId             = "UPDATE user_login_history SET logout_time = NOW(), logout_reason = $1 WHERE user_id = $2 AND logout_time IS NULL"
+	Create                  = `INSERT INTO user_login_history (user_id, session_id, login_time, ip_address, user_agent, auth_method) VALUES ($1, $2, now(), $3, $4, $5)`
+	UpdateLogoutBySessionId = `UPDATE user_login_history SET logout_time = NOW(), logout_reason = $1 WHERE session_id = $2 AND logout_time IS NULL`
+	GetByUserId             = `SELECT * FROM user_login_history WHERE user_id = $1 AND logout_time IS NULL ORDER BY login_time`
+	UpdateLogoutByUserId    = "UPDATE user_login_history SET logout_time = NOW(), logout_reason = $1 WHERE user_id = $2 AND logout_time IS NULL"
 )
 
 type PreparedStatement struct {
-	create                           *sqlx.Stmt
-	updateLogoutByUserIdAndUserAgent *sqlx.Stmt
-	getByUserId                      *sqlx.Stmt
-	updateLogoutByUserId             *sqlx.Stmt
+	create                  *sqlx.Stmt
+	updateLogoutBySessionId *sqlx.Stmt
+	getByUserId             *sqlx.Stmt
+	updateLogoutByUserId    *sqlx.Stmt
 }
 
 type historyRepo struct {
@@ -64,15 +64,15 @@ func (p *historyRepo) Preparex(query string, isMaster bool) *sqlx.Stmt {
 
 func InitPreparedStatement(m *historyRepo) {
 	m.statement = PreparedStatement{
-		create:                           m.Preparex(Create, common.IsMasterDb),
-		updateLogoutByUserIdAndUserAgent: m.Preparex(UpdateLogoutByUserIdAndUserAgent, common.IsMasterDb),
-		getByUserId:                      m.Preparex(GetByUserId, common.NotIsMasterDb),
-		updateLogoutByUserId:             m.Preparex(UpdateLogoutByUserId, common.IsMasterDb),
+		create:                  m.Preparex(Create, common.IsMasterDb),
+		updateLogoutBySessionId: m.Preparex(UpdateLogoutBySessionId, common.IsMasterDb),
+		getByUserId:             m.Preparex(GetByUserId, common.NotIsMasterDb),
+		updateLogoutByUserId:    m.Preparex(UpdateLogoutByUserId, common.IsMasterDb),
 	}
 }
 
-func (p *historyRepo) Create(userId int64, ipAddress, userAgent string) error {
-	_, err := p.statement.create.Exec(userId, ipAddress, userAgent)
+func (p *historyRepo) Create(userId int64, sessionId, ipAddress, userAgent, authMethod string) error {
+	_, err := p.statement.create.Exec(userId, sessionId, ipAddress, userAgent, authMethod)
 	if err != nil {
 		return err
 	}
@@ -80,8 +80,8 @@ func (p *historyRepo) Create(userId int64, ipAddress, userAgent string) error {
 	return nil
 }
 
-func (p *historyRepo) UpdateLogoutByUserIdAndUserAgent(userId int64, logoutReason, userAgent string) error {
-	_, err := p.statement.updateLogoutByUserIdAndUserAgent.Exec(logoutReason, userId, userAgent)
+func (p *historyRepo) UpdateLogoutBySessionId(sessionId, logoutReason string) error {
+	_, err := p.statement.updateLogoutBySessionId.Exec(logoutReason, sessionId)
 	if err != nil {
 		return err
 	}
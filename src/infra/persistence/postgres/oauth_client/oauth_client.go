@@ -0,0 +1,77 @@
+package oauth_client
+
+import (
+	"errors"
+	"github.com/jmoiron/sqlx"
+	"log"
+
+	"go-auth-service/src/infra/constants/common"
+	errorMessage "go-auth-service/src/infra/constants/error_message"
+	"go-auth-service/src/infra/models"
+	"go-auth-service/src/infra/persistence/postgres"
+)
+
+type OAuthClientRepository interface {
+	GetByClientId(clientId string) (*models.OAuthClient, error)
+}
+
+const (
+	GetByClientId = `SELECT * FROM oauth_client WHERE client_id = $1 AND is_active = TRUE AND deleted_at IS NULL`
+)
+
+type PreparedStatement struct {
+	getByClientId *sqlx.Stmt
+}
+
+type oauthClientRepo struct {
+	Connection *postgres.Connection
+	statement  PreparedStatement
+}
+
+func NewOAuthClientRepository(db *postgres.Connection) OAuthClientRepository {
+	repo := &oauthClientRepo{
+		Connection: db,
+	}
+	InitPreparedStatement(repo)
+	return repo
+}
+
+func (p *oauthClientRepo) Preparex(query string, isMaster bool) *sqlx.Stmt {
+	if !isMaster {
+		// for slave
+		statement, err := p.Connection.GetPrimarySlave().Preparex(query)
+		if err != nil {
+			log.Fatalf("Failed to preparex query: %s. Error: %s", query, err.Error())
+		}
+
+		return statement
+	}
+
+	statement, err := p.Connection.GetPrimaryMaster().Preparex(query)
+	if err != nil {
+		log.Fatalf("Failed to preparex query: %s. Error: %s", query, err.Error())
+	}
+
+	return statement
+}
+
+func InitPreparedStatement(m *oauthClientRepo) {
+	m.statement = PreparedStatement{
+		getByClientId: m.Preparex(GetByClientId, common.NotIsMasterDb),
+	}
+}
+
+func (p *oauthClientRepo) GetByClientId(clientId string) (*models.OAuthClient, error) {
+	var client []*models.OAuthClient
+
+	err := p.statement.getByClientId.Select(&client, clientId)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(client) < 1 {
+		return nil, errors.New(errorMessage.OAuthClientNotFound)
+	}
+
+	return client[0], nil
+}
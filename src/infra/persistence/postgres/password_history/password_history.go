@@ -0,0 +1,98 @@
+package password_history
+
+import (
+	"github.com/jmoiron/sqlx"
+	"log"
+
+	"go-auth-service/src/infra/constants/common"
+	"go-auth-service/src/infra/persistence/postgres"
+)
+
+type PasswordHistoryRepository interface {
+	Create(userId int64, passwordHash string) error
+	GetRecentByUserId(userId int64, limit int) ([]string, error)
+	DeleteOlderByUserId(userId int64, keep int) error
+}
+
+const (
+	Create              = `INSERT INTO user_password_history (user_id, password_hash) VALUES ($1, $2)`
+	GetRecentByUserId   = `SELECT password_hash FROM user_password_history WHERE user_id = $1 ORDER BY id DESC LIMIT $2`
+	DeleteOlderByUserId = `DELETE FROM user_password_history WHERE user_id = $1 AND id NOT IN (SELECT id FROM user_password_history WHERE user_id = $1 ORDER BY id DESC LIMIT $2)`
+)
+
+type PreparedStatement struct {
+	create              *sqlx.Stmt
+	getRecentByUserId   *sqlx.Stmt
+	deleteOlderByUserId *sqlx.Stmt
+}
+
+type passwordHistoryRepo struct {
+	Connection *postgres.Connection
+	statement  PreparedStatement
+}
+
+func NewPasswordHistoryRepository(db *postgres.Connection) PasswordHistoryRepository {
+	repo := &passwordHistoryRepo{
+		Connection: db,
+	}
+	InitPreparedStatement(repo)
+	return repo
+}
+
+func (p *passwordHistoryRepo) Preparex(query string, isMaster bool) *sqlx.Stmt {
+	if !isMaster {
+		// for slave
+		statement, err := p.Connection.GetPrimarySlave().Preparex(query)
+		if err != nil {
+			log.Fatalf("Failed to preparex query: %s. Error: %s", query, err.Error())
+		}
+
+		return statement
+	}
+
+	statement, err := p.Connection.GetPrimaryMaster().Preparex(query)
+	if err != nil {
+		log.Fatalf("Failed to preparex query: %s. Error: %s", query, err.Error())
+	}
+
+	return statement
+}
+
+func InitPreparedStatement(m *passwordHistoryRepo) {
+	m.statement = PreparedStatement{
+		create:              m.Preparex(Create, common.IsMasterDb),
+		getRecentByUserId:   m.Preparex(GetRecentByUserId, common.IsMasterDb),
+		deleteOlderByUserId: m.Preparex(DeleteOlderByUserId, common.IsMasterDb),
+	}
+}
+
+func (p *passwordHistoryRepo) Create(userId int64, passwordHash string) error {
+	_, err := p.statement.create.Exec(userId, passwordHash)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetRecentByUserId mengembalikan hash password terbaru lebih dulu
+func (p *passwordHistoryRepo) GetRecentByUserId(userId int64, limit int) ([]string, error) {
+	var hashes []string
+
+	err := p.statement.getRecentByUserId.Select(&hashes, userId, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return hashes, nil
+}
+
+// DeleteOlderByUserId hanya menyisakan keep hash password terbaru
+func (p *passwordHistoryRepo) DeleteOlderByUserId(userId int64, keep int) error {
+	_, err := p.statement.deleteOlderByUserId.Exec(userId, keep)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,137 @@
+package recovery_code
+
+import (
+	"fmt"
+	"github.com/jmoiron/sqlx"
+	"log"
+
+	"go-auth-service/src/infra/constants/common"
+	"go-auth-service/src/infra/persistence/postgres"
+)
+
+type RecoveryCodeRepository interface {
+	ReplaceByUserId(userId int64, codeHashes []string) error
+	Use(userId int64, codeHash string) (bool, error)
+	CountUnusedByUserId(userId int64) (int, error)
+}
+
+const (
+	Create              = `INSERT INTO user_recovery_code (user_id, code_hash) VALUES ($1, $2)`
+	DeleteByUserId      = `DELETE FROM user_recovery_code WHERE user_id = $1`
+	Use                 = `UPDATE user_recovery_code SET used_at = now() WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`
+	CountUnusedByUserId = `SELECT COUNT(*) FROM user_recovery_code WHERE user_id = $1 AND used_at IS NULL`
+)
+
+type PreparedStatement struct {
+	create              *sqlx.Stmt
+	deleteByUserId      *sqlx.Stmt
+	use                 *sqlx.Stmt
+	countUnusedByUserId *sqlx.Stmt
+}
+
+type recoveryCodeRepo struct {
+	Connection *postgres.Connection
+	statement  PreparedStatement
+}
+
+func NewRecoveryCodeRepository(db *postgres.Connection) RecoveryCodeRepository {
+	repo := &recoveryCodeRepo{
+		Connection: db,
+	}
+	InitPreparedStatement(repo)
+	return repo
+}
+
+func (p *recoveryCodeRepo) Preparex(query string, isMaster bool) *sqlx.Stmt {
+	if !isMaster {
+		// for slave
+		statement, err := p.Connection.GetPrimarySlave().Preparex(query)
+		if err != nil {
+			log.Fatalf("Failed to preparex query: %s. Error: %s", query, err.Error())
+		}
+
+		return statement
+	}
+
+	statement, err := p.Connection.GetPrimaryMaster().Preparex(query)
+	if err != nil {
+		log.Fatalf("Failed to preparex query: %s. Error: %s", query, err.Error())
+	}
+
+	return statement
+}
+
+func InitPreparedStatement(m *recoveryCodeRepo) {
+	m.statement = PreparedStatement{
+		create:              m.Preparex(Create, common.IsMasterDb),
+		deleteByUserId:      m.Preparex(DeleteByUserId, common.IsMasterDb),
+		use:                 m.Preparex(Use, common.IsMasterDb),
+		countUnusedByUserId: m.Preparex(CountUnusedByUserId, common.IsMasterDb),
+	}
+}
+
+// ReplaceByUserId menghapus seluruh recovery code lama lalu menyimpan set baru dalam satu transaksi
+func (p *recoveryCodeRepo) ReplaceByUserId(userId int64, codeHashes []string) (err error) {
+	tx, err := p.Connection.GetPrimaryMaster().Beginx()
+	if err != nil {
+		log.Println("Failed to begin transaction:", err)
+		return err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+			log.Println("Recovered in ReplaceByUserId:", r)
+			err = fmt.Errorf("panic occurred: %v", r)
+		} else if err != nil {
+			tx.Rollback()
+			log.Println("Rolling back transaction due to:", err)
+		} else {
+			err = tx.Commit()
+			if err != nil {
+				log.Println("Failed to commit transaction:", err)
+			}
+		}
+	}()
+
+	_, err = tx.Stmtx(p.statement.deleteByUserId).Exec(userId)
+	if err != nil {
+		return err
+	}
+
+	create := tx.Stmtx(p.statement.create)
+	for _, codeHash := range codeHashes {
+		_, err = create.Exec(userId, codeHash)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Use menandai recovery code sudah dipakai. Mengembalikan false jika code tidak ada atau sudah dipakai.
+func (p *recoveryCodeRepo) Use(userId int64, codeHash string) (bool, error) {
+	result, err := p.statement.use.Exec(userId, codeHash)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected == 1, nil
+}
+
+func (p *recoveryCodeRepo) CountUnusedByUserId(userId int64) (int, error) {
+	var count int
+
+	err := p.statement.countUnusedByUserId.Get(&count, userId)
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
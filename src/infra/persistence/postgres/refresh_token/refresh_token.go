@@ -13,24 +13,27 @@ import (
 )
 
 type RefreshTokenRepository interface {
-	Create(userId int64, refreshTokenHash, userAgent string) error
-	GetTokenActive(userId int64, userAgent string) (*models.UserRefreshToken, error)
-	UpdateStatus(userId int64, userAgent string) error
+	Create(userId int64, sessionId, refreshTokenHash string) error
 	UpdateStatusByUserId(userId int64) error
+	UpdateStatusBySessionId(sessionId string) error
+	GetByHash(refreshTokenHash string) (*models.UserRefreshToken, error)
+	MarkUsed(id int64) (bool, error)
 }
 
 const (
-	Create               = `INSERT INTO user_refresh_token (user_id, refresh_token_hash, expires_at, user_agent) VALUES ($1, $2, $3, $4)`
-	GetTokenActive       = `SELECT * FROM user_refresh_token WHERE user_id = $1 AND user_agent = $2 AND is_active = TRUE`
-	UpdateStatus         = `UPDATE user_refresh_token SET is_active = FALSE WHERE user_id = $1 AND user_agent = $2`
-	UpdateStatusByUserId = `UPDATE user_refresh_token SET is_active = FALSE WHERE user_id = $1`
+	Create                  = `INSERT INTO user_refresh_token (user_id, session_id, refresh_token_hash, expires_at) VALUES ($1, $2, $3, $4)`
+	UpdateStatusByUserId    = `UPDATE user_refresh_token SET is_active = FALSE WHERE user_id = $1`
+	UpdateStatusBySessionId = `UPDATE user_refresh_token SET is_active = FALSE WHERE session_id = $1`
+	GetByHash               = `SELECT * FROM user_refresh_token WHERE refresh_token_hash = $1`
+	MarkUsed                = `UPDATE user_refresh_token SET used_at = NOW(), is_active = FALSE WHERE id = $1 AND used_at IS NULL AND is_active = TRUE`
 )
 
 type PreparedStatement struct {
-	create               *sqlx.Stmt
-	getTokenActive       *sqlx.Stmt
-	updateStatus         *sqlx.Stmt
-	updateStatusByUserId *sqlx.Stmt
+	create                  *sqlx.Stmt
+	updateStatusByUserId    *sqlx.Stmt
+	updateStatusBySessionId *sqlx.Stmt
+	getByHash               *sqlx.Stmt
+	markUsed                *sqlx.Stmt
 }
 
 type refreshTokenRepo struct {
@@ -67,16 +70,18 @@ func (p *refreshTokenRepo) Preparex(query string, isMaster bool) *sqlx.Stmt {
 
 func InitPreparedStatement(m *refreshTokenRepo) {
 	m.statement = PreparedStatement{
-		create:               m.Preparex(Create, common.IsMasterDb),
-		getTokenActive:       m.Preparex(GetTokenActive, common.NotIsMasterDb),
-		updateStatus:         m.Preparex(UpdateStatus, common.IsMasterDb),
-		updateStatusByUserId: m.Preparex(UpdateStatusByUserId, common.IsMasterDb),
+		create:                  m.Preparex(Create, common.IsMasterDb),
+		updateStatusByUserId:    m.Preparex(UpdateStatusByUserId, common.IsMasterDb),
+		updateStatusBySessionId: m.Preparex(UpdateStatusBySessionId, common.IsMasterDb),
+		// dibaca dari master agar status used_at selalu terbaru untuk deteksi reuse
+		getByHash: m.Preparex(GetByHash, common.IsMasterDb),
+		markUsed:  m.Preparex(MarkUsed, common.IsMasterDb),
 	}
 }
 
-func (p *refreshTokenRepo) Create(userId int64, refreshTokenHash, userAgent string) error {
+func (p *refreshTokenRepo) Create(userId int64, sessionId, refreshTokenHash string) error {
 	expiresAt := time.Now().Add(common.RefreshTokenExp)
-	_, err := p.statement.create.Exec(userId, refreshTokenHash, expiresAt, userAgent)
+	_, err := p.statement.create.Exec(userId, sessionId, refreshTokenHash, expiresAt)
 	if err != nil {
 		return err
 	}
@@ -84,10 +89,19 @@ func (p *refreshTokenRepo) Create(userId int64, refreshTokenHash, userAgent stri
 	return nil
 }
 
-func (p *refreshTokenRepo) GetTokenActive(userId int64, userAgent string) (*models.UserRefreshToken, error) {
+func (p *refreshTokenRepo) UpdateStatusByUserId(userId int64) error {
+	_, err := p.statement.updateStatusByUserId.Exec(userId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (p *refreshTokenRepo) GetByHash(refreshTokenHash string) (*models.UserRefreshToken, error) {
 	var refreshToken []*models.UserRefreshToken
 
-	err := p.statement.getTokenActive.Select(&refreshToken, userId, userAgent)
+	err := p.statement.getByHash.Select(&refreshToken, refreshTokenHash)
 	if err != nil {
 		return nil, err
 	}
@@ -99,8 +113,8 @@ func (p *refreshTokenRepo) GetTokenActive(userId int64, userAgent string) (*mode
 	return refreshToken[0], nil
 }
 
-func (p *refreshTokenRepo) UpdateStatus(userId int64, userAgent string) error {
-	_, err := p.statement.updateStatus.Exec(userId, userAgent)
+func (p *refreshTokenRepo) UpdateStatusBySessionId(sessionId string) error {
+	_, err := p.statement.updateStatusBySessionId.Exec(sessionId)
 	if err != nil {
 		return err
 	}
@@ -108,11 +122,18 @@ func (p *refreshTokenRepo) UpdateStatus(userId int64, userAgent string) error {
 	return nil
 }
 
-func (p *refreshTokenRepo) UpdateStatusByUserId(userId int64) error {
-	_, err := p.statement.updateStatusByUserId.Exec(userId)
+// MarkUsed menandai refresh token sudah dipakai. Mengembalikan false jika token sudah
+// dipakai atau dinonaktifkan sebelumnya, termasuk ketika dua request refresh berjalan bersamaan.
+func (p *refreshTokenRepo) MarkUsed(id int64) (bool, error) {
+	result, err := p.statement.markUsed.Exec(id)
 	if err != nil {
-		return err
+		return false, err
 	}
 
-	return nil
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected == 1, nil
 }
@@ -0,0 +1,151 @@
+package session
+
+import (
+	"errors"
+	"github.com/jmoiron/sqlx"
+	"log"
+	"time"
+
+	"go-auth-service/src/infra/constants/common"
+	errorMessage "go-auth-service/src/infra/constants/error_message"
+	"go-auth-service/src/infra/models"
+	"go-auth-service/src/infra/persistence/postgres"
+)
+
+type SessionRepository interface {
+	Create(data *models.UserSession) error
+	GetActiveById(id string) (*models.UserSession, error)
+	GetActiveByUserId(userId int64) ([]*models.UserSession, error)
+	UpdateLastSeen(id string) error
+	Revoke(id, revokeReason string) error
+	RevokeByUserId(userId int64, revokeReason string) error
+}
+
+const (
+	Create            = `INSERT INTO user_session (id, user_id, device_label, ip_address, user_agent, client_id, scope, expires_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+	GetActiveById     = `SELECT * FROM user_session WHERE id = $1 AND revoked_at IS NULL AND expires_at > NOW()`
+	GetActiveByUserId = `SELECT * FROM user_session WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW() ORDER BY last_seen_at DESC`
+	UpdateLastSeen    = `UPDATE user_session SET last_seen_at = NOW() WHERE id = $1`
+	Revoke            = `UPDATE user_session SET revoked_at = NOW(), revoke_reason = $1 WHERE id = $2 AND revoked_at IS NULL`
+	RevokeByUserId    = `UPDATE user_session SET revoked_at = NOW(), revoke_reason = $1 WHERE user_id = $2 AND revoked_at IS NULL`
+)
+
+type PreparedStatement struct {
+	create            *sqlx.Stmt
+	getActiveById     *sqlx.Stmt
+	getActiveByUserId *sqlx.Stmt
+	updateLastSeen    *sqlx.Stmt
+	revoke            *sqlx.Stmt
+	revokeByUserId    *sqlx.Stmt
+}
+
+type sessionRepo struct {
+	Connection *postgres.Connection
+	statement  PreparedStatement
+}
+
+func NewSessionRepository(db *postgres.Connection) SessionRepository {
+	repo := &sessionRepo{
+		Connection: db,
+	}
+	InitPreparedStatement(repo)
+	return repo
+}
+
+func (p *sessionRepo) Preparex(query string, isMaster bool) *sqlx.Stmt {
+	if !isMaster {
+		// for slave
+		statement, err := p.Connection.GetPrimarySlave().Preparex(query)
+		if err != nil {
+			log.Fatalf("Failed to preparex query: %s. Error: %s", query, err.Error())
+		}
+
+		return statement
+	}
+
+	statement, err := p.Connection.GetPrimaryMaster().Preparex(query)
+	if err != nil {
+		log.Fatalf("Failed to preparex query: %s. Error: %s", query, err.Error())
+	}
+
+	return statement
+}
+
+func InitPreparedStatement(m *sessionRepo) {
+	m.statement = PreparedStatement{
+		create: m.Preparex(Create, common.IsMasterDb),
+		// dibaca dari master agar sesi yang baru dicabut langsung ditolak
+		getActiveById:     m.Preparex(GetActiveById, common.IsMasterDb),
+		getActiveByUserId: m.Preparex(GetActiveByUserId, common.NotIsMasterDb),
+		updateLastSeen:    m.Preparex(UpdateLastSeen, common.IsMasterDb),
+		revoke:            m.Preparex(Revoke, common.IsMasterDb),
+		revokeByUserId:    m.Preparex(RevokeByUserId, common.IsMasterDb),
+	}
+}
+
+func (p *sessionRepo) Create(data *models.UserSession) error {
+	expiresAt := time.Now().Add(common.RefreshTokenExp)
+	_, err := p.statement.create.Exec(data.Id, data.UserId, data.DeviceLabel, data.IpAddress, data.UserAgent, data.ClientId, data.Scope, expiresAt)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (p *sessionRepo) GetActiveById(id string) (*models.UserSession, error) {
+	var session []*models.UserSession
+
+	err := p.statement.getActiveById.Select(&session, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(session) < 1 {
+		return nil, errors.New(errorMessage.SessionNotFound)
+	}
+
+	return session[0], nil
+}
+
+func (p *sessionRepo) GetActiveByUserId(userId int64) ([]*models.UserSession, error) {
+	var sessions []*models.UserSession
+
+	err := p.statement.getActiveByUserId.Select(&sessions, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sessions) == 0 {
+		return []*models.UserSession{}, nil
+	}
+
+	return sessions, nil
+}
+
+func (p *sessionRepo) UpdateLastSeen(id string) error {
+	_, err := p.statement.updateLastSeen.Exec(id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (p *sessionRepo) Revoke(id, revokeReason string) error {
+	_, err := p.statement.revoke.Exec(revokeReason, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (p *sessionRepo) RevokeByUserId(userId int64, revokeReason string) error {
+	_, err := p.statement.revokeByUserId.Exec(revokeReason, userId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,107 @@
+package totp
+
+import (
+	"errors"
+	"github.com/jmoiron/sqlx"
+	"log"
+
+	"go-auth-service/src/infra/constants/common"
+	errorMessage "go-auth-service/src/infra/constants/error_message"
+	"go-auth-service/src/infra/models"
+	"go-auth-service/src/infra/persistence/postgres"
+)
+
+type TotpRepository interface {
+	Upsert(userId int64, secret string) error
+	GetByUserId(userId int64) (*models.UserTotp, error)
+	Confirm(userId int64) error
+}
+
+const (
+	// secret yang sudah dikonfirmasi tidak ikut tertimpa oleh enrollment ulang
+	Upsert = `INSERT INTO user_totp (user_id, secret) VALUES ($1, $2)
+				ON CONFLICT (user_id) DO UPDATE SET secret = EXCLUDED.secret, updated_at = now()
+				WHERE user_totp.confirmed_at IS NULL`
+	GetByUserId = `SELECT * FROM user_totp WHERE user_id = $1`
+	Confirm     = `UPDATE user_totp SET confirmed_at = now(), updated_at = now() WHERE user_id = $1 AND confirmed_at IS NULL`
+)
+
+type PreparedStatement struct {
+	upsert      *sqlx.Stmt
+	getByUserId *sqlx.Stmt
+	confirm     *sqlx.Stmt
+}
+
+type totpRepo struct {
+	Connection *postgres.Connection
+	statement  PreparedStatement
+}
+
+func NewTotpRepository(db *postgres.Connection) TotpRepository {
+	repo := &totpRepo{
+		Connection: db,
+	}
+	InitPreparedStatement(repo)
+	return repo
+}
+
+func (p *totpRepo) Preparex(query string, isMaster bool) *sqlx.Stmt {
+	if !isMaster {
+		// for slave
+		statement, err := p.Connection.GetPrimarySlave().Preparex(query)
+		if err != nil {
+			log.Fatalf("Failed to preparex query: %s. Error: %s", query, err.Error())
+		}
+
+		return statement
+	}
+
+	statement, err := p.Connection.GetPrimaryMaster().Preparex(query)
+	if err != nil {
+		log.Fatalf("Failed to preparex query: %s. Error: %s", query, err.Error())
+	}
+
+	return statement
+}
+
+func InitPreparedStatement(m *totpRepo) {
+	m.statement = PreparedStatement{
+		upsert: m.Preparex(Upsert, common.IsMasterDb),
+		// dibaca dari master agar konfirmasi enrollment langsung berlaku saat login
+		getByUserId: m.Preparex(GetByUserId, common.IsMasterDb),
+		confirm:     m.Preparex(Confirm, common.IsMasterDb),
+	}
+}
+
+func (p *totpRepo) Upsert(userId int64, secret string) error {
+	_, err := p.statement.upsert.Exec(userId, secret)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (p *totpRepo) GetByUserId(userId int64) (*models.UserTotp, error) {
+	var totp []*models.UserTotp
+
+	err := p.statement.getByUserId.Select(&totp, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(totp) < 1 {
+		return nil, errors.New(errorMessage.TotpNotFound)
+	}
+
+	return totp[0], nil
+}
+
+func (p *totpRepo) Confirm(userId int64) error {
+	_, err := p.statement.confirm.Exec(userId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
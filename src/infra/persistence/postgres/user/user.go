@@ -16,20 +16,27 @@ import (
 )
 
 type UserRepository interface {
-	Create(data *dtoUser.RegisterReq) (userId int64, err error)
+	Create(data *dtoUser.RegisterReq, passwordHash string) (userId int64, err error)
 	GetByEmail(email string) (*models.User, error)
 	GetById(id int64) (*models.User, error)
 	GetUserDetailById(id int64) (*dtoUser.UserDetails, error)
 	UpdateProfileByUserId(userId int64, firstName, lastName, birthDate, gender string) error
 	UpdateProfilePictureByUserId(userId int64, path string) error
 	UpdatePasswordByUserId(userId int64, password string) error
+	RehashPasswordByUserId(userId int64, oldPassword, newPassword string) error
+	SetPasswordResetRequired(userId int64) error
+	UpdateVerifiedByUserId(userId int64) error
+	GetByPhone(phone string) (*models.User, error)
+	UpdatePhoneByUserId(userId int64, phone string) error
+	DeletePhoneByUserId(userId int64) error
 }
 
 const (
 	CreateUser       = `INSERT INTO user_auth (email, password) VALUES ($1, $2) RETURNING id`
 	CreateUserDetail = `INSERT INTO user_detail (user_id, first_name, last_name, user_type_id) VALUES ($1, $2, $3, 3)`
-	GetByEmail       = `SELECT * FROM user_auth WHERE email = $1 AND deleted_at IS NULL`
-	GetById          = `SELECT * FROM user_auth WHERE id = $1 AND deleted_at IS NULL`
+	GetByEmail       = `SELECT ua.*, COALESCE(ud.verified, FALSE) AS verified, ud.phone, COALESCE(ud.user_type_id, 3) AS user_type_id FROM user_auth ua LEFT JOIN user_detail ud ON ud.user_id = ua.id WHERE ua.email = $1 AND ua.deleted_at IS NULL`
+	GetById          = `SELECT ua.*, COALESCE(ud.verified, FALSE) AS verified, ud.phone, COALESCE(ud.user_type_id, 3) AS user_type_id FROM user_auth ua LEFT JOIN user_detail ud ON ud.user_id = ua.id WHERE ua.id = $1 AND ua.deleted_at IS NULL`
+	GetByPhone       = `SELECT ua.*, ud.verified, ud.phone, ud.user_type_id FROM user_auth ua JOIN user_detail ud ON ud.user_id = ua.id WHERE ud.phone = $1 AND ua.deleted_at IS NULL AND ud.deleted_at IS NULL`
 	GetUserDetail    = `SELECT
 							ua.id,
 							ua.email,
@@ -54,7 +61,12 @@ const (
 							ua.id = $1 AND ua.deleted_at IS NULL AND ud.deleted_at IS NULL`
 	UpdateUserDetailByUserId = `UPDATE user_detail SET first_name = $1, last_name = $2, birth_date = $3, gender = $4, updated_at = now() WHERE user_id = $5 AND deleted_at IS NULL`
 	UpdatePictureByUserId    = `UPDATE user_detail SET picture = $1, updated_at = now() WHERE user_id = $2 AND deleted_at IS NULL`
-	UpdatePasswordByUserId   = `UPDATE user_auth SET password = $1, updated_at = now() WHERE id = $2 AND deleted_at IS NULL`
+	UpdatePasswordByUserId   = `UPDATE user_auth SET password = $1, password_reset_required = FALSE, updated_at = now() WHERE id = $2 AND deleted_at IS NULL`
+	RehashPasswordByUserId   = `UPDATE user_auth SET password = $1 WHERE id = $2 AND password = $3 AND deleted_at IS NULL`
+	SetPasswordResetRequired = `UPDATE user_auth SET password_reset_required = TRUE, updated_at = now() WHERE id = $1 AND deleted_at IS NULL`
+	UpdateVerifiedByUserId   = `UPDATE user_detail SET verified = TRUE, updated_at = now() WHERE user_id = $1 AND deleted_at IS NULL`
+	UpdatePhoneByUserId      = `UPDATE user_detail SET phone = $1, updated_at = now() WHERE user_id = $2 AND deleted_at IS NULL`
+	DeletePhoneByUserId      = `UPDATE user_detail SET phone = NULL, updated_at = now() WHERE user_id = $1 AND deleted_at IS NULL`
 )
 
 type PreparedStatement struct {
@@ -66,6 +78,12 @@ type PreparedStatement struct {
 	updateUserDetailByUserId *sqlx.Stmt
 	updatePictureByUserId    *sqlx.Stmt
 	updatePasswordByUserId   *sqlx.Stmt
+	rehashPasswordByUserId   *sqlx.Stmt
+	setPasswordResetRequired *sqlx.Stmt
+	updateVerifiedByUserId   *sqlx.Stmt
+	getByPhone               *sqlx.Stmt
+	updatePhoneByUserId      *sqlx.Stmt
+	deletePhoneByUserId      *sqlx.Stmt
 }
 
 type userRepo struct {
@@ -110,15 +128,16 @@ func InitPreparedStatement(m *userRepo) {
 		updateUserDetailByUserId: m.Preparex(UpdateUserDetailByUserId, common.IsMasterDb),
 		updatePictureByUserId:    m.Preparex(UpdatePictureByUserId, common.IsMasterDb),
 		updatePasswordByUserId:   m.Preparex(UpdatePasswordByUserId, common.IsMasterDb),
+		rehashPasswordByUserId:   m.Preparex(RehashPasswordByUserId, common.IsMasterDb),
+		setPasswordResetRequired: m.Preparex(SetPasswordResetRequired, common.IsMasterDb),
+		updateVerifiedByUserId:   m.Preparex(UpdateVerifiedByUserId, common.IsMasterDb),
+		getByPhone:               m.Preparex(GetByPhone, common.NotIsMasterDb),
+		updatePhoneByUserId:      m.Preparex(UpdatePhoneByUserId, common.IsMasterDb),
+		deletePhoneByUserId:      m.Preparex(DeletePhoneByUserId, common.IsMasterDb),
 	}
 }
 
-func (p *userRepo) Create(data *dtoUser.RegisterReq) (userId int64, err error) {
-	pwd, err := helper.HashPassword(data.Password)
-	if err != nil {
-		return 0, err
-	}
-
+func (p *userRepo) Create(data *dtoUser.RegisterReq, passwordHash string) (userId int64, err error) {
 	tx, err := p.Connection.GetPrimaryMaster().Beginx()
 	if err != nil {
 		log.Println("Failed to begin transaction:", err)
@@ -142,7 +161,7 @@ func (p *userRepo) Create(data *dtoUser.RegisterReq) (userId int64, err error) {
 	}()
 
 	var resultData models.User
-	err = tx.QueryRowx(CreateUser, data.Email, pwd).Scan(&resultData.Id)
+	err = tx.QueryRowx(CreateUser, data.Email, passwordHash).Scan(&resultData.Id)
 	if err != nil {
 		log.Println("Failed to create user:", err)
 		return 0, err
@@ -271,3 +290,65 @@ func (p *userRepo) UpdatePasswordByUserId(userId int64, password string) error {
 
 	return nil
 }
+
+// RehashPasswordByUserId mengganti hash password yang sama dengan hash baru. Hash hanya diganti
+// jika masih sama dengan oldPassword sehingga perubahan password yang terjadi bersamaan tidak tertimpa.
+func (p *userRepo) RehashPasswordByUserId(userId int64, oldPassword, newPassword string) error {
+	_, err := p.statement.rehashPasswordByUserId.Exec(newPassword, userId, oldPassword)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (p *userRepo) SetPasswordResetRequired(userId int64) error {
+	_, err := p.statement.setPasswordResetRequired.Exec(userId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (p *userRepo) UpdateVerifiedByUserId(userId int64) error {
+	_, err := p.statement.updateVerifiedByUserId.Exec(userId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (p *userRepo) GetByPhone(phone string) (*models.User, error) {
+	var user []*models.User
+
+	err := p.statement.getByPhone.Select(&user, phone)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(user) < 1 {
+		return nil, errors.New(errorMessage.UserNotFound)
+	}
+
+	return user[0], nil
+}
+
+func (p *userRepo) UpdatePhoneByUserId(userId int64, phone string) error {
+	_, err := p.statement.updatePhoneByUserId.Exec(phone, userId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (p *userRepo) DeletePhoneByUserId(userId int64) error {
+	_, err := p.statement.deletePhoneByUserId.Exec(userId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,156 @@
+package webauthn
+
+import (
+	"errors"
+	"github.com/jmoiron/sqlx"
+	"log"
+
+	"go-auth-service/src/infra/constants/common"
+	errorMessage "go-auth-service/src/infra/constants/error_message"
+	"go-auth-service/src/infra/models"
+	"go-auth-service/src/infra/persistence/postgres"
+)
+
+type WebauthnRepository interface {
+	Create(data *models.UserWebauthnCredential) error
+	GetByCredentialId(credentialId string) (*models.UserWebauthnCredential, error)
+	GetByUserId(userId int64) ([]*models.UserWebauthnCredential, error)
+	UpdateSignCount(id, signCount int64) error
+	Delete(id, userId int64) (bool, error)
+	DeleteByUserId(userId int64) error
+}
+
+const (
+	Create            = `INSERT INTO user_webauthn_credential (user_id, credential_id, public_key, sign_count, transports, nickname) VALUES ($1, $2, $3, $4, $5, $6)`
+	GetByCredentialId = `SELECT * FROM user_webauthn_credential WHERE credential_id = $1`
+	GetByUserId       = `SELECT * FROM user_webauthn_credential WHERE user_id = $1 ORDER BY created_at DESC`
+	UpdateSignCount   = `UPDATE user_webauthn_credential SET sign_count = $1, last_used_at = now() WHERE id = $2`
+	Delete            = `DELETE FROM user_webauthn_credential WHERE id = $1 AND user_id = $2`
+	DeleteByUserId    = `DELETE FROM user_webauthn_credential WHERE user_id = $1`
+)
+
+type PreparedStatement struct {
+	create            *sqlx.Stmt
+	getByCredentialId *sqlx.Stmt
+	getByUserId       *sqlx.Stmt
+	updateSignCount   *sqlx.Stmt
+	delete            *sqlx.Stmt
+	deleteByUserId    *sqlx.Stmt
+}
+
+type webauthnRepo struct {
+	Connection *postgres.Connection
+	statement  PreparedStatement
+}
+
+func NewWebauthnRepository(db *postgres.Connection) WebauthnRepository {
+	repo := &webauthnRepo{
+		Connection: db,
+	}
+	InitPreparedStatement(repo)
+	return repo
+}
+
+func (p *webauthnRepo) Preparex(query string, isMaster bool) *sqlx.Stmt {
+	if !isMaster {
+		// for slave
+		statement, err := p.Connection.GetPrimarySlave().Preparex(query)
+		if err != nil {
+			log.Fatalf("Failed to preparex query: %s. Error: %s", query, err.Error())
+		}
+
+		return statement
+	}
+
+	statement, err := p.Connection.GetPrimaryMaster().Preparex(query)
+	if err != nil {
+		log.Fatalf("Failed to preparex query: %s. Error: %s", query, err.Error())
+	}
+
+	return statement
+}
+
+func InitPreparedStatement(m *webauthnRepo) {
+	m.statement = PreparedStatement{
+		create: m.Preparex(Create, common.IsMasterDb),
+		// sign count dibaca dari master agar deteksi clone tidak memakai data lama
+		getByCredentialId: m.Preparex(GetByCredentialId, common.IsMasterDb),
+		getByUserId:       m.Preparex(GetByUserId, common.NotIsMasterDb),
+		updateSignCount:   m.Preparex(UpdateSignCount, common.IsMasterDb),
+		delete:            m.Preparex(Delete, common.IsMasterDb),
+		deleteByUserId:    m.Preparex(DeleteByUserId, common.IsMasterDb),
+	}
+}
+
+func (p *webauthnRepo) Create(data *models.UserWebauthnCredential) error {
+	_, err := p.statement.create.Exec(data.UserId, data.CredentialId, data.PublicKey, data.SignCount, data.Transports, data.Nickname)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (p *webauthnRepo) GetByCredentialId(credentialId string) (*models.UserWebauthnCredential, error) {
+	var credentials []*models.UserWebauthnCredential
+
+	err := p.statement.getByCredentialId.Select(&credentials, credentialId)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(credentials) < 1 {
+		return nil, errors.New(errorMessage.PasskeyNotFound)
+	}
+
+	return credentials[0], nil
+}
+
+func (p *webauthnRepo) GetByUserId(userId int64) ([]*models.UserWebauthnCredential, error) {
+	var credentials []*models.UserWebauthnCredential
+
+	err := p.statement.getByUserId.Select(&credentials, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(credentials) == 0 {
+		return []*models.UserWebauthnCredential{}, nil
+	}
+
+	return credentials, nil
+}
+
+func (p *webauthnRepo) UpdateSignCount(id, signCount int64) error {
+	_, err := p.statement.updateSignCount.Exec(signCount, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Delete menghapus passkey milik user. Mengembalikan false jika passkey tidak ditemukan.
+func (p *webauthnRepo) Delete(id, userId int64) (bool, error) {
+	result, err := p.statement.delete.Exec(id, userId)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected == 1, nil
+}
+
+// DeleteByUserId menghapus seluruh passkey milik user
+func (p *webauthnRepo) DeleteByUserId(userId int64) error {
+	_, err := p.statement.deleteByUserId.Exec(userId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
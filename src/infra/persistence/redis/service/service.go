@@ -1,13 +1,71 @@
 package service
 
 import (
+	"fmt"
 	"log"
+	"math/rand"
 	"time"
 
 	"github.com/go-redis/redis/v8"
 	"golang.org/x/net/context"
 )
 
+// incrementScript menaikkan counter dan memasang TTL dalam satu langkah atomik. TTL juga
+// dipasang ulang jika key terlanjur tidak punya TTL, sehingga counter tidak pernah permanen.
+var incrementScript = redis.NewScript(`
+local count = redis.call('INCR', KEYS[1])
+if redis.call('PTTL', KEYS[1]) < 0 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+return count
+`)
+
+// slidingWindowScript mencatat setiap request sebagai member sorted set dengan score waktu
+// (ms) dari jam Redis, sehingga seluruh instance memakai jam yang sama. Request yang keluar
+// dari window dibuang lebih dulu; request ditolak tanpa dicatat jika window sudah penuh.
+// Hasil: {allowed, remaining, retry_after_ms, reset_ms}
+var slidingWindowScript = redis.NewScript(`
+local key = KEYS[1]
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+
+redis.call('ZREMRANGEBYSCORE', key, '-inf', now - window)
+local count = redis.call('ZCARD', key)
+
+local allowed = 0
+if count < limit then
+	redis.call('ZADD', key, now, ARGV[3])
+	count = count + 1
+	allowed = 1
+end
+redis.call('PEXPIRE', key, window)
+
+local oldest = redis.call('ZRANGE', key, 0, 0, 'WITHSCORES')
+local reset = window
+if oldest[2] then
+	reset = tonumber(oldest[2]) + window - now
+end
+
+local retry = 0
+if allowed == 0 then
+	retry = reset
+end
+
+return {allowed, limit - count, retry, reset}
+`)
+
+// RateLimitResult adalah hasil SlidingWindow. Reset adalah waktu sampai request tertua keluar
+// dari window, RetryAfter hanya diisi saat request ditolak.
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration
+	Reset      time.Duration
+}
+
 type ServiceRedis struct {
 	Rdb *redis.Client
 }
@@ -16,7 +74,13 @@ type ServRedisInterface interface {
 	SetData(ctx context.Context, key string, value interface{}, ttl time.Duration) error
 	GetData(ctx context.Context, key string) (string, error)
 	DeleteData(ctx context.Context, key string) error
+	GetDeleteData(ctx context.Context, key string) (string, error)
+	GetMultiData(ctx context.Context, keys ...string) ([]string, error)
 	IsAllowed(ctx context.Context, key string, limit int, duration time.Duration) (bool, error)
+	SetDataNX(ctx context.Context, key string, value interface{}, ttl time.Duration) (bool, error)
+	Increment(ctx context.Context, key string, ttl time.Duration) (int64, error)
+	GetTTL(ctx context.Context, key string) (time.Duration, error)
+	SlidingWindow(ctx context.Context, key string, limit int, window time.Duration) (*RateLimitResult, error)
 }
 
 func NewServRedis(rdb *redis.Client) *ServiceRedis {
@@ -55,20 +119,94 @@ func (p *ServiceRedis) DeleteData(ctx context.Context, key string) error {
 	return nil
 }
 
+// GetDeleteData mengambil lalu menghapus data secara atomik, dipakai untuk data sekali pakai
+func (p *ServiceRedis) GetDeleteData(ctx context.Context, key string) (string, error) {
+	dataRedis, err := p.Rdb.GetDel(ctx, key).Result()
+	if err != nil {
+		log.Printf("Failed to get and delete data from redis for key %s: %v", key, err)
+		return "", err
+	}
+	return dataRedis, nil
+}
+
+// GetMultiData mengambil beberapa key sekaligus, key yang tidak ada bernilai string kosong
+func (p *ServiceRedis) GetMultiData(ctx context.Context, keys ...string) ([]string, error) {
+	dataRedis, err := p.Rdb.MGet(ctx, keys...).Result()
+	if err != nil {
+		log.Printf("Failed to get data from redis for keys %v: %v", keys, err)
+		return nil, err
+	}
+
+	result := make([]string, len(dataRedis))
+	for i, value := range dataRedis {
+		if str, ok := value.(string); ok {
+			result[i] = str
+		}
+	}
+	return result, nil
+}
+
 func (p *ServiceRedis) IsAllowed(ctx context.Context, key string, limit int, duration time.Duration) (bool, error) {
-	count, err := p.Rdb.Incr(ctx, key).Result()
+	count, err := incrementScript.Run(ctx, p.Rdb, []string{key}, duration.Milliseconds()).Int64()
 	if err != nil {
 		log.Println("Error incrementing rate limit:", err)
 		return false, err
 	}
 
-	if count == 1 {
-		p.Rdb.Expire(ctx, key, duration)
-	}
-
 	if count > int64(limit) {
 		return false, nil
 	}
 
 	return true, nil
 }
+
+// SetDataNX menyimpan data hanya jika key belum ada. Hasil false berarti key sudah dipakai.
+func (p *ServiceRedis) SetDataNX(ctx context.Context, key string, value interface{}, ttl time.Duration) (bool, error) {
+	ok, err := p.Rdb.SetNX(ctx, key, value, ttl).Result()
+	if err != nil {
+		log.Println("redis setnx failed:", err)
+		return false, err
+	}
+	return ok, nil
+}
+
+// Increment menambah counter dan mengembalikan nilainya. TTL hanya dipasang saat counter
+// dibuat sehingga counter berakhir setelah ttl sejak kejadian pertama.
+func (p *ServiceRedis) Increment(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	count, err := incrementScript.Run(ctx, p.Rdb, []string{key}, ttl.Milliseconds()).Int64()
+	if err != nil {
+		log.Println("Error incrementing counter:", err)
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// GetTTL mengembalikan sisa TTL key, nilai <= 0 berarti key tidak ada atau tidak punya TTL
+func (p *ServiceRedis) GetTTL(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := p.Rdb.TTL(ctx, key).Result()
+	if err != nil {
+		log.Printf("Failed to get ttl from redis for key %s: %v", key, err)
+		return 0, err
+	}
+	return ttl, nil
+}
+
+// SlidingWindow membatasi key maksimal limit request dalam window yang bergeser secara atomik
+func (p *ServiceRedis) SlidingWindow(ctx context.Context, key string, limit int, window time.Duration) (*RateLimitResult, error) {
+	member := fmt.Sprintf("%d-%d", time.Now().UnixNano(), rand.Int63())
+
+	values, err := slidingWindowScript.Run(ctx, p.Rdb, []string{key}, limit, window.Milliseconds(), member).Int64Slice()
+	if err != nil {
+		log.Printf("Failed to check rate limit for key %s: %v", key, err)
+		return nil, err
+	}
+
+	return &RateLimitResult{
+		Allowed:    values[0] == 1,
+		Limit:      limit,
+		Remaining:  int(values[1]),
+		RetryAfter: time.Duration(values[2]) * time.Millisecond,
+		Reset:      time.Duration(values[3]) * time.Millisecond,
+	}, nil
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+)
+
+func newTestServRedis(t *testing.T) (*ServiceRedis, *miniredis.Miniredis) {
+	t.Helper()
+
+	server := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { _ = rdb.Close() })
+
+	return NewServRedis(rdb), server
+}
+
+// Langkah dijalankan berurutan pada key yang sama; at adalah jam Redis relatif terhadap awal test
+func TestSlidingWindow(t *testing.T) {
+	servRedis, server := newTestServRedis(t)
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	const (
+		limit  = 3
+		window = 10 * time.Second
+	)
+
+	tests := []struct {
+		name          string
+		at            time.Duration
+		wantAllowed   bool
+		wantRemaining int
+		wantRetry     time.Duration
+		wantReset     time.Duration
+	}{
+		{name: "first request", at: 0, wantAllowed: true, wantRemaining: 2, wantReset: 10 * time.Second},
+		{name: "second request", at: time.Second, wantAllowed: true, wantRemaining: 1, wantReset: 9 * time.Second},
+		{name: "last allowed request", at: 2 * time.Second, wantAllowed: true, wantRemaining: 0, wantReset: 8 * time.Second},
+		{name: "window full", at: 3 * time.Second, wantAllowed: false, wantRemaining: 0, wantRetry: 7 * time.Second, wantReset: 7 * time.Second},
+		{name: "rejected requests are not counted", at: 9500 * time.Millisecond, wantAllowed: false, wantRemaining: 0, wantRetry: 500 * time.Millisecond, wantReset: 500 * time.Millisecond},
+		{name: "oldest request left the window", at: 10 * time.Second, wantAllowed: true, wantRemaining: 0, wantReset: time.Second},
+		{name: "full again", at: 10500 * time.Millisecond, wantAllowed: false, wantRemaining: 0, wantRetry: 500 * time.Millisecond, wantReset: 500 * time.Millisecond},
+		{name: "whole window expired", at: 30 * time.Second, wantAllowed: true, wantRemaining: 2, wantReset: 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server.SetTime(start.Add(tt.at))
+
+			result, err := servRedis.SlidingWindow(context.Background(), "rate_limit:login:ip:10.0.0.1", limit, window)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if result.Allowed != tt.wantAllowed || result.Limit != limit || result.Remaining != tt.wantRemaining ||
+				result.RetryAfter != tt.wantRetry || result.Reset != tt.wantReset {
+				t.Fatalf("result = %+v, want allowed=%v remaining=%d retry=%s reset=%s",
+					result, tt.wantAllowed, tt.wantRemaining, tt.wantRetry, tt.wantReset)
+			}
+
+			if ttl := server.TTL("rate_limit:login:ip:10.0.0.1"); ttl != window {
+				t.Fatalf("key ttl = %s, want %s", ttl, window)
+			}
+		})
+	}
+}
+
+func TestSlidingWindowKeysAreIndependent(t *testing.T) {
+	servRedis, server := newTestServRedis(t)
+	server.SetTime(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	ctx := context.Background()
+	if result, err := servRedis.SlidingWindow(ctx, "rate_limit:login:ip:10.0.0.1", 1, time.Minute); err != nil || !result.Allowed {
+		t.Fatalf("first key: result = %+v, err = %v", result, err)
+	}
+	if result, err := servRedis.SlidingWindow(ctx, "rate_limit:login:ip:10.0.0.1", 1, time.Minute); err != nil || result.Allowed {
+		t.Fatalf("first key over limit: result = %+v, err = %v", result, err)
+	}
+	if result, err := servRedis.SlidingWindow(ctx, "rate_limit:login:ip:10.0.0.2", 1, time.Minute); err != nil || !result.Allowed {
+		t.Fatalf("second key: result = %+v, err = %v", result, err)
+	}
+}
+
+func TestSlidingWindowRedisUnavailable(t *testing.T) {
+	servRedis, server := newTestServRedis(t)
+	server.Close()
+
+	if _, err := servRedis.SlidingWindow(context.Background(), "rate_limit:login:ip:10.0.0.1", 1, time.Minute); err == nil {
+		t.Fatal("expected error while Redis is unavailable")
+	}
+}
@@ -0,0 +1,41 @@
+package sms
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// LogSender menulis SMS ke file (atau log aplikasi jika path kosong) tanpa mengirimnya.
+// Hanya untuk development karena OTP tersimpan dalam bentuk plain text.
+type LogSender struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewLogSender(path string) SMSSender {
+	return &LogSender{
+		path: path,
+	}
+}
+
+func (s *LogSender) Send(to, message string) error {
+	if s.path == "" {
+		log.Printf("[SMS] to=%s message=%q", to, message)
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = fmt.Fprintf(file, "%s\t%s\t%q\n", time.Now().Format(time.RFC3339), to, message)
+	return err
+}
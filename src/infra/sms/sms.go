@@ -0,0 +1,23 @@
+package sms
+
+import (
+	"fmt"
+
+	"go-auth-service/src/infra/config"
+)
+
+// SMSSender mengirim SMS ke nomor E.164. Provider lain cukup mengimplementasikan
+// interface ini lalu didaftarkan di NewSender.
+type SMSSender interface {
+	Send(to, message string) error
+}
+
+// NewSender memilih SMSSender berdasarkan SMS_PROVIDER
+func NewSender(conf config.SmsConf) (SMSSender, error) {
+	switch conf.Provider {
+	case "log":
+		return NewLogSender(conf.LogPath), nil
+	}
+
+	return nil, fmt.Errorf("unknown sms provider: %s", conf.Provider)
+}
@@ -59,7 +59,7 @@ func authWorker(concurrency int, w *AuthImpl) {
 		}
 
 		if dataConsume.Event == common.EventLogin {
-			err = w.UseCaseMail.SendMailLogin(dataConsume.UserId, dataConsume.IpAddress, dataConsume.Device)
+			err = w.UseCaseMail.SendMailLogin(dataConsume.UserId, dataConsume.SessionId, dataConsume.IpAddress, dataConsume.Device, dataConsume.AuthMethod)
 			if err != nil {
 				log.Println("[ERROR] send mail err:", err)
 			}
@@ -73,6 +73,36 @@ func authWorker(concurrency int, w *AuthImpl) {
 			if err != nil {
 				log.Println("[ERROR] send mail err:", err)
 			}
+		} else if dataConsume.Event == common.EventForgotPassword {
+			err = w.UseCaseMail.SendMailResetPassword(dataConsume.UserId)
+			if err != nil {
+				log.Println("[ERROR] send mail err:", err)
+			}
+		} else if dataConsume.Event == common.EventVerifyEmail {
+			err = w.UseCaseMail.SendMailVerification(dataConsume.UserId)
+			if err != nil {
+				log.Println("[ERROR] send mail err:", err)
+			}
+		} else if dataConsume.Event == common.EventRecoveryCodeUsed {
+			err = w.UseCaseMail.SendMailRecoveryCodeUsed(dataConsume.UserId, dataConsume.IpAddress, dataConsume.Device)
+			if err != nil {
+				log.Println("[ERROR] send mail err:", err)
+			}
+		} else if dataConsume.Event == common.EventMagicLink {
+			err = w.UseCaseMail.SendMailMagicLink(dataConsume.UserId, dataConsume.DeviceBinding, dataConsume.IpAddress, dataConsume.Device)
+			if err != nil {
+				log.Println("[ERROR] send mail err:", err)
+			}
+		} else if dataConsume.Event == common.EventAccountLocked {
+			err = w.UseCaseMail.SendMailAccountLocked(dataConsume.UserId, dataConsume.IpAddress, dataConsume.LockedUntil)
+			if err != nil {
+				log.Println("[ERROR] send mail err:", err)
+			}
+		} else if dataConsume.Event == common.EventRefreshTokenReuse {
+			err = w.UseCaseMail.SendMailRefreshTokenReuse(dataConsume.UserId, dataConsume.Device)
+			if err != nil {
+				log.Println("[ERROR] send mail err:", err)
+			}
 		}
 	})
 
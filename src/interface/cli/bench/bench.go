@@ -0,0 +1,290 @@
+package bench
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Command adalah nama tool benchmark. Tool ini tidak ikut di binary service, jalankan lewat
+// entry point terpisah, contoh:
+//
+//	go run ./cmd/bench-hash-flood -url http://localhost:8080 -email user@mail.com -password 'Secret123!'
+const Command = "bench-hash-flood"
+
+type options struct {
+	baseURL       string
+	email         string
+	password      string
+	concurrency   int
+	baseline      time.Duration
+	duration      time.Duration
+	probePath     string
+	probeInterval time.Duration
+}
+
+// Run mengukur latency route lain (default GET /api/auth/me) sebelum dan selama banjir request
+// login, untuk memastikan worker pool hashing password menjaga latency tetap datar. Akun yang
+// dipakai harus sudah terverifikasi dan tanpa MFA. Policy rate limit "login" perlu dinaikkan
+// sementara agar banjir request sampai ke hashing, bukan berhenti di 429.
+func Run(args []string) error {
+	opts := options{}
+	flags := flag.NewFlagSet(Command, flag.ContinueOnError)
+	flags.StringVar(&opts.baseURL, "url", "http://localhost:8080", "base URL of the running service")
+	flags.StringVar(&opts.email, "email", "", "email of a verified account without MFA")
+	flags.StringVar(&opts.password, "password", "", "password of the account")
+	flags.IntVar(&opts.concurrency, "concurrency", 64, "number of concurrent login requests during the flood")
+	flags.DurationVar(&opts.baseline, "baseline", 10*time.Second, "how long to probe before the flood")
+	flags.DurationVar(&opts.duration, "duration", 30*time.Second, "how long the login flood runs")
+	flags.StringVar(&opts.probePath, "probe", "/api/auth/me", "route whose latency is measured, called with the access token")
+	flags.DurationVar(&opts.probeInterval, "probe-interval", 20*time.Millisecond, "delay between probe requests")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if opts.email == "" || opts.password == "" {
+		flags.Usage()
+		return errors.New("-email and -password are required")
+	}
+
+	if opts.concurrency < 1 {
+		return errors.New("-concurrency must be at least 1")
+	}
+
+	opts.baseURL = strings.TrimRight(opts.baseURL, "/")
+	client := &http.Client{
+		Timeout:   30 * time.Second,
+		Transport: &http.Transport{MaxIdleConnsPerHost: opts.concurrency + 1},
+	}
+
+	token, err := login(client, opts)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("baseline: probing %s for %s\n", opts.probePath, opts.baseline)
+	baseline := probe(client, opts, token, opts.baseline, nil)
+
+	fmt.Printf("flood: %d concurrent logins for %s while probing %s\n", opts.concurrency, opts.duration, opts.probePath)
+	flood := &floodResult{status: map[int]uint64{}}
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < opts.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			floodLogin(client, opts, flood, stop)
+		}()
+	}
+
+	during := probe(client, opts, token, opts.duration, stop)
+	wg.Wait()
+
+	fmt.Println()
+	fmt.Printf("%-10s %8s %10s %10s %10s %10s %8s\n", "phase", "requests", "p50", "p95", "p99", "max", "errors")
+	baseline.print("baseline")
+	during.print("flood")
+
+	fmt.Println()
+	fmt.Printf("login flood: %d requests (%.1f/s)\n", flood.total, float64(flood.total)/opts.duration.Seconds())
+	codes := make([]int, 0, len(flood.status))
+	for code := range flood.status {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+	for _, code := range codes {
+		fmt.Printf("  %d: %d\n", code, flood.status[code])
+	}
+	if flood.failed > 0 {
+		fmt.Printf("  transport errors: %d\n", flood.failed)
+	}
+
+	printHashMetrics(client, opts, token)
+	return nil
+}
+
+type loginResponse struct {
+	Data struct {
+		AccessToken string `json:"access_token"`
+		MfaRequired bool   `json:"mfa_required"`
+	} `json:"data"`
+	Message string `json:"message"`
+}
+
+func login(client *http.Client, opts options) (string, error) {
+	resp, err := postLogin(client, opts)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var body loginResponse
+	if err = json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("login failed with status %d: %s", resp.StatusCode, body.Message)
+	}
+	if body.Data.MfaRequired || body.Data.AccessToken == "" {
+		return "", errors.New("login did not return an access token, use an account without MFA")
+	}
+
+	return body.Data.AccessToken, nil
+}
+
+func postLogin(client *http.Client, opts options) (*http.Response, error) {
+	payload, _ := json.Marshal(map[string]string{"email": opts.email, "password": opts.password})
+
+	req, err := http.NewRequest(http.MethodPost, opts.baseURL+"/api/auth/login", bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", Command)
+
+	return client.Do(req)
+}
+
+type floodResult struct {
+	mu     sync.Mutex
+	status map[int]uint64
+	total  uint64
+	failed uint64
+}
+
+func floodLogin(client *http.Client, opts options, result *floodResult, stop <-chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			return
+		default:
+		}
+
+		resp, err := postLogin(client, opts)
+		if err != nil {
+			atomic.AddUint64(&result.failed, 1)
+			continue
+		}
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		result.mu.Lock()
+		result.status[resp.StatusCode]++
+		result.total++
+		result.mu.Unlock()
+	}
+}
+
+type probeResult struct {
+	latencies []time.Duration
+	errors    int
+}
+
+// probe memanggil route probe secara berurutan selama duration. Jika stop tidak nil, stop
+// ditutup saat probe selesai untuk menghentikan banjir login.
+func probe(client *http.Client, opts options, token string, duration time.Duration, stop chan struct{}) *probeResult {
+	result := &probeResult{}
+	deadline := time.Now().Add(duration)
+
+	for time.Now().Before(deadline) {
+		req, err := http.NewRequest(http.MethodGet, opts.baseURL+opts.probePath, nil)
+		if err != nil {
+			result.errors++
+			break
+		}
+		req.Header.Set("Authorization", token)
+		req.Header.Set("User-Agent", Command)
+
+		start := time.Now()
+		resp, err := client.Do(req)
+		if err == nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		elapsed := time.Since(start)
+
+		if err != nil || resp.StatusCode >= 400 {
+			result.errors++
+		} else {
+			result.latencies = append(result.latencies, elapsed)
+		}
+
+		time.Sleep(opts.probeInterval)
+	}
+
+	if stop != nil {
+		close(stop)
+	}
+
+	return result
+}
+
+func (p *probeResult) print(phase string) {
+	sort.Slice(p.latencies, func(i, j int) bool { return p.latencies[i] < p.latencies[j] })
+	fmt.Printf("%-10s %8d %10s %10s %10s %10s %8d\n", phase, len(p.latencies),
+		p.percentile(0.50), p.percentile(0.95), p.percentile(0.99), p.percentile(1), p.errors)
+}
+
+func (p *probeResult) percentile(q float64) time.Duration {
+	if len(p.latencies) == 0 {
+		return 0
+	}
+
+	i := int(q*float64(len(p.latencies))+0.5) - 1
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(p.latencies) {
+		i = len(p.latencies) - 1
+	}
+
+	return p.latencies[i].Round(10 * time.Microsecond)
+}
+
+// printHashMetrics menampilkan metrik worker pool jika akun yang dipakai adalah admin
+func printHashMetrics(client *http.Client, opts options, token string) {
+	req, err := http.NewRequest(http.MethodGet, opts.baseURL+"/api/auth/admin/metrics/password-hash", nil)
+	if err != nil {
+		return
+	}
+	req.Header.Set("Authorization", token)
+	req.Header.Set("User-Agent", Command)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		fmt.Println("password hash metrics: not available (admin account required)")
+		return
+	}
+
+	var body struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return
+	}
+
+	var out bytes.Buffer
+	if err = json.Indent(&out, body.Data, "", "  "); err != nil {
+		return
+	}
+	fmt.Println("password hash metrics:")
+	_, _ = out.WriteTo(os.Stdout)
+	fmt.Println()
+}
@@ -0,0 +1,141 @@
+package breach
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go-auth-service/src/infra/helper"
+)
+
+// Command adalah nama subcommand, contoh:
+//
+//	go-auth-service build-breach-filter -input pwned-passwords-sha1.txt -output breached.filter
+const Command = "build-breach-filter"
+
+// Run membangun file filter untuk BREACHED_PASSWORD_SOURCE dari dump HIBP. Input berupa file
+// "HASH:COUNT" (pwned-passwords-sha1) atau direktori file range (ABCDE.txt berisi SUFFIX:COUNT).
+// Input dibaca dua kali: untuk menghitung jumlah hash lalu untuk mengisi filter.
+func Run(args []string) error {
+	flags := flag.NewFlagSet(Command, flag.ContinueOnError)
+	input := flags.String("input", "", "HIBP dump file or directory of range files")
+	output := flags.String("output", "", "filter file to write")
+	fpRate := flags.Float64("fp-rate", 0.001, "false positive rate of the filter")
+	minCount := flags.Int("min-count", 1, "skip hashes seen fewer times than this in breaches")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *input == "" || *output == "" {
+		flags.Usage()
+		return errors.New("-input and -output are required")
+	}
+
+	if *fpRate <= 0 || *fpRate >= 1 {
+		return errors.New("-fp-rate must be between 0 and 1")
+	}
+
+	var total uint64
+	err := walk(*input, *minCount, func(sum [sha1.Size]byte) {
+		total++
+	})
+	if err != nil {
+		return err
+	}
+
+	filter := helper.NewBreachFilter(total, *fpRate)
+	err = walk(*input, *minCount, filter.Add)
+	if err != nil {
+		return err
+	}
+
+	// ditulis ke file sementara agar service tidak pernah membaca filter yang setengah jadi
+	tmp := *output + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	writer := bufio.NewWriter(file)
+	if _, err = filter.WriteTo(writer); err == nil {
+		err = writer.Flush()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	if err = os.Rename(tmp, *output); err != nil {
+		return err
+	}
+
+	log.Printf("breached password filter written to %s (%d hashes, false positive rate %g)", *output, total, *fpRate)
+	return nil
+}
+
+// walk memanggil fn untuk setiap hash dengan count >= minCount
+func walk(input string, minCount int, fn func(sum [sha1.Size]byte)) error {
+	info, err := os.Stat(input)
+	if err != nil {
+		return err
+	}
+
+	if !info.IsDir() {
+		return walkFile(input, "", minCount, fn)
+	}
+
+	entries, err := os.ReadDir(input)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		prefix := strings.TrimSuffix(entry.Name(), ".txt")
+		if entry.IsDir() || len(prefix) != 5 {
+			continue
+		}
+
+		if err = walkFile(filepath.Join(input, entry.Name()), prefix, minCount, fn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func walkFile(path, prefix string, minCount int, fn func(sum [sha1.Size]byte)) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+
+		sum, count, ok := helper.ParseBreachLine(text, prefix)
+		if !ok {
+			return fmt.Errorf("%s:%d: invalid line", path, line)
+		}
+
+		if count >= minCount {
+			fn(sum)
+		}
+	}
+
+	return scanner.Err()
+}
@@ -0,0 +1,337 @@
+package oauth
+
+import (
+	"errors"
+	validation "github.com/go-ozzo/ozzo-validation"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-auth-service/src/app/dto/oauth"
+	"go-auth-service/src/app/dto/user"
+	usecases "go-auth-service/src/app/usecases/oauth"
+	userUC "go-auth-service/src/app/usecases/user"
+	"go-auth-service/src/infra/constants/common"
+	errorMessage "go-auth-service/src/infra/constants/error_message"
+	"go-auth-service/src/infra/helper"
+	"go-auth-service/src/interface/rest/response"
+)
+
+type OAuthHandlerInterface interface {
+	Authorize(w http.ResponseWriter, r *http.Request)
+	AuthorizeLogin(w http.ResponseWriter, r *http.Request)
+	Token(w http.ResponseWriter, r *http.Request)
+	UserInfo(w http.ResponseWriter, r *http.Request)
+	Introspect(w http.ResponseWriter, r *http.Request)
+}
+
+type oauthHandler struct {
+	usecase usecases.OAuthUCInterface
+}
+
+func NewOAuthHandler(h usecases.OAuthUCInterface) OAuthHandlerInterface {
+	return &oauthHandler{
+		usecase: h,
+	}
+}
+
+// Authorize menampilkan halaman login untuk authorization request yang valid
+func (h *oauthHandler) Authorize(w http.ResponseWriter, r *http.Request) {
+	data := authorizeReqFromValues(r.URL.Query())
+
+	err := h.usecase.ValidateAuthorize(&data)
+	if err != nil {
+		log.Println(err)
+		h.authorizeError(w, r, &data, err)
+		return
+	}
+
+	renderAuthorize(w, http.StatusOK, map[string]interface{}{
+		"show_form": true,
+		"client_id": data.ClientId,
+		"request":   data,
+	})
+}
+
+// AuthorizeLogin memproses form login lalu redirect ke client dengan authorization code
+func (h *oauthHandler) AuthorizeLogin(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		log.Println(err)
+		renderAuthorize(w, http.StatusBadRequest, map[string]interface{}{"error": errorMessage.RequestPayload})
+		return
+	}
+
+	data := authorizeReqFromValues(r.PostForm)
+
+	err := h.usecase.ValidateAuthorize(&data)
+	if err != nil {
+		log.Println(err)
+		h.authorizeError(w, r, &data, err)
+		return
+	}
+
+	userAgent := r.Header.Get("User-Agent")
+	login := user.LoginReq{
+		Email:    r.PostForm.Get("email"),
+		Password: r.PostForm.Get("password"),
+	}
+
+	if err = login.Validate(); err == nil {
+		var code string
+		code, err = h.usecase.Authorize(&data, &login, r.PostForm.Get("otp"), helper.GetRealIP(r), userAgent)
+		if err == nil {
+			redirectToClient(w, r, data.RedirectUri, url.Values{"code": {code}, "state": {data.State}})
+			return
+		}
+	}
+
+	log.Println(err)
+	status := http.StatusUnauthorized
+	message := errorMessage.InvalidCredentials
+	if err != nil && (err.Error() == errorMessage.MfaRequired || err.Error() == errorMessage.InvalidTotpCode || err.Error() == errorMessage.InvalidRecoveryCode) {
+		message = err.Error()
+	}
+
+	var lockErr *userUC.LockoutError
+	if errors.As(err, &lockErr) {
+		status = http.StatusTooManyRequests
+		message = lockErr.Message
+		w.Header().Set("Retry-After", strconv.FormatInt(int64((lockErr.RetryAfter+time.Second-1)/time.Second), 10))
+	}
+
+	if err != nil && err.Error() == errorMessage.PasswordHashBusy {
+		status = http.StatusServiceUnavailable
+		message = errorMessage.PasswordHashBusy
+		w.Header().Set("Retry-After", strconv.FormatInt(int64(common.PasswordHashRetryAfter/time.Second), 10))
+	}
+
+	renderAuthorize(w, status, map[string]interface{}{
+		"show_form": true,
+		"client_id": data.ClientId,
+		"request":   data,
+		"email":     login.Email,
+		"error":     message,
+	})
+}
+
+func (h *oauthHandler) Token(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+
+	if err := r.ParseForm(); err != nil {
+		log.Println(err)
+		response.OAuthError(w, http.StatusBadRequest, common.OAuthErrInvalidRequest, errorMessage.RequestPayload)
+		return
+	}
+
+	postDTO := oauth.TokenReq{
+		GrantType:    r.PostForm.Get("grant_type"),
+		Code:         r.PostForm.Get("code"),
+		RedirectUri:  r.PostForm.Get("redirect_uri"),
+		CodeVerifier: r.PostForm.Get("code_verifier"),
+		RefreshToken: r.PostForm.Get("refresh_token"),
+		Scope:        r.PostForm.Get("scope"),
+		ClientId:     r.PostForm.Get("client_id"),
+		ClientSecret: r.PostForm.Get("client_secret"),
+	}
+
+	// client_secret_basic
+	if clientId, clientSecret, ok := r.BasicAuth(); ok {
+		postDTO.ClientId, _ = url.QueryUnescape(clientId)
+		postDTO.ClientSecret, _ = url.QueryUnescape(clientSecret)
+	}
+
+	err := postDTO.Validate()
+	if err != nil {
+		log.Println(err)
+		response.OAuthError(w, http.StatusBadRequest, common.OAuthErrInvalidRequest, err.Error())
+		return
+	}
+
+	token, err := h.usecase.Token(&postDTO, r.Header.Get("User-Agent"))
+	if err != nil {
+		log.Println(err)
+		status, code := oauthErrorCode(err)
+		if code == common.OAuthErrInvalidClient {
+			w.Header().Set("WWW-Authenticate", `Basic realm="token"`)
+		}
+		if code == common.OAuthErrTemporarilyUnavailable {
+			w.Header().Set("Retry-After", strconv.FormatInt(int64(common.PasswordHashRetryAfter/time.Second), 10))
+		}
+		response.OAuthError(w, status, code, err.Error())
+		return
+	}
+
+	response.RawJSON(w, http.StatusOK, token)
+}
+
+func (h *oauthHandler) UserInfo(w http.ResponseWriter, r *http.Request) {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if token == "" {
+		w.Header().Set("WWW-Authenticate", `Bearer`)
+		response.OAuthError(w, http.StatusUnauthorized, common.OAuthErrInvalidRequest, errorMessage.MissingToken)
+		return
+	}
+
+	claims, err := helper.VerifyOAuthToken(token)
+	if err != nil {
+		log.Println(err)
+		w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+		response.OAuthError(w, http.StatusUnauthorized, common.OAuthErrInvalidToken, err.Error())
+		return
+	}
+
+	userInfo, err := h.usecase.UserInfo(claims.UserID, claims.Scope)
+	if err != nil {
+		log.Println(err)
+		w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+		response.OAuthError(w, http.StatusUnauthorized, common.OAuthErrInvalidToken, err.Error())
+		return
+	}
+
+	response.RawJSON(w, http.StatusOK, userInfo)
+}
+
+// Introspect dipanggil resource server dengan autentikasi client (basic atau form)
+func (h *oauthHandler) Introspect(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+
+	if err := r.ParseForm(); err != nil {
+		log.Println(err)
+		response.OAuthError(w, http.StatusBadRequest, common.OAuthErrInvalidRequest, errorMessage.RequestPayload)
+		return
+	}
+
+	postDTO := oauth.IntrospectReq{
+		Token:         r.PostForm.Get("token"),
+		TokenTypeHint: r.PostForm.Get("token_type_hint"),
+		ClientId:      r.PostForm.Get("client_id"),
+		ClientSecret:  r.PostForm.Get("client_secret"),
+	}
+
+	if clientId, clientSecret, ok := r.BasicAuth(); ok {
+		postDTO.ClientId, _ = url.QueryUnescape(clientId)
+		postDTO.ClientSecret, _ = url.QueryUnescape(clientSecret)
+	}
+
+	err := postDTO.Validate()
+	if err != nil {
+		log.Println(err)
+		response.OAuthError(w, http.StatusBadRequest, common.OAuthErrInvalidRequest, err.Error())
+		return
+	}
+
+	result, err := h.usecase.Introspect(&postDTO)
+	if err != nil {
+		log.Println(err)
+		status, code := oauthErrorCode(err)
+		if code == common.OAuthErrInvalidClient {
+			w.Header().Set("WWW-Authenticate", `Basic realm="introspect"`)
+		}
+		if code == common.OAuthErrTemporarilyUnavailable {
+			w.Header().Set("Retry-After", strconv.FormatInt(int64(common.PasswordHashRetryAfter/time.Second), 10))
+		}
+		response.OAuthError(w, status, code, err.Error())
+		return
+	}
+
+	response.RawJSON(w, http.StatusOK, result)
+}
+
+// authorizeError menampilkan halaman error jika client atau redirect_uri tidak valid,
+// selain itu error dikirim ke redirect_uri sesuai RFC 6749 4.1.2.1
+func (h *oauthHandler) authorizeError(w http.ResponseWriter, r *http.Request, data *oauth.AuthorizeReq, err error) {
+	if err.Error() == errorMessage.InvalidClient || err.Error() == errorMessage.InvalidRedirectUri {
+		renderAuthorize(w, http.StatusBadRequest, map[string]interface{}{"error": err.Error()})
+		return
+	}
+
+	_, code := oauthErrorCode(err)
+	redirectToClient(w, r, data.RedirectUri, url.Values{
+		"error":             {code},
+		"error_description": {err.Error()},
+		"state":             {data.State},
+	})
+}
+
+// oauthErrorCode memetakan pesan error usecase ke status HTTP dan error code RFC 6749
+func oauthErrorCode(err error) (int, string) {
+	switch err.Error() {
+	case errorMessage.InvalidClient:
+		return http.StatusUnauthorized, common.OAuthErrInvalidClient
+	case errorMessage.InvalidGrant, errorMessage.InvalidCodeVerifier:
+		return http.StatusBadRequest, common.OAuthErrInvalidGrant
+	case errorMessage.UnauthorizedClient:
+		return http.StatusBadRequest, common.OAuthErrUnauthorizedClient
+	case errorMessage.UnsupportedGrantType:
+		return http.StatusBadRequest, common.OAuthErrUnsupportedGrantType
+	case errorMessage.UnsupportedResponseType:
+		return http.StatusBadRequest, common.OAuthErrUnsupportedResponseType
+	case errorMessage.InvalidScope:
+		return http.StatusBadRequest, common.OAuthErrInvalidScope
+	case errorMessage.InvalidRedirectUri:
+		return http.StatusBadRequest, common.OAuthErrInvalidRequest
+	case errorMessage.PasswordHashBusy:
+		return http.StatusServiceUnavailable, common.OAuthErrTemporarilyUnavailable
+	}
+
+	if _, ok := err.(validation.Errors); ok {
+		return http.StatusBadRequest, common.OAuthErrInvalidRequest
+	}
+
+	return http.StatusInternalServerError, common.OAuthErrServerError
+}
+
+func authorizeReqFromValues(values url.Values) oauth.AuthorizeReq {
+	return oauth.AuthorizeReq{
+		ResponseType:        values.Get("response_type"),
+		ClientId:            values.Get("client_id"),
+		RedirectUri:         values.Get("redirect_uri"),
+		Scope:               values.Get("scope"),
+		State:               values.Get("state"),
+		Nonce:               values.Get("nonce"),
+		CodeChallenge:       values.Get("code_challenge"),
+		CodeChallengeMethod: values.Get("code_challenge_method"),
+	}
+}
+
+func redirectToClient(w http.ResponseWriter, r *http.Request, redirectUri string, params url.Values) {
+	target, err := url.Parse(redirectUri)
+	if err != nil {
+		renderAuthorize(w, http.StatusBadRequest, map[string]interface{}{"error": errorMessage.InvalidRedirectUri})
+		return
+	}
+
+	query := target.Query()
+	for key, value := range params {
+		if len(value) > 0 && value[0] != "" {
+			query.Set(key, value[0])
+		}
+	}
+	target.RawQuery = query.Encode()
+
+	http.Redirect(w, r, target.String(), http.StatusFound)
+}
+
+func renderAuthorize(w http.ResponseWriter, statusCode int, data map[string]interface{}) {
+	file := os.Getenv("PATH_PAGE_TEMPLATE") + "authorize.html"
+
+	tmpl, err := template.ParseFiles(file)
+	if err != nil {
+		log.Println(err)
+		response.JSON(w, http.StatusInternalServerError, "error", errorMessage.BadRequest, nil)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(statusCode)
+	if err = tmpl.Execute(w, data); err != nil {
+		log.Println(err)
+	}
+}
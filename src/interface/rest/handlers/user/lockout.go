@@ -0,0 +1,49 @@
+package user
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	errorMessage "go-auth-service/src/infra/constants/error_message"
+	"go-auth-service/src/infra/helper"
+	"go-auth-service/src/interface/rest/response"
+)
+
+func (h *userHandler) UnlockAccount(w http.ResponseWriter, r *http.Request) {
+	token := r.Header.Get("Authorization")
+	if token == "" {
+		response.JSON(w, http.StatusUnauthorized, "error", errorMessage.MissingToken, nil)
+		return
+	}
+
+	claims, err := helper.VerifyToken(token)
+	if err != nil {
+		log.Println(err)
+		response.JSON(w, http.StatusUnauthorized, "error", errorMessage.InvalidToken, nil)
+		return
+	}
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		response.JSON(w, http.StatusNotFound, "error", errorMessage.UserNotFound, nil)
+		return
+	}
+
+	err = h.usecase.UnlockAccount(claims.UserID, id)
+	if err != nil {
+		log.Println(err)
+		switch err.Error() {
+		case errorMessage.Forbidden:
+			response.JSON(w, http.StatusForbidden, "error", err.Error(), nil)
+		case errorMessage.UserNotFound:
+			response.JSON(w, http.StatusNotFound, "error", err.Error(), nil)
+		default:
+			response.JSON(w, http.StatusInternalServerError, "error", errorMessage.FailedUpdateData, nil)
+		}
+		return
+	}
+
+	response.JSON(w, http.StatusOK, "success", "account unlocked", nil)
+}
@@ -0,0 +1,100 @@
+package user
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"go-auth-service/src/app/dto/user"
+	errorMessage "go-auth-service/src/infra/constants/error_message"
+	"go-auth-service/src/infra/helper"
+	"go-auth-service/src/interface/rest/response"
+)
+
+func (h *userHandler) RequestMagicLink(w http.ResponseWriter, r *http.Request) {
+	userIp := helper.GetRealIP(r)
+	userAgent := r.Header.Get("User-Agent")
+	if userAgent == "" {
+		response.JSON(w, http.StatusUnauthorized, "error", errorMessage.MissingUserAgent, nil)
+		return
+	}
+
+	postDTO := user.MagicLinkReq{}
+	err := json.NewDecoder(r.Body).Decode(&postDTO)
+	if err != nil {
+		log.Println(err)
+		response.JSON(w, http.StatusBadRequest, "error", errorMessage.RequestPayload, nil)
+		return
+	}
+
+	err = postDTO.Validate()
+	if err != nil {
+		log.Println(err)
+		response.JSON(w, http.StatusBadRequest, "error", err.Error(), nil)
+		return
+	}
+
+	resp, err := h.usecase.RequestMagicLink(postDTO.Email, userIp, userAgent)
+	if err != nil {
+		log.Println(err)
+		switch err.Error() {
+		case errorMessage.MagicLinkDisabled:
+			response.JSON(w, http.StatusNotFound, "error", err.Error(), nil)
+		case errorMessage.ToManyRequest:
+			response.JSON(w, http.StatusTooManyRequests, "error", errorMessage.ToManyRequest, nil)
+		default:
+			response.JSON(w, http.StatusInternalServerError, "error", errorMessage.FailedCreateData, nil)
+		}
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	response.JSON(w, http.StatusOK, "success", "if the email is registered, a sign-in link has been sent", resp)
+}
+
+func (h *userHandler) LoginMagicLink(w http.ResponseWriter, r *http.Request) {
+	userIp := helper.GetRealIP(r)
+	userAgent := r.Header.Get("User-Agent")
+	if userAgent == "" {
+		response.JSON(w, http.StatusUnauthorized, "error", errorMessage.MissingUserAgent, nil)
+		return
+	}
+
+	postDTO := user.MagicLinkLoginReq{}
+	err := json.NewDecoder(r.Body).Decode(&postDTO)
+	if err != nil {
+		log.Println(err)
+		response.JSON(w, http.StatusBadRequest, "error", errorMessage.RequestPayload, nil)
+		return
+	}
+
+	err = postDTO.Validate()
+	if err != nil {
+		log.Println(err)
+		response.JSON(w, http.StatusBadRequest, "error", err.Error(), nil)
+		return
+	}
+
+	token, err := h.usecase.LoginMagicLink(&postDTO, userIp, userAgent)
+	if err != nil {
+		log.Println(err)
+		switch err.Error() {
+		case errorMessage.MagicLinkDisabled:
+			response.JSON(w, http.StatusNotFound, "error", err.Error(), nil)
+		case errorMessage.PasswordResetRequired, errorMessage.MagicLinkDeviceMismatch:
+			response.JSON(w, http.StatusForbidden, "error", err.Error(), nil)
+		case errorMessage.InvalidMagicLink:
+			response.JSON(w, http.StatusUnauthorized, "error", err.Error(), nil)
+		default:
+			response.JSON(w, http.StatusUnauthorized, "error", errorMessage.Unauthorized, nil)
+		}
+		return
+	}
+
+	if token.MfaRequired {
+		response.JSON(w, http.StatusOK, "success", errorMessage.MfaRequired, token)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, "success", "successful login", token)
+}
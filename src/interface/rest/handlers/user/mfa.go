@@ -0,0 +1,168 @@
+package user
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"go-auth-service/src/app/dto/user"
+	usecases "go-auth-service/src/app/usecases/user"
+	errorMessage "go-auth-service/src/infra/constants/error_message"
+	"go-auth-service/src/infra/helper"
+	"go-auth-service/src/interface/rest/response"
+)
+
+func (h *userHandler) EnrollTotp(w http.ResponseWriter, r *http.Request) {
+	token := r.Header.Get("Authorization")
+	if token == "" {
+		response.JSON(w, http.StatusUnauthorized, "error", errorMessage.MissingToken, nil)
+		return
+	}
+
+	claims, err := helper.VerifyToken(token)
+	if err != nil {
+		log.Println(err)
+		response.JSON(w, http.StatusUnauthorized, "error", errorMessage.InvalidToken, nil)
+		return
+	}
+
+	enroll, err := h.usecase.EnrollTotp(claims.UserID)
+	if err != nil {
+		log.Println(err)
+		if err.Error() == errorMessage.TotpAlreadyEnabled {
+			response.JSON(w, http.StatusConflict, "error", errorMessage.TotpAlreadyEnabled, nil)
+			return
+		}
+		response.JSON(w, http.StatusInternalServerError, "error", errorMessage.FailedCreateData, nil)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	response.JSON(w, http.StatusOK, "success", "scan the qr code and confirm with the first code", enroll)
+}
+
+func (h *userHandler) ConfirmTotp(w http.ResponseWriter, r *http.Request) {
+	token := r.Header.Get("Authorization")
+	if token == "" {
+		response.JSON(w, http.StatusUnauthorized, "error", errorMessage.MissingToken, nil)
+		return
+	}
+
+	claims, err := helper.VerifyToken(token)
+	if err != nil {
+		log.Println(err)
+		response.JSON(w, http.StatusUnauthorized, "error", errorMessage.InvalidToken, nil)
+		return
+	}
+
+	postDTO := user.TotpConfirmReq{}
+	err = json.NewDecoder(r.Body).Decode(&postDTO)
+	if err != nil {
+		log.Println(err)
+		response.JSON(w, http.StatusBadRequest, "error", errorMessage.RequestPayload, nil)
+		return
+	}
+
+	err = postDTO.Validate()
+	if err != nil {
+		log.Println(err)
+		response.JSON(w, http.StatusBadRequest, "error", err.Error(), nil)
+		return
+	}
+
+	codes, err := h.usecase.ConfirmTotp(claims.UserID, postDTO.Code)
+	if err != nil {
+		log.Println(err)
+		switch err.Error() {
+		case errorMessage.TotpNotFound, errorMessage.InvalidTotpCode:
+			response.JSON(w, http.StatusBadRequest, "error", err.Error(), nil)
+		case errorMessage.TotpAlreadyEnabled:
+			response.JSON(w, http.StatusConflict, "error", err.Error(), nil)
+		default:
+			response.JSON(w, http.StatusInternalServerError, "error", errorMessage.FailedUpdateData, nil)
+		}
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	response.JSON(w, http.StatusOK, "success", "totp enabled, store the recovery codes in a safe place", user.RecoveryCodesResp{RecoveryCodes: codes})
+}
+
+func (h *userHandler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	token := r.Header.Get("Authorization")
+	if token == "" {
+		response.JSON(w, http.StatusUnauthorized, "error", errorMessage.MissingToken, nil)
+		return
+	}
+
+	claims, err := helper.VerifyToken(token)
+	if err != nil {
+		log.Println(err)
+		response.JSON(w, http.StatusUnauthorized, "error", errorMessage.InvalidToken, nil)
+		return
+	}
+
+	codes, err := h.usecase.RegenerateRecoveryCodes(claims.UserID)
+	if err != nil {
+		log.Println(err)
+		if err.Error() == errorMessage.TotpNotFound {
+			response.JSON(w, http.StatusBadRequest, "error", errorMessage.TotpNotFound, nil)
+			return
+		}
+		response.JSON(w, http.StatusInternalServerError, "error", errorMessage.FailedCreateData, nil)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	response.JSON(w, http.StatusOK, "success", "recovery codes regenerated, previous codes are no longer valid", user.RecoveryCodesResp{RecoveryCodes: codes})
+}
+
+func (h *userHandler) LoginMfa(w http.ResponseWriter, r *http.Request) {
+	userIp := helper.GetRealIP(r)
+	userAgent := r.Header.Get("User-Agent")
+	if userAgent == "" {
+		response.JSON(w, http.StatusUnauthorized, "error", errorMessage.MissingUserAgent, nil)
+		return
+	}
+
+	postDTO := user.LoginMfaReq{}
+	err := json.NewDecoder(r.Body).Decode(&postDTO)
+	if err != nil {
+		log.Println(err)
+		response.JSON(w, http.StatusBadRequest, "error", errorMessage.RequestPayload, nil)
+		return
+	}
+
+	err = postDTO.Validate()
+	if err != nil {
+		log.Println(err)
+		response.JSON(w, http.StatusBadRequest, "error", err.Error(), nil)
+		return
+	}
+
+	token, err := h.usecase.LoginMfa(&postDTO, userIp, userAgent)
+	if err != nil {
+		log.Println(err)
+		var lockErr *usecases.LockoutError
+		if errors.As(err, &lockErr) {
+			w.Header().Set("Retry-After", retryAfter(lockErr.RetryAfter))
+			response.JSON(w, http.StatusTooManyRequests, "error", lockErr.Message, nil)
+			return
+		}
+
+		switch err.Error() {
+		case errorMessage.RateLimitUnavailable:
+			response.JSON(w, http.StatusServiceUnavailable, "error", err.Error(), nil)
+		case errorMessage.ToManyRequest:
+			response.JSON(w, http.StatusTooManyRequests, "error", errorMessage.ToManyRequest, nil)
+		case errorMessage.InvalidMfaToken, errorMessage.InvalidTotpCode, errorMessage.InvalidRecoveryCode:
+			response.JSON(w, http.StatusUnauthorized, "error", err.Error(), nil)
+		default:
+			response.JSON(w, http.StatusUnauthorized, "error", errorMessage.Unauthorized, nil)
+		}
+		return
+	}
+
+	response.JSON(w, http.StatusOK, "success", "successful login", token)
+}
@@ -0,0 +1,214 @@
+package user
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"go-auth-service/src/app/dto/user"
+	errorMessage "go-auth-service/src/infra/constants/error_message"
+	"go-auth-service/src/infra/helper"
+	"go-auth-service/src/interface/rest/response"
+)
+
+func (h *userHandler) BeginPasskeyRegistration(w http.ResponseWriter, r *http.Request) {
+	token := r.Header.Get("Authorization")
+	if token == "" {
+		response.JSON(w, http.StatusUnauthorized, "error", errorMessage.MissingToken, nil)
+		return
+	}
+
+	claims, err := helper.VerifyToken(token)
+	if err != nil {
+		log.Println(err)
+		response.JSON(w, http.StatusUnauthorized, "error", errorMessage.InvalidToken, nil)
+		return
+	}
+
+	options, err := h.usecase.BeginPasskeyRegistration(claims.UserID)
+	if err != nil {
+		log.Println(err)
+		passkeyError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, "success", "passkey registration options", options)
+}
+
+func (h *userHandler) FinishPasskeyRegistration(w http.ResponseWriter, r *http.Request) {
+	token := r.Header.Get("Authorization")
+	if token == "" {
+		response.JSON(w, http.StatusUnauthorized, "error", errorMessage.MissingToken, nil)
+		return
+	}
+
+	claims, err := helper.VerifyToken(token)
+	if err != nil {
+		log.Println(err)
+		response.JSON(w, http.StatusUnauthorized, "error", errorMessage.InvalidToken, nil)
+		return
+	}
+
+	postDTO := user.PasskeyRegisterReq{}
+	err = json.NewDecoder(r.Body).Decode(&postDTO)
+	if err != nil {
+		log.Println(err)
+		response.JSON(w, http.StatusBadRequest, "error", errorMessage.RequestPayload, nil)
+		return
+	}
+
+	err = postDTO.Validate()
+	if err != nil {
+		log.Println(err)
+		response.JSON(w, http.StatusBadRequest, "error", err.Error(), nil)
+		return
+	}
+
+	err = h.usecase.FinishPasskeyRegistration(claims.UserID, &postDTO)
+	if err != nil {
+		log.Println(err)
+		passkeyError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, "success", "passkey registered", nil)
+}
+
+func (h *userHandler) ListPasskeys(w http.ResponseWriter, r *http.Request) {
+	token := r.Header.Get("Authorization")
+	if token == "" {
+		response.JSON(w, http.StatusUnauthorized, "error", errorMessage.MissingToken, nil)
+		return
+	}
+
+	claims, err := helper.VerifyToken(token)
+	if err != nil {
+		log.Println(err)
+		response.JSON(w, http.StatusUnauthorized, "error", errorMessage.InvalidToken, nil)
+		return
+	}
+
+	passkeys, err := h.usecase.ListPasskeys(claims.UserID)
+	if err != nil {
+		log.Println(err)
+		response.JSON(w, http.StatusInternalServerError, "error", errorMessage.BadRequest, nil)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, "success", "list passkeys", passkeys)
+}
+
+func (h *userHandler) DeletePasskey(w http.ResponseWriter, r *http.Request) {
+	token := r.Header.Get("Authorization")
+	if token == "" {
+		response.JSON(w, http.StatusUnauthorized, "error", errorMessage.MissingToken, nil)
+		return
+	}
+
+	claims, err := helper.VerifyToken(token)
+	if err != nil {
+		log.Println(err)
+		response.JSON(w, http.StatusUnauthorized, "error", errorMessage.InvalidToken, nil)
+		return
+	}
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		response.JSON(w, http.StatusNotFound, "error", errorMessage.PasskeyNotFound, nil)
+		return
+	}
+
+	err = h.usecase.DeletePasskey(claims.UserID, id)
+	if err != nil {
+		log.Println(err)
+		if err.Error() == errorMessage.PasskeyNotFound {
+			response.JSON(w, http.StatusNotFound, "error", errorMessage.PasskeyNotFound, nil)
+			return
+		}
+		response.JSON(w, http.StatusInternalServerError, "error", errorMessage.FailedDeleteData, nil)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, "success", "passkey deleted", nil)
+}
+
+func (h *userHandler) BeginPasskeyLogin(w http.ResponseWriter, r *http.Request) {
+	postDTO := user.PasskeyLoginBeginReq{}
+	if r.ContentLength != 0 {
+		err := json.NewDecoder(r.Body).Decode(&postDTO)
+		if err != nil {
+			log.Println(err)
+			response.JSON(w, http.StatusBadRequest, "error", errorMessage.RequestPayload, nil)
+			return
+		}
+	}
+
+	err := postDTO.Validate()
+	if err != nil {
+		log.Println(err)
+		response.JSON(w, http.StatusBadRequest, "error", err.Error(), nil)
+		return
+	}
+
+	options, err := h.usecase.BeginPasskeyLogin(postDTO.Email)
+	if err != nil {
+		log.Println(err)
+		passkeyError(w, err)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, "success", "passkey login options", options)
+}
+
+func (h *userHandler) FinishPasskeyLogin(w http.ResponseWriter, r *http.Request) {
+	userIp := helper.GetRealIP(r)
+	userAgent := r.Header.Get("User-Agent")
+	if userAgent == "" {
+		response.JSON(w, http.StatusUnauthorized, "error", errorMessage.MissingUserAgent, nil)
+		return
+	}
+
+	postDTO := user.PasskeyLoginReq{}
+	err := json.NewDecoder(r.Body).Decode(&postDTO)
+	if err != nil {
+		log.Println(err)
+		response.JSON(w, http.StatusBadRequest, "error", errorMessage.RequestPayload, nil)
+		return
+	}
+
+	err = postDTO.Validate()
+	if err != nil {
+		log.Println(err)
+		response.JSON(w, http.StatusBadRequest, "error", err.Error(), nil)
+		return
+	}
+
+	token, err := h.usecase.FinishPasskeyLogin(&postDTO, userIp, userAgent)
+	if err != nil {
+		log.Println(err)
+		if err.Error() == errorMessage.EmailNotVerified || err.Error() == errorMessage.PasswordResetRequired {
+			response.JSON(w, http.StatusForbidden, "error", err.Error(), nil)
+			return
+		}
+		response.JSON(w, http.StatusUnauthorized, "error", errorMessage.Unauthorized, nil)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, "success", "successful login", token)
+}
+
+// passkeyError memetakan error ceremony passkey ke status HTTP
+func passkeyError(w http.ResponseWriter, err error) {
+	switch err.Error() {
+	case errorMessage.WebAuthnDisabled:
+		response.JSON(w, http.StatusNotFound, "error", err.Error(), nil)
+	case errorMessage.InvalidWebAuthnChallenge, errorMessage.InvalidWebAuthnResponse:
+		response.JSON(w, http.StatusBadRequest, "error", err.Error(), nil)
+	case errorMessage.PasskeyAlreadyRegistered:
+		response.JSON(w, http.StatusConflict, "error", err.Error(), nil)
+	default:
+		response.JSON(w, http.StatusInternalServerError, "error", errorMessage.BadRequest, nil)
+	}
+}
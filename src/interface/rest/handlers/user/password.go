@@ -0,0 +1,40 @@
+package user
+
+import (
+	"log"
+	"net/http"
+
+	errorMessage "go-auth-service/src/infra/constants/error_message"
+	"go-auth-service/src/infra/helper"
+	"go-auth-service/src/interface/rest/response"
+)
+
+// PasswordHashMetrics menampilkan ukuran antrean, jumlah request yang ditolak dan waktu tunggu
+// worker pool hashing password
+func (h *userHandler) PasswordHashMetrics(w http.ResponseWriter, r *http.Request) {
+	token := r.Header.Get("Authorization")
+	if token == "" {
+		response.JSON(w, http.StatusUnauthorized, "error", errorMessage.MissingToken, nil)
+		return
+	}
+
+	claims, err := helper.VerifyToken(token)
+	if err != nil {
+		log.Println(err)
+		response.JSON(w, http.StatusUnauthorized, "error", errorMessage.InvalidToken, nil)
+		return
+	}
+
+	metrics, err := h.usecase.PasswordHashMetrics(claims.UserID)
+	if err != nil {
+		log.Println(err)
+		if err.Error() == errorMessage.Forbidden {
+			response.JSON(w, http.StatusForbidden, "error", err.Error(), nil)
+			return
+		}
+		response.JSON(w, http.StatusInternalServerError, "error", errorMessage.BadRequest, nil)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, "success", "password hash metrics", metrics)
+}
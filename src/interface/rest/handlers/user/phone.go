@@ -0,0 +1,233 @@
+package user
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/lib/pq"
+	"go-auth-service/src/app/dto/user"
+	usecases "go-auth-service/src/app/usecases/user"
+	errorMessage "go-auth-service/src/infra/constants/error_message"
+	"go-auth-service/src/infra/helper"
+	"go-auth-service/src/interface/rest/response"
+)
+
+func (h *userHandler) RequestPhoneVerification(w http.ResponseWriter, r *http.Request) {
+	token := r.Header.Get("Authorization")
+	if token == "" {
+		response.JSON(w, http.StatusUnauthorized, "error", errorMessage.MissingToken, nil)
+		return
+	}
+
+	claims, err := helper.VerifyToken(token)
+	if err != nil {
+		log.Println(err)
+		response.JSON(w, http.StatusUnauthorized, "error", errorMessage.InvalidToken, nil)
+		return
+	}
+
+	postDTO := user.PhoneReq{}
+	err = json.NewDecoder(r.Body).Decode(&postDTO)
+	if err != nil {
+		log.Println(err)
+		response.JSON(w, http.StatusBadRequest, "error", errorMessage.RequestPayload, nil)
+		return
+	}
+
+	err = postDTO.Validate()
+	if err != nil {
+		log.Println(err)
+		response.JSON(w, http.StatusBadRequest, "error", err.Error(), nil)
+		return
+	}
+
+	err = h.usecase.RequestPhoneVerification(claims.UserID, postDTO.Phone)
+	if err != nil {
+		log.Println(err)
+		switch err.Error() {
+		case errorMessage.PhoneAlready:
+			response.JSON(w, http.StatusConflict, "error", err.Error(), nil)
+		case errorMessage.ToManyRequest:
+			response.JSON(w, http.StatusTooManyRequests, "error", errorMessage.ToManyRequest, nil)
+		default:
+			response.JSON(w, http.StatusInternalServerError, "error", errorMessage.FailedCreateData, nil)
+		}
+		return
+	}
+
+	response.JSON(w, http.StatusOK, "success", "verification code has been sent", nil)
+}
+
+func (h *userHandler) VerifyPhone(w http.ResponseWriter, r *http.Request) {
+	token := r.Header.Get("Authorization")
+	if token == "" {
+		response.JSON(w, http.StatusUnauthorized, "error", errorMessage.MissingToken, nil)
+		return
+	}
+
+	claims, err := helper.VerifyToken(token)
+	if err != nil {
+		log.Println(err)
+		response.JSON(w, http.StatusUnauthorized, "error", errorMessage.InvalidToken, nil)
+		return
+	}
+
+	postDTO := user.PhoneVerifyReq{}
+	err = json.NewDecoder(r.Body).Decode(&postDTO)
+	if err != nil {
+		log.Println(err)
+		response.JSON(w, http.StatusBadRequest, "error", errorMessage.RequestPayload, nil)
+		return
+	}
+
+	err = postDTO.Validate()
+	if err != nil {
+		log.Println(err)
+		response.JSON(w, http.StatusBadRequest, "error", err.Error(), nil)
+		return
+	}
+
+	err = h.usecase.VerifyPhone(claims.UserID, postDTO.Code)
+	if err != nil {
+		log.Println(err)
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			response.JSON(w, http.StatusConflict, "error", errorMessage.PhoneAlready, nil)
+			return
+		}
+
+		switch err.Error() {
+		case errorMessage.RateLimitUnavailable:
+			response.JSON(w, http.StatusServiceUnavailable, "error", err.Error(), nil)
+		case errorMessage.InvalidSmsOtp:
+			response.JSON(w, http.StatusBadRequest, "error", err.Error(), nil)
+		case errorMessage.ToManyRequest:
+			response.JSON(w, http.StatusTooManyRequests, "error", errorMessage.ToManyRequest, nil)
+		default:
+			response.JSON(w, http.StatusInternalServerError, "error", errorMessage.FailedUpdateData, nil)
+		}
+		return
+	}
+
+	response.JSON(w, http.StatusOK, "success", "phone number has been verified", nil)
+}
+
+func (h *userHandler) RemovePhone(w http.ResponseWriter, r *http.Request) {
+	token := r.Header.Get("Authorization")
+	if token == "" {
+		response.JSON(w, http.StatusUnauthorized, "error", errorMessage.MissingToken, nil)
+		return
+	}
+
+	claims, err := helper.VerifyToken(token)
+	if err != nil {
+		log.Println(err)
+		response.JSON(w, http.StatusUnauthorized, "error", errorMessage.InvalidToken, nil)
+		return
+	}
+
+	err = h.usecase.RemovePhone(claims.UserID)
+	if err != nil {
+		log.Println(err)
+		if err.Error() == errorMessage.PhoneNotFound {
+			response.JSON(w, http.StatusNotFound, "error", errorMessage.PhoneNotFound, nil)
+			return
+		}
+		response.JSON(w, http.StatusInternalServerError, "error", errorMessage.FailedUpdateData, nil)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, "success", "phone number has been removed", nil)
+}
+
+func (h *userHandler) RequestSmsLogin(w http.ResponseWriter, r *http.Request) {
+	postDTO := user.PhoneReq{}
+	err := json.NewDecoder(r.Body).Decode(&postDTO)
+	if err != nil {
+		log.Println(err)
+		response.JSON(w, http.StatusBadRequest, "error", errorMessage.RequestPayload, nil)
+		return
+	}
+
+	err = postDTO.Validate()
+	if err != nil {
+		log.Println(err)
+		response.JSON(w, http.StatusBadRequest, "error", err.Error(), nil)
+		return
+	}
+
+	err = h.usecase.RequestSmsLogin(postDTO.Phone, helper.GetRealIP(r))
+	if err != nil {
+		log.Println(err)
+		switch err.Error() {
+		case errorMessage.SmsLoginDisabled:
+			response.JSON(w, http.StatusNotFound, "error", err.Error(), nil)
+		case errorMessage.ToManyRequest:
+			response.JSON(w, http.StatusTooManyRequests, "error", errorMessage.ToManyRequest, nil)
+		default:
+			response.JSON(w, http.StatusInternalServerError, "error", errorMessage.FailedCreateData, nil)
+		}
+		return
+	}
+
+	response.JSON(w, http.StatusOK, "success", "if the phone number is registered, a login code has been sent", nil)
+}
+
+func (h *userHandler) LoginSms(w http.ResponseWriter, r *http.Request) {
+	userIp := helper.GetRealIP(r)
+	userAgent := r.Header.Get("User-Agent")
+	if userAgent == "" {
+		response.JSON(w, http.StatusUnauthorized, "error", errorMessage.MissingUserAgent, nil)
+		return
+	}
+
+	postDTO := user.SmsLoginReq{}
+	err := json.NewDecoder(r.Body).Decode(&postDTO)
+	if err != nil {
+		log.Println(err)
+		response.JSON(w, http.StatusBadRequest, "error", errorMessage.RequestPayload, nil)
+		return
+	}
+
+	err = postDTO.Validate()
+	if err != nil {
+		log.Println(err)
+		response.JSON(w, http.StatusBadRequest, "error", err.Error(), nil)
+		return
+	}
+
+	token, err := h.usecase.LoginSms(&postDTO, userIp, userAgent)
+	if err != nil {
+		log.Println(err)
+		var lockErr *usecases.LockoutError
+		if errors.As(err, &lockErr) {
+			w.Header().Set("Retry-After", retryAfter(lockErr.RetryAfter))
+			response.JSON(w, http.StatusTooManyRequests, "error", lockErr.Message, nil)
+			return
+		}
+
+		switch err.Error() {
+		case errorMessage.RateLimitUnavailable:
+			response.JSON(w, http.StatusServiceUnavailable, "error", err.Error(), nil)
+		case errorMessage.SmsLoginDisabled:
+			response.JSON(w, http.StatusNotFound, "error", err.Error(), nil)
+		case errorMessage.ToManyRequest:
+			response.JSON(w, http.StatusTooManyRequests, "error", errorMessage.ToManyRequest, nil)
+		case errorMessage.PasswordResetRequired, errorMessage.EmailNotVerified:
+			response.JSON(w, http.StatusForbidden, "error", err.Error(), nil)
+		case errorMessage.InvalidSmsOtp:
+			response.JSON(w, http.StatusUnauthorized, "error", err.Error(), nil)
+		default:
+			response.JSON(w, http.StatusUnauthorized, "error", errorMessage.Unauthorized, nil)
+		}
+		return
+	}
+
+	if token.MfaRequired {
+		response.JSON(w, http.StatusOK, "success", errorMessage.MfaRequired, token)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, "success", "successful login", token)
+}
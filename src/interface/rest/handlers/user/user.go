@@ -2,15 +2,21 @@ package user
 
 import (
 	"encoding/json"
+	"errors"
 	"go-auth-service/src/infra/helper"
+	"go-auth-service/src/infra/password"
 	"log"
 	"net/http"
-	"strings"
+	"strconv"
+	"time"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/lib/pq"
 	"go-auth-service/src/app/dto/user"
 	usecases "go-auth-service/src/app/usecases/user"
+	"go-auth-service/src/infra/constants/common"
 	errorMessage "go-auth-service/src/infra/constants/error_message"
+	"go-auth-service/src/interface/rest/page"
 	"go-auth-service/src/interface/rest/response"
 )
 
@@ -20,39 +26,105 @@ type UserHandlerInterface interface {
 	Me(w http.ResponseWriter, r *http.Request)
 	RefreshToken(w http.ResponseWriter, r *http.Request)
 	Logout(w http.ResponseWriter, r *http.Request)
+	ConfirmRevokeToken(w http.ResponseWriter, r *http.Request)
 	RevokeToken(w http.ResponseWriter, r *http.Request)
 	UpdateProfile(w http.ResponseWriter, r *http.Request)
 	UpdateProfilePicture(w http.ResponseWriter, r *http.Request)
 	UpdatePassword(w http.ResponseWriter, r *http.Request)
+	ForgotPassword(w http.ResponseWriter, r *http.Request)
+	ResetPassword(w http.ResponseWriter, r *http.Request)
+	ListSessions(w http.ResponseWriter, r *http.Request)
+	RevokeSession(w http.ResponseWriter, r *http.Request)
+	RevokeOtherSessions(w http.ResponseWriter, r *http.Request)
+	VerifyEmail(w http.ResponseWriter, r *http.Request)
+	ResendVerification(w http.ResponseWriter, r *http.Request)
+	EnrollTotp(w http.ResponseWriter, r *http.Request)
+	ConfirmTotp(w http.ResponseWriter, r *http.Request)
+	RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request)
+	LoginMfa(w http.ResponseWriter, r *http.Request)
+	BeginPasskeyRegistration(w http.ResponseWriter, r *http.Request)
+	FinishPasskeyRegistration(w http.ResponseWriter, r *http.Request)
+	ListPasskeys(w http.ResponseWriter, r *http.Request)
+	DeletePasskey(w http.ResponseWriter, r *http.Request)
+	BeginPasskeyLogin(w http.ResponseWriter, r *http.Request)
+	FinishPasskeyLogin(w http.ResponseWriter, r *http.Request)
+	RequestMagicLink(w http.ResponseWriter, r *http.Request)
+	LoginMagicLink(w http.ResponseWriter, r *http.Request)
+	RequestPhoneVerification(w http.ResponseWriter, r *http.Request)
+	VerifyPhone(w http.ResponseWriter, r *http.Request)
+	RemovePhone(w http.ResponseWriter, r *http.Request)
+	RequestSmsLogin(w http.ResponseWriter, r *http.Request)
+	LoginSms(w http.ResponseWriter, r *http.Request)
+	UnlockAccount(w http.ResponseWriter, r *http.Request)
+	PasswordHashMetrics(w http.ResponseWriter, r *http.Request)
 }
 
 type userHandler struct {
 	usecase usecases.UserUCInterface
+	pages   page.RendererInterface
 }
 
-func NewUserHandler(h usecases.UserUCInterface) UserHandlerInterface {
+func NewUserHandler(h usecases.UserUCInterface, pages page.RendererInterface) UserHandlerInterface {
 	return &userHandler{
 		usecase: h,
+		pages:   pages,
 	}
 }
 
-func (h *userHandler) RevokeToken(w http.ResponseWriter, r *http.Request) {
-	pathParts := strings.Split(r.URL.Path, "/")
+// ConfirmRevokeToken menampilkan halaman konfirmasi untuk link "this wasn't me". Sesi baru
+// diakhiri lewat POST dari halaman tersebut, karena link email bisa dibuka otomatis oleh pemindai email.
+func (h *userHandler) ConfirmRevokeToken(w http.ResponseWriter, r *http.Request) {
+	if chi.URLParam(r, "token") == "" {
+		h.pages.Result(w, r, page.Invalid, "")
+		return
+	}
+
+	message := "Sign out the session from this login? You will need to reset your password before logging in again."
+	button := "Sign out session"
+	if all, _ := strconv.ParseBool(r.URL.Query().Get("all")); all {
+		message = "Sign out all sessions? You will need to reset your password before logging in again."
+		button = "Sign out all sessions"
+	}
+
+	h.pages.Confirm(w, r, "Wasn't You?", message, button)
+}
 
-	if len(pathParts) < 5 {
-		response.JSON(w, http.StatusBadRequest, "error", errorMessage.BadRequest, nil)
+func (h *userHandler) RevokeToken(w http.ResponseWriter, r *http.Request) {
+	token := chi.URLParam(r, "token")
+	if token == "" {
+		h.pages.Result(w, r, page.Invalid, "")
 		return
 	}
 
-	emailEncrypt := pathParts[4]
+	all, _ := strconv.ParseBool(r.URL.Query().Get("all"))
 
-	err := h.usecase.RevokeToken(emailEncrypt)
+	err := h.usecase.RevokeToken(token, all)
 	if err != nil {
-		response.JSON(w, http.StatusUnauthorized, "error", errorMessage.ExpiredToken, nil)
+		log.Println(err)
+		h.pages.Result(w, r, linkOutcome(err), "")
+		return
+	}
+
+	message := "The session has been signed out. Please reset your password before logging in again."
+	if all {
+		message = "All sessions have been signed out. Please reset your password before logging in again."
 	}
 
-	http.Redirect(w, r, "https://www.kemhan.go.id/", http.StatusFound)
+	h.pages.Result(w, r, page.Success, message)
+}
+
+// linkOutcome memetakan error usecase dari link email ke halaman hasil
+func linkOutcome(err error) page.Outcome {
+	switch err.Error() {
+	case errorMessage.ExpiredToken:
+		return page.Expired
+	case errorMessage.RevokeLinkUsed:
+		return page.Used
+	case errorMessage.InvalidEncryptedData:
+		return page.Invalid
+	}
 
+	return page.Failed
 }
 
 func (h *userHandler) Register(w http.ResponseWriter, r *http.Request) {
@@ -74,6 +146,16 @@ func (h *userHandler) Register(w http.ResponseWriter, r *http.Request) {
 	err = h.usecase.Register(&postDTO)
 	if err != nil {
 		log.Println(err)
+		if err.Error() == errorMessage.PasswordHashBusy {
+			passwordHashBusy(w)
+			return
+		}
+		var policyErr *password.PolicyError
+		if errors.As(err, &policyErr) {
+			response.JSON(w, http.StatusBadRequest, "error", policyErr.Message, nil)
+			return
+		}
+
 		if err.Error() == errorMessage.EmailAlready {
 			response.JSON(w, http.StatusConflict, "error", errorMessage.EmailAlready, nil)
 			return
@@ -117,10 +199,33 @@ func (h *userHandler) Login(w http.ResponseWriter, r *http.Request) {
 	token, err := h.usecase.Login(&postDTO, userIp, userAgent)
 	if err != nil {
 		log.Println(err)
+		var lockErr *usecases.LockoutError
+		if errors.As(err, &lockErr) {
+			w.Header().Set("Retry-After", retryAfter(lockErr.RetryAfter))
+			response.JSON(w, http.StatusTooManyRequests, "error", lockErr.Message, nil)
+			return
+		}
+		if err.Error() == errorMessage.PasswordHashBusy {
+			passwordHashBusy(w)
+			return
+		}
+		if err.Error() == errorMessage.PasswordResetRequired {
+			response.JSON(w, http.StatusForbidden, "error", errorMessage.PasswordResetRequired, nil)
+			return
+		}
+		if err.Error() == errorMessage.EmailNotVerified {
+			response.JSON(w, http.StatusForbidden, "error", errorMessage.EmailNotVerified, nil)
+			return
+		}
 		response.JSON(w, http.StatusUnauthorized, "error", errorMessage.Unauthorized, nil)
 		return
 	}
 
+	if token.MfaRequired {
+		response.JSON(w, http.StatusOK, "success", errorMessage.MfaRequired, token)
+		return
+	}
+
 	response.JSON(w, http.StatusOK, "success", "successful login", token)
 }
 
@@ -178,12 +283,6 @@ func (h *userHandler) Logout(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userAgent := r.Header.Get("User-Agent")
-	if userAgent == "" {
-		response.JSON(w, http.StatusUnauthorized, "error", errorMessage.MissingUserAgent, nil)
-		return
-	}
-
 	claims, err := helper.VerifyToken(token)
 	if err != nil {
 		log.Println(err)
@@ -191,7 +290,7 @@ func (h *userHandler) Logout(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.usecase.Logout(claims.UserID, claims.Email, userAgent)
+	err = h.usecase.Logout(claims)
 	if err != nil {
 		log.Println(err)
 		response.JSON(w, http.StatusUnauthorized, "error", errorMessage.Unauthorized, nil)
@@ -285,7 +384,8 @@ func (h *userHandler) UpdatePassword(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	claims, err := helper.VerifyToken(token)
+	// token sudah diverifikasi oleh middleware rate limit atau verifikasi email
+	claims, err := helper.RequestClaims(r)
 	if err != nil {
 		log.Println(err)
 		response.JSON(w, http.StatusUnauthorized, "error", errorMessage.InvalidToken, nil)
@@ -310,9 +410,214 @@ func (h *userHandler) UpdatePassword(w http.ResponseWriter, r *http.Request) {
 	err = h.usecase.UpdatePassword(claims.UserID, postDTO.OldPassword, postDTO.NewPassword)
 	if err != nil {
 		log.Println(err)
+		if err.Error() == errorMessage.PasswordHashBusy {
+			passwordHashBusy(w)
+			return
+		}
+		var policyErr *password.PolicyError
+		if errors.As(err, &policyErr) {
+			response.JSON(w, http.StatusBadRequest, "error", policyErr.Message, nil)
+			return
+		}
 		response.JSON(w, http.StatusUnauthorized, "error", errorMessage.Unauthorized, nil)
 		return
 	}
 
 	response.JSON(w, http.StatusOK, "success", "update password", nil)
 }
+
+func (h *userHandler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	token := r.Header.Get("Authorization")
+	if token == "" {
+		response.JSON(w, http.StatusUnauthorized, "error", errorMessage.MissingToken, nil)
+		return
+	}
+
+	claims, err := helper.VerifyToken(token)
+	if err != nil {
+		log.Println(err)
+		response.JSON(w, http.StatusUnauthorized, "error", errorMessage.InvalidToken, nil)
+		return
+	}
+
+	sessions, err := h.usecase.ListSessions(claims)
+	if err != nil {
+		log.Println(err)
+		response.JSON(w, http.StatusInternalServerError, "error", errorMessage.BadRequest, nil)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, "success", "active sessions", sessions)
+}
+
+func (h *userHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	token := r.Header.Get("Authorization")
+	if token == "" {
+		response.JSON(w, http.StatusUnauthorized, "error", errorMessage.MissingToken, nil)
+		return
+	}
+
+	claims, err := helper.VerifyToken(token)
+	if err != nil {
+		log.Println(err)
+		response.JSON(w, http.StatusUnauthorized, "error", errorMessage.InvalidToken, nil)
+		return
+	}
+
+	err = h.usecase.RevokeSession(claims, chi.URLParam(r, "id"))
+	if err != nil {
+		log.Println(err)
+		if err.Error() == errorMessage.SessionNotFound {
+			response.JSON(w, http.StatusNotFound, "error", errorMessage.SessionNotFound, nil)
+			return
+		}
+		response.JSON(w, http.StatusInternalServerError, "error", errorMessage.FailedUpdateData, nil)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, "success", "session revoked", nil)
+}
+
+func (h *userHandler) RevokeOtherSessions(w http.ResponseWriter, r *http.Request) {
+	token := r.Header.Get("Authorization")
+	if token == "" {
+		response.JSON(w, http.StatusUnauthorized, "error", errorMessage.MissingToken, nil)
+		return
+	}
+
+	claims, err := helper.VerifyToken(token)
+	if err != nil {
+		log.Println(err)
+		response.JSON(w, http.StatusUnauthorized, "error", errorMessage.InvalidToken, nil)
+		return
+	}
+
+	err = h.usecase.RevokeOtherSessions(claims)
+	if err != nil {
+		log.Println(err)
+		response.JSON(w, http.StatusInternalServerError, "error", errorMessage.FailedUpdateData, nil)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, "success", "all other sessions revoked", nil)
+}
+
+func (h *userHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	postDTO := user.ForgotPasswordReq{}
+	err := json.NewDecoder(r.Body).Decode(&postDTO)
+	if err != nil {
+		log.Println(err)
+		response.JSON(w, http.StatusBadRequest, "error", errorMessage.RequestPayload, nil)
+		return
+	}
+
+	err = postDTO.Validate()
+	if err != nil {
+		log.Println(err)
+		response.JSON(w, http.StatusBadRequest, "error", err.Error(), nil)
+		return
+	}
+
+	err = h.usecase.ForgotPassword(postDTO.Email)
+	if err != nil {
+		log.Println(err)
+		response.JSON(w, http.StatusInternalServerError, "error", errorMessage.BadRequest, nil)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, "success", "if the email is registered, a reset password link has been sent", nil)
+}
+
+func (h *userHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	postDTO := user.ResetPasswordReq{}
+	err := json.NewDecoder(r.Body).Decode(&postDTO)
+	if err != nil {
+		log.Println(err)
+		response.JSON(w, http.StatusBadRequest, "error", errorMessage.RequestPayload, nil)
+		return
+	}
+
+	err = postDTO.Validate()
+	if err != nil {
+		log.Println(err)
+		response.JSON(w, http.StatusBadRequest, "error", err.Error(), nil)
+		return
+	}
+
+	err = h.usecase.ResetPassword(postDTO.Token, postDTO.NewPassword)
+	if err != nil {
+		log.Println(err)
+		if err.Error() == errorMessage.PasswordHashBusy {
+			passwordHashBusy(w)
+			return
+		}
+		var policyErr *password.PolicyError
+		if errors.As(err, &policyErr) {
+			response.JSON(w, http.StatusBadRequest, "error", policyErr.Message, nil)
+			return
+		}
+		if err.Error() == errorMessage.InvalidResetToken {
+			response.JSON(w, http.StatusBadRequest, "error", errorMessage.InvalidResetToken, nil)
+			return
+		}
+		response.JSON(w, http.StatusInternalServerError, "error", errorMessage.FailedUpdateData, nil)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, "success", "reset password", nil)
+}
+
+func (h *userHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		h.pages.Result(w, r, page.Invalid, "")
+		return
+	}
+
+	err := h.usecase.VerifyEmail(token)
+	if err != nil {
+		log.Println(err)
+		h.pages.Result(w, r, linkOutcome(err), "")
+		return
+	}
+
+	h.pages.Result(w, r, page.Success, "Your email address has been verified.")
+}
+
+func (h *userHandler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	postDTO := user.ResendVerificationReq{}
+	err := json.NewDecoder(r.Body).Decode(&postDTO)
+	if err != nil {
+		log.Println(err)
+		response.JSON(w, http.StatusBadRequest, "error", errorMessage.RequestPayload, nil)
+		return
+	}
+
+	err = postDTO.Validate()
+	if err != nil {
+		log.Println(err)
+		response.JSON(w, http.StatusBadRequest, "error", err.Error(), nil)
+		return
+	}
+
+	err = h.usecase.ResendVerification(postDTO.Email)
+	if err != nil {
+		log.Println(err)
+		response.JSON(w, http.StatusInternalServerError, "error", errorMessage.BadRequest, nil)
+		return
+	}
+
+	response.JSON(w, http.StatusOK, "success", "if the email is registered and not yet verified, a verification link has been sent", nil)
+}
+
+// passwordHashBusy menolak request saat antrean hashing password penuh, client diminta
+// mencoba lagi setelah common.PasswordHashRetryAfter
+func passwordHashBusy(w http.ResponseWriter) {
+	w.Header().Set("Retry-After", retryAfter(common.PasswordHashRetryAfter))
+	response.JSON(w, http.StatusServiceUnavailable, "error", errorMessage.PasswordHashBusy, nil)
+}
+
+// retryAfter membulatkan durasi ke atas dalam detik untuk header Retry-After
+func retryAfter(d time.Duration) string {
+	return strconv.FormatInt(int64((d+time.Second-1)/time.Second), 10)
+}
@@ -0,0 +1,54 @@
+package wellknown
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"go-auth-service/src/app/dto/oauth"
+	"go-auth-service/src/infra/constants/common"
+	"go-auth-service/src/infra/helper"
+	"go-auth-service/src/interface/rest/response"
+)
+
+type WellKnownHandlerInterface interface {
+	JWKS(w http.ResponseWriter, r *http.Request)
+	OpenIDConfiguration(w http.ResponseWriter, r *http.Request)
+}
+
+type wellKnownHandler struct{}
+
+func NewWellKnownHandler() WellKnownHandlerInterface {
+	return &wellKnownHandler{}
+}
+
+// JWKS menampilkan public key untuk verifikasi access token secara offline.
+// Response mengikuti format RFC 7517 sehingga ditulis dengan response.RawJSON.
+func (h *wellKnownHandler) JWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(common.JwksCacheMaxAge.Seconds())))
+	response.RawJSON(w, http.StatusOK, helper.JWKS())
+}
+
+// OpenIDConfiguration menampilkan discovery document OpenID Connect. Semua endpoint
+// diturunkan dari JWT_ISSUER sehingga issuer harus berupa URL publik service ini.
+func (h *wellKnownHandler) OpenIDConfiguration(w http.ResponseWriter, r *http.Request) {
+	issuer := strings.TrimSuffix(helper.TokenIssuer(), "/")
+
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(common.JwksCacheMaxAge.Seconds())))
+	response.RawJSON(w, http.StatusOK, oauth.OpenIDConfiguration{
+		Issuer:                            helper.TokenIssuer(),
+		AuthorizationEndpoint:             issuer + "/oauth/authorize",
+		TokenEndpoint:                     issuer + "/oauth/token",
+		UserinfoEndpoint:                  issuer + "/oauth/userinfo",
+		IntrospectionEndpoint:             issuer + "/oauth/introspect",
+		JwksUri:                           issuer + "/.well-known/jwks.json",
+		ResponseTypesSupported:            []string{common.ResponseTypeCode},
+		GrantTypesSupported:               []string{common.GrantTypeAuthorizationCode, common.GrantTypeRefreshToken, common.GrantTypeClientCredentials},
+		SubjectTypesSupported:             []string{"public"},
+		IdTokenSigningAlgValuesSupported:  helper.SigningAlgorithms(),
+		ScopesSupported:                   []string{common.ScopeOpenId, common.ScopeProfile, common.ScopeEmail},
+		ClaimsSupported:                   []string{"sub", "iss", "aud", "exp", "iat", "auth_time", "nonce", "email", "email_verified", "name", "given_name", "family_name", "picture", "gender", "birthdate"},
+		CodeChallengeMethodsSupported:     []string{common.CodeChallengeS256},
+		TokenEndpointAuthMethodsSupported: []string{"none", "client_secret_basic", "client_secret_post"},
+	})
+}
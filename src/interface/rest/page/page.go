@@ -0,0 +1,178 @@
+package page
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"go-auth-service/src/infra/config"
+)
+
+//go:embed templates/*.html
+var embedded embed.FS
+
+// Outcome adalah hasil dari link yang dibuka user di browser (link email dan sejenisnya)
+type Outcome string
+
+const (
+	Success Outcome = "success"
+	Expired Outcome = "expired"
+	Used    Outcome = "used"
+	Invalid Outcome = "invalid"
+	Failed  Outcome = "error"
+)
+
+type outcomeText struct {
+	StatusCode int
+	Title      string
+	Message    string
+}
+
+var outcomes = map[Outcome]outcomeText{
+	Success: {http.StatusOK, "Done", "Your request has been processed."},
+	Expired: {http.StatusGone, "Link Expired", "This link has expired. Please request a new one."},
+	Used:    {http.StatusGone, "Link Already Used", "This link has already been used and cannot be used again."},
+	Invalid: {http.StatusBadRequest, "Invalid Link", "This link is invalid. Please make sure you copied the whole link."},
+	Failed:  {http.StatusInternalServerError, "Something Went Wrong", "We could not process your request. Please try again later."},
+}
+
+var colorRegex = regexp.MustCompile(`^#[0-9a-fA-F]{3,8}$`)
+
+type RendererInterface interface {
+	Result(w http.ResponseWriter, r *http.Request, outcome Outcome, message string)
+	Confirm(w http.ResponseWriter, r *http.Request, title, message, button string)
+}
+
+type renderer struct {
+	tmpl         *template.Template
+	conf         config.PageConf
+	allowedHosts map[string]bool
+}
+
+// New memuat template halaman yang di-embed. Template di PAGE_TEMPLATE_DIR menggantikan template
+// embedded dengan nama file yang sama, sehingga direktori lama yang hanya berisi result.html tetap bisa dipakai.
+func New(conf config.PageConf) (RendererInterface, error) {
+	tmpl, err := template.ParseFS(embedded, "templates/*.html")
+	if err != nil {
+		return nil, err
+	}
+
+	if conf.TemplateDir != "" {
+		files, err := filepath.Glob(filepath.Join(conf.TemplateDir, "*.html"))
+		if err != nil {
+			return nil, err
+		}
+		if len(files) == 0 {
+			return nil, errors.New("no page template found in PAGE_TEMPLATE_DIR")
+		}
+
+		if tmpl, err = tmpl.ParseFiles(files...); err != nil {
+			return nil, err
+		}
+	}
+
+	// warna disisipkan ke CSS sehingga hanya format hex yang diterima
+	if conf.PrimaryColor != "" && !colorRegex.MatchString(conf.PrimaryColor) {
+		return nil, fmt.Errorf("invalid PAGE_PRIMARY_COLOR: %s", conf.PrimaryColor)
+	}
+
+	p := &renderer{
+		tmpl:         tmpl,
+		conf:         conf,
+		allowedHosts: map[string]bool{},
+	}
+
+	for _, host := range conf.RedirectAllowlist {
+		p.allowedHosts[strings.ToLower(host)] = true
+	}
+
+	if conf.RedirectUrl != "" {
+		target, err := url.Parse(conf.RedirectUrl)
+		if err != nil || (target.Scheme != "https" && target.Scheme != "http") || target.Host == "" {
+			return nil, fmt.Errorf("invalid PAGE_REDIRECT_URL: %s", conf.RedirectUrl)
+		}
+		p.allowedHosts[strings.ToLower(target.Host)] = true
+	}
+
+	return p, nil
+}
+
+// Result menampilkan halaman hasil. Message kosong memakai pesan default sesuai outcome.
+func (p *renderer) Result(w http.ResponseWriter, r *http.Request, outcome Outcome, message string) {
+	text, ok := outcomes[outcome]
+	if !ok {
+		outcome, text = Failed, outcomes[Failed]
+	}
+
+	if message == "" {
+		message = text.Message
+	}
+
+	data := p.data(text.Title, message)
+	data["outcome"] = string(outcome)
+	data["redirect_url"] = p.redirectUrl(r)
+
+	p.render(w, text.StatusCode, "result.html", data)
+}
+
+// Confirm menampilkan halaman konfirmasi dengan form POST ke URL yang sama (termasuk query),
+// agar aksi dari link email tidak dijalankan oleh GET, misalnya oleh link preview atau pemindai email.
+func (p *renderer) Confirm(w http.ResponseWriter, r *http.Request, title, message, button string) {
+	data := p.data(title, message)
+	data["button"] = button
+
+	p.render(w, http.StatusOK, "confirm.html", data)
+}
+
+// data berisi variabel yang dipakai seluruh template
+func (p *renderer) data(title, message string) map[string]interface{} {
+	return map[string]interface{}{
+		"title":         title,
+		"message":       message,
+		"app_name":      p.conf.AppName,
+		"primary_color": template.CSS(p.conf.PrimaryColor),
+		"logo_url":      p.conf.LogoUrl,
+		"support_email": p.conf.SupportEmail,
+	}
+}
+
+func (p *renderer) render(w http.ResponseWriter, statusCode int, name string, data map[string]interface{}) {
+	// token ada di URL, jangan sampai bocor lewat Referer atau halaman di-frame
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	w.Header().Set("X-Frame-Options", "DENY")
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'; img-src https: data:; form-action 'self'")
+	w.WriteHeader(statusCode)
+
+	if err := p.tmpl.ExecuteTemplate(w, name, data); err != nil {
+		log.Println(err)
+	}
+}
+
+// redirectUrl memakai parameter redirect jika host-nya ada di allowlist,
+// selain itu PAGE_REDIRECT_URL, agar link tidak bisa dipakai sebagai open redirect
+func (p *renderer) redirectUrl(r *http.Request) string {
+	requested := r.URL.Query().Get("redirect")
+	if requested == "" {
+		return p.conf.RedirectUrl
+	}
+
+	target, err := url.Parse(requested)
+	if err != nil || (target.Scheme != "https" && target.Scheme != "http") || target.User != nil {
+		return p.conf.RedirectUrl
+	}
+
+	if !p.allowedHosts[strings.ToLower(target.Host)] {
+		return p.conf.RedirectUrl
+	}
+
+	return target.String()
+}
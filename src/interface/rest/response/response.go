@@ -28,3 +28,21 @@ func JSON(w http.ResponseWriter, statusCode int, status string, message string,
 		json.NewEncoder(w).Encode(SuccessResponse{Status: status, Message: message, Data: data})
 	}
 }
+
+// OAuthErrorResponse defines the structure for OAuth2 error responses (RFC 6749 5.2).
+type OAuthErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+// RawJSON writes data without the status/message envelope, used by protocol
+// endpoints (OAuth2, OpenID Connect, JWKS) whose body format is fixed by a spec.
+func RawJSON(w http.ResponseWriter, statusCode int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(data)
+}
+
+func OAuthError(w http.ResponseWriter, statusCode int, code string, description string) {
+	RawJSON(w, statusCode, OAuthErrorResponse{Error: code, ErrorDescription: description})
+}
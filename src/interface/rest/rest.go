@@ -10,10 +10,14 @@ import (
 
 	usecases "go-auth-service/src/app/usecases"
 	"go-auth-service/src/infra/config"
+	redis "go-auth-service/src/infra/persistence/redis/service"
 
 	//healthHandler "auth-user-service/src/interface/rest/handlers"
+	oauthHandler "go-auth-service/src/interface/rest/handlers/oauth"
 	userHandler "go-auth-service/src/interface/rest/handlers/user"
+	wellKnownHandler "go-auth-service/src/interface/rest/handlers/wellknown"
 
+	"go-auth-service/src/interface/rest/page"
 	"go-auth-service/src/interface/rest/route"
 
 	"github.com/go-chi/chi/middleware"
@@ -34,12 +38,22 @@ type HttpServer struct {
 // routes using different http verbs.
 func New(
 	conf config.HttpConf,
+	pageConf config.PageConf,
+	verificationConf config.EmailVerificationConf,
+	rateLimitConf config.RateLimitConf,
+	redisService redis.ServRedisInterface,
 	isProd bool,
 	logger *logrus.Logger,
 	useCases usecases.AllUseCases,
 ) (*HttpServer, error) {
+	pages, err := page.New(pageConf)
+	if err != nil {
+		return nil, err
+	}
+
 	// wrap all the routes
-	routeHandler := makeRoute(conf.XRequestID, conf.Timeout, isProd, logger, useCases)
+	limiter := route.NewRateLimiter(redisService, rateLimitConf)
+	routeHandler := makeRoute(conf.XRequestID, conf.Timeout, isProd, logger, useCases, pages, verificationConf, limiter)
 
 	// http service
 	srv := http.Server{
@@ -57,6 +71,9 @@ func makeRoute(
 	isProd bool,
 	logger *logrus.Logger,
 	useCases usecases.AllUseCases,
+	pages page.RendererInterface,
+	verificationConf config.EmailVerificationConf,
+	limiter *route.RateLimiter,
 ) *chi.Mux {
 
 	r := chi.NewRouter()
@@ -66,7 +83,7 @@ func makeRoute(
 		AllowedOrigins:   []string{"*"},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-		ExposedHeaders:   []string{"Link"},
+		ExposedHeaders:   []string{"Link", "Retry-After", "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset"},
 		AllowCredentials: true,
 		MaxAge:           300, // MaxAge untuk OPTIONS preflight request
 	}))
@@ -84,10 +101,15 @@ func makeRoute(
 	}
 
 	// instantiate the handlers here ...
-	uh := userHandler.NewUserHandler(useCases.UserUC)
+	uh := userHandler.NewUserHandler(useCases.UserUC, pages)
+	wh := wellKnownHandler.NewWellKnownHandler()
+	oh := oauthHandler.NewOAuthHandler(useCases.OAuthUC)
+
+	r.Mount("/.well-known", route.WellKnownRouter(wh))
+	r.Mount("/oauth", route.OAuthRouter(oh, limiter))
 
 	r.Route("/api", func(r chi.Router) {
-		r.Mount("/auth", route.UserRouter(uh))
+		r.Mount("/auth", route.UserRouter(uh, verificationConf, limiter))
 	})
 
 	return r
@@ -0,0 +1,21 @@
+package route
+
+import (
+	"github.com/go-chi/chi/v5"
+	"net/http"
+
+	handlersOAuth "go-auth-service/src/interface/rest/handlers/oauth"
+)
+
+func OAuthRouter(h handlersOAuth.OAuthHandlerInterface, limiter *RateLimiter) http.Handler {
+	r := chi.NewRouter()
+
+	r.Get("/authorize", h.Authorize)
+	r.Post("/authorize", h.AuthorizeLogin)
+	r.With(limiter.Limit("oauth-token")).Post("/token", h.Token)
+	r.Get("/userinfo", h.UserInfo)
+	r.Post("/userinfo", h.UserInfo)
+	r.Post("/introspect", h.Introspect)
+
+	return r
+}
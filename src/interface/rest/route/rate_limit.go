@@ -0,0 +1,97 @@
+package route
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go-auth-service/src/infra/config"
+	"go-auth-service/src/infra/constants/common"
+	errorMessage "go-auth-service/src/infra/constants/error_message"
+	"go-auth-service/src/infra/helper"
+	redis "go-auth-service/src/infra/persistence/redis/service"
+	"go-auth-service/src/interface/rest/response"
+)
+
+// RateLimiter membatasi request per route dengan sliding window di Redis sesuai RATE_LIMIT_POLICIES
+type RateLimiter struct {
+	Redis redis.ServRedisInterface
+	Conf  config.RateLimitConf
+}
+
+func NewRateLimiter(redisService redis.ServRedisInterface, conf config.RateLimitConf) *RateLimiter {
+	return &RateLimiter{
+		Redis: redisService,
+		Conf:  conf,
+	}
+}
+
+// Limit memasang policy dengan nama tertentu. Route tanpa policy (atau limit 0) tidak dibatasi.
+// Setiap response membawa header X-RateLimit-*, request yang ditolak mendapat 429 dan Retry-After.
+func (l *RateLimiter) Limit(name string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		policy, ok := l.Conf.Policies[name]
+		if !ok || policy.Limit <= 0 {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if policy.Key == "account" {
+				r = helper.WithRequestClaims(r)
+			}
+
+			key := fmt.Sprintf("%s:%s:%s", common.RateLimitKey, name, rateLimitIdentity(r, policy.Key))
+
+			result, err := l.Redis.SlidingWindow(context.Background(), key, policy.Limit, policy.Window)
+			if err != nil {
+				if l.Conf.FailOpen {
+					next.ServeHTTP(w, r)
+					return
+				}
+				response.JSON(w, http.StatusServiceUnavailable, "error", errorMessage.RateLimitUnavailable, nil)
+				return
+			}
+
+			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(result.Reset).Unix(), 10))
+
+			if !result.Allowed {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(result.RetryAfter.Seconds()))))
+				response.JSON(w, http.StatusTooManyRequests, "error", errorMessage.ToManyRequest, nil)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// rateLimitIdentity menentukan penghitung request. Access token yang tidak valid atau client_id
+// yang kosong dihitung per IP, sehingga token palsu tidak menghasilkan penghitung baru.
+// client_id belum terautentikasi saat middleware berjalan, jadi selalu digabung dengan IP agar
+// request palsu dengan client_id milik client lain tidak menghabiskan jatah client tersebut.
+func rateLimitIdentity(r *http.Request, key string) string {
+	ip := helper.GetRealIP(r)
+
+	switch key {
+	case "account":
+		claims, err := helper.RequestClaims(r)
+		if err == nil {
+			return fmt.Sprintf("account:%d", claims.UserID)
+		}
+	case "client":
+		clientId, _, ok := r.BasicAuth()
+		if !ok {
+			clientId = r.FormValue("client_id")
+		}
+		if clientId != "" {
+			return fmt.Sprintf("client:%s:ip:%s", clientId, ip)
+		}
+	}
+
+	return "ip:" + ip
+}
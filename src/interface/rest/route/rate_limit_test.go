@@ -0,0 +1,222 @@
+package route
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"go-auth-service/src/infra/config"
+	"go-auth-service/src/infra/constants/common"
+	redis "go-auth-service/src/infra/persistence/redis/service"
+)
+
+// fakeRateLimitRedis mengembalikan result atau err yang sudah ditentukan dan mencatat key terakhir.
+// Method lain dari interface tidak dipakai test sehingga memanggilnya akan panic.
+type fakeRateLimitRedis struct {
+	redis.ServRedisInterface
+
+	result *redis.RateLimitResult
+	err    error
+	key    string
+}
+
+func (f *fakeRateLimitRedis) SlidingWindow(ctx context.Context, key string, limit int, window time.Duration) (*redis.RateLimitResult, error) {
+	f.key = key
+	if f.err != nil {
+		return nil, f.err
+	}
+	return f.result, nil
+}
+
+func testRateLimitConf(failOpen bool) config.RateLimitConf {
+	return config.RateLimitConf{
+		FailOpen: failOpen,
+		Policies: map[string]config.RateLimitPolicy{
+			"login":    {Limit: 20, Window: time.Minute, Key: "ip"},
+			"disabled": {Limit: 0, Window: time.Minute, Key: "ip"},
+		},
+	}
+}
+
+var okHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+})
+
+func TestRateLimiterLimit(t *testing.T) {
+	redisDown := errors.New("redis: connection refused")
+
+	tests := []struct {
+		name           string
+		policy         string
+		failOpen       bool
+		result         *redis.RateLimitResult
+		err            error
+		wantStatus     int
+		wantRemaining  string
+		wantRetryAfter string
+		wantReset      time.Duration
+	}{
+		{
+			name:          "allowed",
+			policy:        "login",
+			result:        &redis.RateLimitResult{Allowed: true, Limit: 20, Remaining: 19, Reset: time.Minute},
+			wantStatus:    http.StatusOK,
+			wantRemaining: "19",
+			wantReset:     time.Minute,
+		},
+		{
+			name:           "rejected",
+			policy:         "login",
+			result:         &redis.RateLimitResult{Allowed: false, Limit: 20, Remaining: 0, RetryAfter: 12 * time.Second, Reset: 12 * time.Second},
+			wantStatus:     http.StatusTooManyRequests,
+			wantRemaining:  "0",
+			wantRetryAfter: "12",
+			wantReset:      12 * time.Second,
+		},
+		{
+			name:           "retry after rounded up",
+			policy:         "login",
+			result:         &redis.RateLimitResult{Allowed: false, Limit: 20, Remaining: 0, RetryAfter: 1500 * time.Millisecond, Reset: 1500 * time.Millisecond},
+			wantStatus:     http.StatusTooManyRequests,
+			wantRemaining:  "0",
+			wantRetryAfter: "2",
+			wantReset:      1500 * time.Millisecond,
+		},
+		{name: "redis down, fail closed", policy: "login", err: redisDown, wantStatus: http.StatusServiceUnavailable},
+		{name: "redis down, fail open", policy: "login", failOpen: true, err: redisDown, wantStatus: http.StatusOK},
+		{name: "route without policy", policy: "register", err: redisDown, wantStatus: http.StatusOK},
+		{name: "disabled policy", policy: "disabled", err: redisDown, wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeRateLimitRedis{result: tt.result, err: tt.err}
+			limiter := NewRateLimiter(fake, testRateLimitConf(tt.failOpen))
+
+			rec := httptest.NewRecorder()
+			limiter.Limit(tt.policy)(okHandler).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/auth/login", nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+
+			if got := rec.Header().Get("Retry-After"); got != tt.wantRetryAfter {
+				t.Fatalf("Retry-After = %q, want %q", got, tt.wantRetryAfter)
+			}
+
+			if tt.policy == "login" && fake.key != common.RateLimitKey+":login:ip:192.0.2.1" {
+				t.Fatalf("counter key = %q", fake.key)
+			}
+
+			if tt.result == nil {
+				if rec.Header().Get("X-RateLimit-Limit") != "" {
+					t.Fatal("rate limit headers set without a result")
+				}
+				return
+			}
+
+			if got := rec.Header().Get("X-RateLimit-Limit"); got != "20" {
+				t.Fatalf("X-RateLimit-Limit = %q, want 20", got)
+			}
+			if got := rec.Header().Get("X-RateLimit-Remaining"); got != tt.wantRemaining {
+				t.Fatalf("X-RateLimit-Remaining = %q, want %q", got, tt.wantRemaining)
+			}
+
+			reset, err := strconv.ParseInt(rec.Header().Get("X-RateLimit-Reset"), 10, 64)
+			if err != nil {
+				t.Fatalf("X-RateLimit-Reset: %v", err)
+			}
+			if want := time.Now().Add(tt.wantReset).Unix(); reset < want-1 || reset > want {
+				t.Fatalf("X-RateLimit-Reset = %d, want %d", reset, want)
+			}
+		})
+	}
+}
+
+func TestRateLimitIdentity(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		request func() *http.Request
+		want    string
+	}{
+		{
+			name: "ip from remote address",
+			key:  "ip",
+			request: func() *http.Request {
+				r := httptest.NewRequest(http.MethodPost, "/api/auth/login", nil)
+				r.RemoteAddr = "10.0.0.1:4321"
+				return r
+			},
+			want: "ip:10.0.0.1",
+		},
+		{
+			name: "ip from forwarded header",
+			key:  "ip",
+			request: func() *http.Request {
+				r := httptest.NewRequest(http.MethodPost, "/api/auth/login", nil)
+				r.Header.Set("X-Forwarded-For", "203.0.113.7, 10.0.0.1")
+				return r
+			},
+			want: "ip:203.0.113.7",
+		},
+		{
+			name: "invalid access token counted per ip",
+			key:  "account",
+			request: func() *http.Request {
+				r := httptest.NewRequest(http.MethodPut, "/api/auth/update-password", nil)
+				r.RemoteAddr = "10.0.0.1:4321"
+				r.Header.Set("Authorization", "Bearer not-a-token")
+				return r
+			},
+			want: "ip:10.0.0.1",
+		},
+		{
+			name: "client from basic auth",
+			key:  "client",
+			request: func() *http.Request {
+				r := httptest.NewRequest(http.MethodPost, "/oauth/token", nil)
+				r.RemoteAddr = "10.0.0.1:4321"
+				r.SetBasicAuth("my-client", "secret")
+				return r
+			},
+			want: "client:my-client:ip:10.0.0.1",
+		},
+		{
+			name: "client from form",
+			key:  "client",
+			request: func() *http.Request {
+				form := url.Values{"client_id": {"my-client"}}
+				r := httptest.NewRequest(http.MethodPost, "/oauth/token", strings.NewReader(form.Encode()))
+				r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+				r.Header.Set("X-Forwarded-For", "203.0.113.7")
+				return r
+			},
+			want: "client:my-client:ip:203.0.113.7",
+		},
+		{
+			name: "missing client counted per ip",
+			key:  "client",
+			request: func() *http.Request {
+				r := httptest.NewRequest(http.MethodPost, "/oauth/token", nil)
+				r.RemoteAddr = "10.0.0.1:4321"
+				return r
+			},
+			want: "ip:10.0.0.1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rateLimitIdentity(tt.request(), tt.key); got != tt.want {
+				t.Fatalf("rateLimitIdentity = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
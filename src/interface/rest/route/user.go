@@ -4,21 +4,49 @@ import (
 	"github.com/go-chi/chi/v5"
 	"net/http"
 
+	"go-auth-service/src/infra/config"
 	handlersUser "go-auth-service/src/interface/rest/handlers/user"
 )
 
-func UserRouter(h handlersUser.UserHandlerInterface) http.Handler {
+func UserRouter(h handlersUser.UserHandlerInterface, verification config.EmailVerificationConf, limiter *RateLimiter) http.Handler {
 	r := chi.NewRouter()
 
-	r.Post("/register", h.Register)
-	r.Post("/login", h.Login)
-	r.Get("/me", h.Me)
-	r.Get("/refresh-token", h.RefreshToken)
+	r.With(limiter.Limit("register")).Post("/register", h.Register)
+	r.With(limiter.Limit("login")).Post("/login", h.Login)
+	r.Post("/login/mfa", h.LoginMfa)
+	r.Post("/login/passkey/begin", h.BeginPasskeyLogin)
+	r.Post("/login/passkey/finish", h.FinishPasskeyLogin)
+	r.Post("/login/magic-link", h.RequestMagicLink)
+	r.Post("/login/magic-link/verify", h.LoginMagicLink)
+	r.Post("/login/sms", h.RequestSmsLogin)
+	r.Post("/login/sms/verify", h.LoginSms)
+	r.With(requireVerifiedEmail(verification, "me")).Get("/me", h.Me)
+	r.With(limiter.Limit("refresh-token")).Get("/refresh-token", h.RefreshToken)
 	r.Get("/logout", h.Logout)
-	r.Get("/revoke-token/{email-encrypt}", h.RevokeToken)
-	r.Put("/update-profile", h.UpdateProfile)
-	r.Put("/update-profile-picture", h.UpdateProfilePicture)
-	r.Put("/update-password", h.UpdatePassword)
+	r.Get("/revoke-token/{token}", h.ConfirmRevokeToken)
+	r.Post("/revoke-token/{token}", h.RevokeToken)
+	r.With(requireVerifiedEmail(verification, "update-profile")).Put("/update-profile", h.UpdateProfile)
+	r.With(requireVerifiedEmail(verification, "update-profile-picture")).Put("/update-profile-picture", h.UpdateProfilePicture)
+	r.With(limiter.Limit("update-password"), requireVerifiedEmail(verification, "update-password")).Put("/update-password", h.UpdatePassword)
+	r.Post("/forgot-password", h.ForgotPassword)
+	r.Post("/reset-password", h.ResetPassword)
+	r.Get("/verify-email", h.VerifyEmail)
+	r.Post("/resend-verification", h.ResendVerification)
+	r.With(requireVerifiedEmail(verification, "mfa")).Post("/mfa/totp/enroll", h.EnrollTotp)
+	r.With(requireVerifiedEmail(verification, "mfa")).Post("/mfa/totp/confirm", h.ConfirmTotp)
+	r.With(requireVerifiedEmail(verification, "mfa")).Post("/mfa/recovery-codes", h.RegenerateRecoveryCodes)
+	r.With(requireVerifiedEmail(verification, "passkeys")).Post("/passkeys/register/begin", h.BeginPasskeyRegistration)
+	r.With(requireVerifiedEmail(verification, "passkeys")).Post("/passkeys/register/finish", h.FinishPasskeyRegistration)
+	r.With(requireVerifiedEmail(verification, "passkeys")).Get("/passkeys", h.ListPasskeys)
+	r.With(requireVerifiedEmail(verification, "passkeys")).Delete("/passkeys/{id}", h.DeletePasskey)
+	r.With(requireVerifiedEmail(verification, "phone")).Post("/phone", h.RequestPhoneVerification)
+	r.With(requireVerifiedEmail(verification, "phone")).Post("/phone/verify", h.VerifyPhone)
+	r.With(requireVerifiedEmail(verification, "phone")).Delete("/phone", h.RemovePhone)
+	r.With(requireVerifiedEmail(verification, "sessions")).Get("/sessions", h.ListSessions)
+	r.With(requireVerifiedEmail(verification, "sessions")).Delete("/sessions", h.RevokeOtherSessions)
+	r.With(requireVerifiedEmail(verification, "sessions")).Delete("/sessions/{id}", h.RevokeSession)
+	r.Post("/admin/users/{id}/unlock", h.UnlockAccount)
+	r.Get("/admin/metrics/password-hash", h.PasswordHashMetrics)
 
 	return r
 }
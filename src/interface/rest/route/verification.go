@@ -0,0 +1,52 @@
+package route
+
+import (
+	"net/http"
+
+	"go-auth-service/src/infra/config"
+	"go-auth-service/src/infra/constants/common"
+	errorMessage "go-auth-service/src/infra/constants/error_message"
+	"go-auth-service/src/infra/helper"
+	"go-auth-service/src/interface/rest/response"
+)
+
+// requireVerifiedEmail menolak akun yang emailnya belum terverifikasi pada endpoint tertentu
+// jika EMAIL_VERIFICATION_POLICY=endpoints. Endpoint dipilih lewat EMAIL_VERIFICATION_ENDPOINTS;
+// jika kosong seluruh endpoint yang memakai middleware ini ikut dibatasi.
+// Token yang tidak valid diteruskan agar handler tetap mengembalikan error yang sama.
+func requireVerifiedEmail(conf config.EmailVerificationConf, endpoint string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if !verificationRequired(conf, endpoint) {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r = helper.WithRequestClaims(r)
+			claims, err := helper.RequestClaims(r)
+			if err == nil && !claims.Verified {
+				response.JSON(w, http.StatusForbidden, "error", errorMessage.EmailNotVerified, nil)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func verificationRequired(conf config.EmailVerificationConf, endpoint string) bool {
+	if conf.Policy != common.VerificationPolicyEndpoints {
+		return false
+	}
+
+	if len(conf.Endpoints) == 0 {
+		return true
+	}
+
+	for _, e := range conf.Endpoints {
+		if e == endpoint {
+			return true
+		}
+	}
+
+	return false
+}
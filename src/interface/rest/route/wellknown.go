@@ -0,0 +1,17 @@
+package route
+
+import (
+	"github.com/go-chi/chi/v5"
+	"net/http"
+
+	handlersWellKnown "go-auth-service/src/interface/rest/handlers/wellknown"
+)
+
+func WellKnownRouter(h handlersWellKnown.WellKnownHandlerInterface) http.Handler {
+	r := chi.NewRouter()
+
+	r.Get("/jwks.json", h.JWKS)
+	r.Get("/openid-configuration", h.OpenIDConfiguration)
+
+	return r
+}